@@ -0,0 +1,131 @@
+package audiotags
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"songLibrary/lyrics"
+
+	"github.com/dhowden/tag"
+)
+
+var ErrNoTags = errors.New("в файле нет тегов")
+
+// Расширения файлов, которые читает импорт
+var Extensions = []string{".mp3", ".flac", ".ogg", ".oga", ".opus"}
+
+// Track - теги одного файла, нужные библиотеке
+type Track struct {
+	Artist string
+	Title  string
+	Album  string
+	Year   int
+	// Полная дата в формате dd.mm.yyyy, если теги её содержат, иначе пусто
+	ReleaseDate string
+	TrackNumber int
+	DiscNumber  int
+	// Текст из USLT или комментария LYRICS
+	Lyrics string
+	// LRC из SYLT или из LYRICS с метками времени
+	SyncedLyrics string
+}
+
+// Supported - подходит ли файл для импорта по расширению
+func Supported(name string) bool {
+	return slices.Contains(Extensions, strings.ToLower(filepath.Ext(name)))
+}
+
+// Read читает ID3v2 (MP3) или Vorbis comment (FLAC, OGG)
+func Read(r io.ReadSeeker) (Track, error) {
+	m, err := tag.ReadFrom(r)
+	if err != nil {
+		if errors.Is(err, tag.ErrNoTagsFound) {
+			return Track{}, ErrNoTags
+		}
+		return Track{}, fmt.Errorf("не удалось прочитать теги: %w", err)
+	}
+
+	track := Track{
+		Artist: strings.TrimSpace(m.Artist()),
+		Title:  strings.TrimSpace(m.Title()),
+		Album:  strings.TrimSpace(m.Album()),
+		Year:   m.Year(),
+	}
+	if track.Artist == "" {
+		track.Artist = strings.TrimSpace(m.AlbumArtist())
+	}
+	track.TrackNumber, _ = m.Track()
+	track.DiscNumber, _ = m.Disc()
+
+	raw := m.Raw()
+
+	switch m.Format() {
+	case tag.ID3v2_2, tag.ID3v2_3, tag.ID3v2_4:
+		track.ReleaseDate = id3Date(raw)
+		track.Lyrics = lyrics.Normalize(m.Lyrics())
+		for _, name := range []string{"SYLT", "SLT"} {
+			if b, ok := raw[name].([]byte); ok {
+				track.SyncedLyrics = parseSYLT(b)
+				break
+			}
+		}
+
+	case tag.VORBIS:
+		track.ReleaseDate = isoDate(rawString(raw, "date"))
+		text := rawString(raw, "lyrics")
+		if text == "" {
+			text = rawString(raw, "unsyncedlyrics")
+		}
+		// В LYRICS часто кладут LRC, тогда это синхронизированный текст
+		if _, err := lyrics.ParseLRC(text); err == nil {
+			track.SyncedLyrics = text
+		} else {
+			track.Lyrics = lyrics.Normalize(text)
+		}
+	}
+
+	if track.Artist == "" && track.Title == "" {
+		return track, ErrNoTags
+	}
+
+	return track, nil
+}
+
+func rawString(raw map[string]any, key string) string {
+	s, _ := raw[key].(string)
+	return strings.TrimSpace(s)
+}
+
+// id3Date - полная дата из TDRC (2.4) или TYER с TDAT в формате DDMM (2.3)
+func id3Date(raw map[string]any) string {
+	if date := isoDate(rawString(raw, "TDRC")); date != "" {
+		return date
+	}
+
+	year, dayMonth := rawString(raw, "TYER"), rawString(raw, "TDAT")
+	if len(year) != 4 || len(dayMonth) != 4 {
+		return ""
+	}
+	t, err := time.Parse("02012006", dayMonth+year)
+	if err != nil {
+		return ""
+	}
+	return t.Format("02.01.2006")
+}
+
+// isoDate переводит yyyy-mm-dd (в том числе с временем) в dd.mm.yyyy, неполные даты отбрасывает
+func isoDate(value string) string {
+	if len(value) < 10 {
+		return ""
+	}
+	t, err := time.Parse(time.DateOnly, value[:10])
+	if err != nil {
+		return ""
+	}
+	return t.Format("02.01.2006")
+}
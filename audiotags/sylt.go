@@ -0,0 +1,109 @@
+package audiotags
+
+import (
+	"encoding/binary"
+	"strings"
+	"unicode/utf16"
+
+	"songLibrary/lyrics"
+)
+
+// Кодировки текста во фреймах ID3v2
+const (
+	encodingISO8859 = 0
+	encodingUTF16   = 1
+	encodingUTF16BE = 2
+	encodingUTF8    = 3
+)
+
+// Формат меток времени SYLT: 1 - кадры MPEG, 2 - миллисекунды
+const syltMillis = 2
+
+// parseSYLT переводит фрейм SYLT в LRC. Фрейм: кодировка, язык, формат меток, тип
+// содержимого, описание, затем пары "текст с терминатором + 4 байта времени".
+// Метки в кадрах MPEG не переводятся, такой фрейм пропускается.
+func parseSYLT(b []byte) string {
+	if len(b) < 6 || b[4] != syltMillis {
+		return ""
+	}
+	enc := b[0]
+
+	// Описание не нужно
+	_, rest, ok := cutText(b[6:], enc)
+	if !ok {
+		return ""
+	}
+
+	var lines []lyrics.TimedLine
+	for len(rest) > 0 {
+		text, tail, ok := cutText(rest, enc)
+		if !ok || len(tail) < 4 {
+			break
+		}
+		start := int64(binary.BigEndian.Uint32(tail[:4]))
+		rest = tail[4:]
+
+		// Обычно строка начинается с перевода строки, слоги одной строки идут без него
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		lines = append(lines, lyrics.TimedLine{StartMs: start, Text: text})
+	}
+
+	if len(lines) == 0 {
+		return ""
+	}
+	return lyrics.FormatLRC(nil, lines)
+}
+
+// cutText отрезает строку до терминатора: 0x00 или 0x0000 для UTF-16
+func cutText(b []byte, enc byte) (string, []byte, bool) {
+	if enc == encodingUTF16 || enc == encodingUTF16BE {
+		for i := 0; i+1 < len(b); i += 2 {
+			if b[i] == 0 && b[i+1] == 0 {
+				return decodeUTF16(b[:i], enc), b[i+2:], true
+			}
+		}
+		return "", nil, false
+	}
+
+	for i, c := range b {
+		if c == 0 {
+			return decodeText(b[:i], enc), b[i+1:], true
+		}
+	}
+	return "", nil, false
+}
+
+func decodeText(b []byte, enc byte) string {
+	if enc == encodingUTF8 {
+		return string(b)
+	}
+
+	// ISO-8859-1 совпадает с первыми 256 кодами Unicode
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+// decodeUTF16 учитывает BOM, без него (кодировка 2) порядок байтов big-endian
+func decodeUTF16(b []byte, enc byte) string {
+	var order binary.ByteOrder = binary.BigEndian
+	if enc == encodingUTF16 && len(b) >= 2 {
+		switch {
+		case b[0] == 0xFF && b[1] == 0xFE:
+			order, b = binary.LittleEndian, b[2:]
+		case b[0] == 0xFE && b[1] == 0xFF:
+			b = b[2:]
+		}
+	}
+
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		units[i] = order.Uint16(b[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
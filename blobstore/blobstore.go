@@ -0,0 +1,105 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"songLibrary/initializers"
+	"strings"
+)
+
+var (
+	ErrNotFound   = errors.New("файл не найден")
+	ErrInvalidKey = errors.New("некорректный ключ файла")
+)
+
+// Store хранит файлы по ключу вида "songs/1/cover.jpg". Ручки работают только
+// с интерфейсом, поэтому локальный диск можно заменить S3-совместимым хранилищем.
+type Store interface {
+	Put(ctx context.Context, key string, data []byte) error
+	// Open возвращает ErrNotFound, если файла нет
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete не считает ошибкой отсутствие файла
+	Delete(ctx context.Context, key string) error
+}
+
+// New создаёт хранилище по конфигу
+func New(config initializers.MediaConfig) (Store, error) {
+	switch config.Storage {
+	case initializers.MediaStorageLocal:
+		return NewLocal(config.Path), nil
+	}
+	return nil, fmt.Errorf("неизвестное хранилище файлов: %s", config.Storage)
+}
+
+// Local хранит файлы в каталоге на диске. Каталоги создаются при первой записи,
+// чтобы команды, которым файлы не нужны, ничего не создавали.
+type Local struct {
+	root string
+}
+
+func NewLocal(root string) *Local {
+	return &Local{root: root}
+}
+
+// path переводит ключ в путь внутри каталога, ключи с выходом за его пределы отклоняются
+func (s *Local) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if key == "" || clean == "/" || strings.Contains(key, "\\") || clean != "/"+key {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(clean)), nil
+}
+
+// Put пишет во временный файл и переименовывает его, чтобы читатели не увидели файл наполовину
+func (s *Local) Put(ctx context.Context, key string, data []byte) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+func (s *Local) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	target, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(target)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return f, err
+}
+
+func (s *Local) Delete(ctx context.Context, key string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
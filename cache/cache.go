@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cache хранит готовые ответы в сериализованном виде, поэтому реализацию
+// можно заменить внешним хранилищем (Redis и т.п.) без изменения ручек.
+// Каждая запись может иметь теги, по которым она инвалидируется.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string)
+	InvalidateTags(ctx context.Context, tags ...string)
+}
+
+// Теги записей
+const (
+	TagSongsList = "songs:list"
+	// Ответы с названиями жанров и тегов, сбрасываются при их переименовании и удалении
+	TagTaxonomy = "taxonomy"
+)
+
+func TagSong(id any) string {
+	return "song:" + toString(id)
+}
+
+func TagGroup(id any) string {
+	return "group:" + toString(id)
+}
+
+// Key строит ключ из префикса и параметров запроса: параметры сортируются,
+// пустые отбрасываются, значения обрезаются по пробелам
+func Key(prefix string, params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for k, values := range params {
+		for _, v := range values {
+			if strings.TrimSpace(v) != "" {
+				keys = append(keys, k)
+				break
+			}
+		}
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(prefix)
+	for _, k := range keys {
+		values := make([]string, 0, len(params[k]))
+		for _, v := range params[k] {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+		sort.Strings(values)
+
+		b.WriteByte('|')
+		b.WriteString(url.QueryEscape(k))
+		b.WriteByte('=')
+		for i, v := range values {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(url.QueryEscape(v))
+		}
+	}
+	return b.String()
+}
+
+// Noop - кеш, который ничего не хранит
+type Noop struct{}
+
+func (Noop) Get(context.Context, string) ([]byte, bool)                    { return nil, false }
+func (Noop) Set(context.Context, string, []byte, time.Duration, ...string) {}
+func (Noop) InvalidateTags(context.Context, ...string)                     {}
+
+func toString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
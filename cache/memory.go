@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+	tags      []string
+}
+
+// Memory - LRU-кеш в памяти процесса с TTL и тегами
+type Memory struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List
+	entries map[string]*list.Element
+	tags    map[string]map[string]struct{}
+}
+
+// NewMemory создаёт кеш на size записей, ttl используется, если в Set передан 0
+func NewMemory(size int, ttl time.Duration) *Memory {
+	return &Memory{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+		tags:    make(map[string]map[string]struct{}),
+	}
+}
+
+func (m *Memory) Get(_ context.Context, key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	el, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	e := el.Value.(*entry)
+	if time.Now().After(e.expiresAt) {
+		m.remove(el)
+		return nil, false
+	}
+
+	m.order.MoveToFront(el)
+	return e.value, true
+}
+
+func (m *Memory) Set(_ context.Context, key string, value []byte, ttl time.Duration, tags ...string) {
+	if ttl <= 0 {
+		ttl = m.ttl
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if el, ok := m.entries[key]; ok {
+		m.remove(el)
+	}
+
+	e := &entry{key: key, value: value, expiresAt: time.Now().Add(ttl), tags: tags}
+	m.entries[key] = m.order.PushFront(e)
+	for _, tag := range tags {
+		if m.tags[tag] == nil {
+			m.tags[tag] = make(map[string]struct{})
+		}
+		m.tags[tag][key] = struct{}{}
+	}
+
+	for m.order.Len() > m.size {
+		m.remove(m.order.Back())
+	}
+}
+
+func (m *Memory) InvalidateTags(_ context.Context, tags ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range m.tags[tag] {
+			if el, ok := m.entries[key]; ok {
+				m.remove(el)
+			}
+		}
+		delete(m.tags, tag)
+	}
+}
+
+// remove вызывается под mu
+func (m *Memory) remove(el *list.Element) {
+	e := m.order.Remove(el).(*entry)
+	delete(m.entries, e.key)
+
+	for _, tag := range e.tags {
+		delete(m.tags[tag], e.key)
+		if len(m.tags[tag]) == 0 {
+			delete(m.tags, tag)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package chords
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"songLibrary/lyrics"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var ErrInvalidChordPro = errors.New("некорректный ChordPro")
+
+// {name} или {name: value}
+var directiveRegexp = regexp.MustCompile(`^\{\s*([a-zA-Z_]+)\s*(?::\s*(.*?))?\s*\}$`)
+
+// Окружения ChordPro
+const (
+	EnvChorus = "chorus"
+	EnvVerse  = "verse"
+	EnvBridge = "bridge"
+	EnvTab    = "tab"
+)
+
+// Position - аккорд над символом строки, Pos считается в символах, а не байтах
+type Position struct {
+	Pos   int
+	Chord string
+}
+
+// Line - строка текста без аккордов и аккорды над ней
+type Line struct {
+	Text   string
+	Chords []Position
+}
+
+// Block - часть песни: окружение {start_of_...} или абзац между пустыми строками
+type Block struct {
+	// Окружение, пустое для обычного абзаца
+	Env string
+	// Из {start_of_chorus: Label} или предшествующего {comment: Label}
+	Label string
+	Lines []Line
+	// {chorus} - повтор последнего припева без текста
+	Repeat bool
+}
+
+// Sheet - разобранный ChordPro
+type Sheet struct {
+	Title  string
+	Artist string
+	Key    string
+	Capo   int
+	Blocks []Block
+}
+
+// Parse разбирает ChordPro: директивы метаданных, окружения, комментарии как метки частей
+// и аккорды в квадратных скобках внутри строк
+func Parse(src string) (Sheet, error) {
+	var sheet Sheet
+
+	var env, pendingLabel string
+	var current *Block
+
+	flush := func() {
+		if current != nil && len(current.Lines) > 0 {
+			sheet.Blocks = append(sheet.Blocks, *current)
+		}
+		current = nil
+	}
+
+	for _, raw := range strings.Split(lyrics.Normalize(src), "\n") {
+		if strings.HasPrefix(raw, "#") {
+			continue
+		}
+		if raw == "" {
+			// Внутри окружения пустые строки не делят часть
+			if env == "" {
+				flush()
+			}
+			continue
+		}
+
+		if m := directiveRegexp.FindStringSubmatch(raw); m != nil {
+			name, value := strings.ToLower(m[1]), m[2]
+
+			switch name {
+			case "title", "t":
+				sheet.Title = value
+			case "artist", "subtitle", "st":
+				if sheet.Artist == "" {
+					sheet.Artist = value
+				}
+			case "key":
+				sheet.Key = value
+			case "capo":
+				capo, err := strconv.Atoi(value)
+				if err != nil || capo < 0 {
+					return sheet, fmt.Errorf("%w: capo %s", ErrInvalidChordPro, value)
+				}
+				sheet.Capo = capo
+			case "comment", "c", "comment_italic", "ci", "comment_box", "cb", "highlight":
+				flush()
+				pendingLabel = value
+			case "start_of_chorus", "soc", "start_of_verse", "sov", "start_of_bridge", "sob", "start_of_tab", "sot":
+				flush()
+				env = envName(name)
+				current = &Block{Env: env, Label: firstNonEmpty(value, pendingLabel)}
+				pendingLabel = ""
+			case "end_of_chorus", "eoc", "end_of_verse", "eov", "end_of_bridge", "eob", "end_of_tab", "eot":
+				flush()
+				env = ""
+			case "chorus":
+				flush()
+				sheet.Blocks = append(sheet.Blocks, Block{Env: EnvChorus, Label: value, Repeat: true})
+			}
+			continue
+		}
+
+		if current == nil {
+			current = &Block{Env: env, Label: pendingLabel}
+			pendingLabel = ""
+		}
+		current.Lines = append(current.Lines, parseLine(raw))
+	}
+	flush()
+
+	if len(sheet.textBlocks()) == 0 {
+		return sheet, fmt.Errorf("%w: нет строк текста", ErrInvalidChordPro)
+	}
+
+	return sheet, nil
+}
+
+func envName(directive string) string {
+	switch directive {
+	case "start_of_chorus", "soc":
+		return EnvChorus
+	case "start_of_verse", "sov":
+		return EnvVerse
+	case "start_of_bridge", "sob":
+		return EnvBridge
+	default:
+		return EnvTab
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// parseLine отделяет аккорды от текста строки
+func parseLine(raw string) Line {
+	var line Line
+	var text strings.Builder
+	pos := 0
+
+	for len(raw) > 0 {
+		if raw[0] == '[' {
+			if end := strings.IndexByte(raw, ']'); end > 0 {
+				if chord := strings.TrimSpace(raw[1:end]); chord != "" {
+					line.Chords = append(line.Chords, Position{Pos: pos, Chord: chord})
+				}
+				raw = raw[end+1:]
+				continue
+			}
+		}
+
+		r, size := utf8.DecodeRuneInString(raw)
+		text.WriteRune(r)
+		pos++
+		raw = raw[size:]
+	}
+
+	// Пробелы в начале строки сдвигают аккорды
+	full := text.String()
+	trimmed := strings.TrimLeft(full, " \t")
+	shift := utf8.RuneCountInString(full) - utf8.RuneCountInString(trimmed)
+	for i := range line.Chords {
+		line.Chords[i].Pos = max(line.Chords[i].Pos-shift, 0)
+	}
+	line.Text = strings.TrimRight(trimmed, " \t")
+
+	return line
+}
+
+// textBlocks - части, которые попадают в текст песни: с непустыми строками или повторы
+func (s Sheet) textBlocks() []Block {
+	var blocks []Block
+	for _, block := range s.Blocks {
+		if block.Repeat || len(block.textLines()) > 0 {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks
+}
+
+// textLines - строки с текстом, строки из одних аккордов пропускаются
+func (b Block) textLines() []Line {
+	var lines []Line
+	for _, line := range b.Lines {
+		if line.Text != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// marker - метка части для текста песни. Метка без известных слов заменяется
+// названием окружения, чтобы тип части не потерялся.
+func (b Block) marker() string {
+	if _, _, ok := lyrics.Classify(b.Label); ok {
+		return b.Label
+	}
+	switch b.Env {
+	case EnvChorus:
+		return "Chorus"
+	case EnvBridge:
+		return "Bridge"
+	}
+	return ""
+}
+
+// LyricsText - текст песни без аккордов с метками частей, разбирается lyrics.Parse
+// в части по одной на блок
+func (s Sheet) LyricsText() string {
+	var parts []string
+	for _, block := range s.textBlocks() {
+		var b strings.Builder
+		if marker := block.marker(); marker != "" {
+			b.WriteString("[" + marker + "]\n")
+		}
+		for _, line := range block.textLines() {
+			b.WriteString(line.Text + "\n")
+		}
+		parts = append(parts, strings.TrimSuffix(b.String(), "\n"))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// VerseChord - аккорд в части текста: номер строки части и символ в строке
+type VerseChord struct {
+	Line  int
+	Pos   int
+	Chord string
+}
+
+// SectionChords раскладывает аккорды по частям, полученным из lyrics.Parse(s.LyricsText()).
+// Повторы берут аккорды первого вхождения. Если части не совпали с блоками, возвращает nil.
+func (s Sheet) SectionChords(sections []lyrics.Section) [][]VerseChord {
+	blocks := s.textBlocks()
+	if len(blocks) != len(sections) {
+		return nil
+	}
+
+	result := make([][]VerseChord, len(sections))
+	for i, section := range sections {
+		source := blocks[i]
+		if section.RepeatOf >= 0 {
+			source = blocks[section.RepeatOf]
+		}
+		for lineIndex, line := range source.textLines() {
+			for _, chord := range line.Chords {
+				result[i] = append(result[i], VerseChord{Line: lineIndex, Pos: chord.Pos, Chord: chord.Chord})
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,312 @@
+package chords
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+// Форматы вывода
+const (
+	FormatChordPro = "chordpro"
+	FormatText     = "text"
+	FormatHTML     = "html"
+)
+
+// Options - транспонирование при выводе
+type Options struct {
+	// Сдвиг звучания в полутонах
+	Transpose int
+	// AccidentalsAuto, AccidentalsSharp или AccidentalsFlat
+	Accidentals string
+	// Лад каподастра для аппликатур, nil - как в исходнике
+	Capo *int
+}
+
+// Transposed возвращает копию с транспонированными аккордами. Тональность сдвигается
+// на Transpose, а аккорды ещё и пересчитываются под каподастр: на n-м ладу играются
+// аппликатуры на n полутонов ниже звучания.
+func (s Sheet) Transposed(opts Options) Sheet {
+	shift := opts.Transpose
+	result := s
+	if opts.Capo != nil {
+		shift += s.Capo - *opts.Capo
+		result.Capo = *opts.Capo
+	}
+
+	// Без явного выбора знаки берутся по новой тональности
+	accidentals := opts.Accidentals
+	if flats, ok := keyFlats(s.Key, opts.Transpose); ok && accidentals == AccidentalsAuto {
+		accidentals = AccidentalsSharp
+		if flats {
+			accidentals = AccidentalsFlat
+		}
+	}
+	if s.Key != "" && (opts.Transpose != 0 || opts.Accidentals != AccidentalsAuto) {
+		result.Key = Transpose(s.Key, opts.Transpose, accidentals)
+	}
+
+	// Без сдвига и явного выбора знаков аккорды остаются как в исходнике
+	if shift == 0 && opts.Accidentals == AccidentalsAuto {
+		return result
+	}
+
+	result.Blocks = make([]Block, len(s.Blocks))
+	for i, block := range s.Blocks {
+		block.Lines = make([]Line, len(s.Blocks[i].Lines))
+		for j, line := range s.Blocks[i].Lines {
+			line.Chords = make([]Position, len(line.Chords))
+			for k, chord := range s.Blocks[i].Lines[j].Chords {
+				line.Chords[k] = Position{Pos: chord.Pos, Chord: Transpose(chord.Chord, shift, accidentals)}
+			}
+			block.Lines[j] = line
+		}
+		result.Blocks[i] = block
+	}
+	return result
+}
+
+// Render выводит песню в одном из форматов
+func (s Sheet) Render(format string) (string, error) {
+	switch format {
+	case FormatChordPro:
+		return s.ChordPro(), nil
+	case FormatText:
+		return s.Text(), nil
+	case FormatHTML:
+		return s.HTML(), nil
+	default:
+		return "", fmt.Errorf("неизвестный формат: %s", format)
+	}
+}
+
+// Heading - заголовок части при выводе
+func (b Block) Heading() string {
+	if b.Label != "" {
+		return b.Label
+	}
+	switch b.Env {
+	case EnvChorus:
+		return "Chorus"
+	case EnvBridge:
+		return "Bridge"
+	}
+	return ""
+}
+
+// ChordPro собирает исходник обратно
+func (s Sheet) ChordPro() string {
+	var b strings.Builder
+
+	for _, directive := range [][2]string{{"title", s.Title}, {"artist", s.Artist}, {"key", s.Key}} {
+		if directive[1] != "" {
+			fmt.Fprintf(&b, "{%s: %s}\n", directive[0], directive[1])
+		}
+	}
+	if s.Capo > 0 {
+		fmt.Fprintf(&b, "{capo: %d}\n", s.Capo)
+	}
+
+	for _, block := range s.Blocks {
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+
+		switch {
+		case block.Repeat:
+			b.WriteString(directive("chorus", block.Label))
+			continue
+		case block.Env != "":
+			b.WriteString(directive("start_of_"+block.Env, block.Label))
+		case block.Label != "":
+			b.WriteString(directive("comment", block.Label))
+		}
+
+		for _, line := range block.Lines {
+			b.WriteString(inlineChords(line) + "\n")
+		}
+
+		if block.Env != "" {
+			b.WriteString(directive("end_of_"+block.Env, ""))
+		}
+	}
+
+	return b.String()
+}
+
+func directive(name, value string) string {
+	if value == "" {
+		return "{" + name + "}\n"
+	}
+	return "{" + name + ": " + value + "}\n"
+}
+
+// inlineChords вставляет аккорды в строку в квадратных скобках
+func inlineChords(line Line) string {
+	text := []rune(line.Text)
+
+	var b strings.Builder
+	prev := 0
+	for _, chord := range line.Chords {
+		pos := min(chord.Pos, len(text))
+		b.WriteString(string(text[prev:pos]))
+		b.WriteString("[" + chord.Chord + "]")
+		prev = pos
+	}
+	b.WriteString(string(text[prev:]))
+
+	return b.String()
+}
+
+// Text - текст с аккордами над строками, для моноширинного шрифта
+func (s Sheet) Text() string {
+	var b strings.Builder
+
+	if s.Title != "" {
+		b.WriteString(s.Title + "\n")
+	}
+	if s.Artist != "" {
+		b.WriteString(s.Artist + "\n")
+	}
+	if meta := s.Meta(); meta != "" {
+		b.WriteString(meta + "\n")
+	}
+
+	for _, block := range s.Blocks {
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		if label := block.Heading(); label != "" {
+			b.WriteString("[" + label + "]\n")
+		}
+
+		for _, line := range block.Lines {
+			if len(line.Chords) > 0 {
+				b.WriteString(chordLine(line) + "\n")
+			}
+			if line.Text != "" {
+				b.WriteString(line.Text + "\n")
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// Meta - тональность и каподастр одной строкой
+func (s Sheet) Meta() string {
+	var parts []string
+	if s.Key != "" {
+		parts = append(parts, "Key: "+s.Key)
+	}
+	if s.Capo > 0 {
+		parts = append(parts, fmt.Sprintf("Capo: %d", s.Capo))
+	}
+	return strings.Join(parts, "  ")
+}
+
+// chordLine ставит аккорды над символами строки. Если аккорды не помещаются,
+// следующий сдвигается вправо, чтобы между ними оставался пробел.
+func chordLine(line Line) string {
+	var b strings.Builder
+	col := 0
+	for _, chord := range line.Chords {
+		if chord.Pos > col {
+			b.WriteString(strings.Repeat(" ", chord.Pos-col))
+			col = chord.Pos
+		} else if col > 0 {
+			b.WriteByte(' ')
+			col++
+		}
+		b.WriteString(chord.Chord)
+		col += utf8.RuneCountInString(chord.Chord)
+	}
+	return b.String()
+}
+
+// HTML - страница с аккордами над слогами, подходит для печати
+func (s Sheet) HTML() string {
+	var b strings.Builder
+
+	title := html.EscapeString(s.Title)
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	b.WriteString("<title>" + title + "</title>\n")
+	b.WriteString("<style>\n" + HTMLStyle + "</style>\n</head>\n<body>\n<div class=\"song\">\n")
+	b.WriteString(s.HTMLFragment())
+	b.WriteString("</div>\n</body>\n</html>\n")
+
+	return b.String()
+}
+
+// HTMLFragment - содержимое песни без обёртки страницы
+func (s Sheet) HTMLFragment() string {
+	var b strings.Builder
+
+	if s.Title != "" {
+		b.WriteString("<h1 class=\"title\">" + html.EscapeString(s.Title) + "</h1>\n")
+	}
+	if s.Artist != "" {
+		b.WriteString("<h2 class=\"artist\">" + html.EscapeString(s.Artist) + "</h2>\n")
+	}
+	if meta := s.Meta(); meta != "" {
+		b.WriteString("<p class=\"meta\">" + html.EscapeString(meta) + "</p>\n")
+	}
+
+	for _, block := range s.Blocks {
+		class := block.Env
+		if class == "" {
+			class = "paragraph"
+		}
+		b.WriteString("<section class=\"" + class + "\">\n")
+		if label := block.Heading(); label != "" {
+			b.WriteString("<h3 class=\"label\">" + html.EscapeString(label) + "</h3>\n")
+		}
+		for _, line := range block.Lines {
+			b.WriteString("<div class=\"line\">" + htmlLine(line) + "</div>\n")
+		}
+		b.WriteString("</section>\n")
+	}
+
+	return b.String()
+}
+
+// htmlLine делит строку на куски, у каждого куска свой аккорд сверху
+func htmlLine(line Line) string {
+	text := []rune(line.Text)
+
+	var b strings.Builder
+	chunk := func(chord string, part []rune) {
+		b.WriteString("<span class=\"chunk\">")
+		if chord != "" {
+			b.WriteString("<span class=\"chord\">" + html.EscapeString(chord) + "</span>")
+		}
+		b.WriteString("<span class=\"lyric\">" + html.EscapeString(string(part)) + "</span></span>")
+	}
+
+	prev := 0
+	chord := ""
+	for _, position := range line.Chords {
+		pos := min(position.Pos, len(text))
+		if pos > prev || chord != "" {
+			chunk(chord, text[prev:pos])
+		}
+		prev, chord = pos, position.Chord
+	}
+	if prev < len(text) || chord != "" {
+		chunk(chord, text[prev:])
+	}
+
+	return b.String()
+}
+
+// HTMLStyle - стили HTMLFragment, для страниц с несколькими песнями
+const HTMLStyle = `.song { font-family: sans-serif; }
+.meta { color: #555; }
+.line { white-space: pre; margin: 0.2em 0; }
+.chunk { display: inline-flex; flex-direction: column; vertical-align: bottom; }
+.chord { font-weight: bold; color: #b00; padding-right: 0.3em; }
+.lyric:empty::after { content: "\00a0"; }
+.chorus { margin-left: 1.5em; }
+.label { font-size: 1em; font-style: italic; }
+`
@@ -0,0 +1,122 @@
+package chords
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Запись знаков альтерации при транспонировании
+const (
+	// Как в исходном аккорде, или по тональности, если она указана
+	AccidentalsAuto  = ""
+	AccidentalsSharp = "sharp"
+	AccidentalsFlat  = "flat"
+)
+
+var (
+	sharpNotes = []string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+	flatNotes  = []string{"C", "Db", "D", "Eb", "E", "F", "Gb", "G", "Ab", "A", "Bb", "B"}
+
+	// Основной тон, качество и бас: C#m7/G#
+	chordRegexp = regexp.MustCompile(`^([A-G])([#b]?)([^/]*)(?:/([A-G])([#b]?))?$`)
+
+	// Мажорные и минорные тональности, которые принято записывать с бемолями, по номеру тоники
+	flatMajorKeys = map[int]bool{1: true, 3: true, 5: true, 8: true, 10: true}
+	flatMinorKeys = map[int]bool{0: true, 2: true, 3: true, 5: true, 7: true, 10: true}
+)
+
+// noteIndex - номер ноты в октаве от C
+func noteIndex(letter, accidental string) int {
+	index := 0
+	for i, note := range sharpNotes {
+		if note == letter {
+			index = i
+			break
+		}
+	}
+	switch accidental {
+	case "#":
+		index++
+	case "b":
+		index--
+	}
+	return (index + 12) % 12
+}
+
+func noteName(index int, flats bool) string {
+	if flats {
+		return flatNotes[index]
+	}
+	return sharpNotes[index]
+}
+
+// Transpose сдвигает аккорд на semitones полутонов. Аккорды, которые не разобрались
+// (N.C., x), возвращаются как есть.
+func Transpose(chord string, semitones int, accidentals string) string {
+	m := chordRegexp.FindStringSubmatch(chord)
+	if m == nil {
+		return chord
+	}
+
+	flats := accidentals == AccidentalsFlat || accidentals == AccidentalsAuto && m[2] == "b"
+	shift := ((semitones % 12) + 12) % 12
+
+	result := noteName((noteIndex(m[1], m[2])+shift)%12, flats) + m[3]
+	if m[4] != "" {
+		result += "/" + noteName((noteIndex(m[4], m[5])+shift)%12, flats)
+	}
+	return result
+}
+
+// parseKey разбирает тональность: F, Bb, Dm, Am7, "G minor". Минорной считается тональность,
+// качество которой начинается с m, но не с maj, или с min в любом регистре: M7 и maj7 - мажор.
+// ok = false, если не разобралась.
+func parseKey(key string) (tonic int, minor, ok bool) {
+	key = strings.ReplaceAll(strings.TrimSpace(key), " ", "")
+	m := chordRegexp.FindStringSubmatch(key)
+	if m == nil {
+		return 0, false, false
+	}
+
+	quality := m[3]
+	minor = strings.HasPrefix(quality, "m") && !strings.HasPrefix(quality, "maj") ||
+		strings.HasPrefix(strings.ToLower(quality), "min")
+	return noteIndex(m[1], m[2]), minor, true
+}
+
+// keyFlats сообщает, записывается ли тональность key, сдвинутая на semitones, с бемолями.
+// ok = false, если тональность не разобралась.
+func keyFlats(key string, semitones int) (flats, ok bool) {
+	tonic, minor, ok := parseKey(key)
+	if !ok {
+		return false, false
+	}
+
+	tonic = (tonic + ((semitones%12)+12)%12) % 12
+	if minor {
+		return flatMinorKeys[tonic], true
+	}
+	return flatMajorKeys[tonic], true
+}
+
+// ValidKey сообщает, разбирается ли тональность
+func ValidKey(key string) bool {
+	_, _, ok := parseKey(key)
+	return ok
+}
+
+// KeyInterval - сдвиг в полутонах от тональности from к тональности to, от -5 до 6,
+// лад не учитывается. ok = false, если одна из тональностей не разобралась.
+func KeyInterval(from, to string) (int, bool) {
+	fromTonic, _, okFrom := parseKey(from)
+	toTonic, _, okTo := parseKey(to)
+	if !okFrom || !okTo {
+		return 0, false
+	}
+
+	shift := (toTonic - fromTonic + 12) % 12
+	if shift > 6 {
+		shift -= 12
+	}
+	return shift, true
+}
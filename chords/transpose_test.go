@@ -0,0 +1,157 @@
+package chords
+
+import "testing"
+
+func TestTranspose(t *testing.T) {
+	tests := []struct {
+		chord       string
+		semitones   int
+		accidentals string
+		want        string
+	}{
+		{"C", 2, AccidentalsAuto, "D"},
+		{"C", 1, AccidentalsAuto, "C#"},
+		{"Bb", 1, AccidentalsAuto, "B"},
+		{"Bb", 2, AccidentalsAuto, "C"},
+		{"Eb", 1, AccidentalsAuto, "E"},
+		{"Db", 1, AccidentalsAuto, "D"},
+		{"Ab", 1, AccidentalsAuto, "A"},
+		{"Db", 2, AccidentalsAuto, "Eb"},
+		{"C", 1, AccidentalsFlat, "Db"},
+		{"Bb", 1, AccidentalsSharp, "B"},
+		{"Bb", 0, AccidentalsSharp, "A#"},
+		{"C#m7", 0, AccidentalsFlat, "Dbm7"},
+		{"Am7", 3, AccidentalsAuto, "Cm7"},
+		{"Cmaj7", 4, AccidentalsAuto, "Emaj7"},
+		{"C/E", 2, AccidentalsAuto, "D/F#"},
+		{"Am/G", 2, AccidentalsFlat, "Bm/A"},
+		{"D/F#", -2, AccidentalsAuto, "C/E"},
+		{"Bb/D", 1, AccidentalsSharp, "B/D#"},
+		{"C", -1, AccidentalsAuto, "B"},
+		{"C", -2, AccidentalsFlat, "Bb"},
+		{"F#", -13, AccidentalsAuto, "F"},
+		{"G", 12, AccidentalsAuto, "G"},
+		{"Cb", 0, AccidentalsSharp, "B"},
+		{"N.C.", 2, AccidentalsAuto, "N.C."},
+		{"x", 2, AccidentalsAuto, "x"},
+		{"H7", 2, AccidentalsAuto, "H7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.chord, func(t *testing.T) {
+			if got := Transpose(tt.chord, tt.semitones, tt.accidentals); got != tt.want {
+				t.Errorf("Transpose(%q, %d, %q) = %q; want %q", tt.chord, tt.semitones, tt.accidentals, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		key   string
+		tonic int
+		minor bool
+		ok    bool
+	}{
+		{"C", 0, false, true},
+		{"Bb", 10, false, true},
+		{"Dm", 2, true, true},
+		{"Am7", 9, true, true},
+		{"F#m7b5", 6, true, true},
+		{"Cmin", 0, true, true},
+		{"G minor", 7, true, true},
+		{"E Minor", 4, true, true},
+		{"Cmaj7", 0, false, true},
+		{"CM7", 0, false, true},
+		{"A major", 9, false, true},
+		{"H", 0, false, false},
+		{"", 0, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			tonic, minor, ok := parseKey(tt.key)
+			if tonic != tt.tonic || minor != tt.minor || ok != tt.ok {
+				t.Errorf("parseKey(%q) = %d, %v, %v; want %d, %v, %v", tt.key, tonic, minor, ok, tt.tonic, tt.minor, tt.ok)
+			}
+		})
+	}
+}
+
+func TestKeyFlats(t *testing.T) {
+	tests := []struct {
+		key       string
+		semitones int
+		flats     bool
+		ok        bool
+	}{
+		{"C", 0, false, true},
+		{"F", 0, true, true},
+		{"C", 5, true, true},
+		{"C", -2, true, true},
+		{"G", 2, false, true},
+		{"Dm", 0, true, true},
+		{"Am", 0, false, true},
+		{"Am", -2, true, true},
+		{"Am7", -2, true, true},
+		{"Em", 5, false, true},
+		{"Cmaj7", 2, false, true},
+		{"N.C.", 0, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			flats, ok := keyFlats(tt.key, tt.semitones)
+			if flats != tt.flats || ok != tt.ok {
+				t.Errorf("keyFlats(%q, %d) = %v, %v; want %v, %v", tt.key, tt.semitones, flats, ok, tt.flats, tt.ok)
+			}
+		})
+	}
+}
+
+func TestKeyInterval(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     int
+		ok       bool
+	}{
+		{"C", "D", 2, true},
+		{"C", "Bb", -2, true},
+		{"G", "C", 5, true},
+		{"C", "F#", 6, true},
+		{"C", "G", -5, true},
+		{"Am", "Cm", 3, true},
+		{"Db", "C#", 0, true},
+		{"C", "N.C.", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+"→"+tt.to, func(t *testing.T) {
+			got, ok := KeyInterval(tt.from, tt.to)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("KeyInterval(%q, %q) = %d, %v; want %d, %v", tt.from, tt.to, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestTransposedKeyAccidentals(t *testing.T) {
+	tests := []struct {
+		name      string
+		key       string
+		chord     string
+		semitones int
+		want      string
+	}{
+		{"мажор вниз на тон - бемоли по тональности", "C", "A#", -2, "Ab"},
+		{"Am7 вниз на тон - Gm, бемоли", "Am7", "F", -2, "Eb"},
+		{"Am7 вверх на полутон - Bbm, бемоли", "Am7", "C", 1, "Db"},
+		{"Em7 вверх на кварту - Am, диезы", "Em7", "D#", 5, "G#"},
+		{"Bm7 вверх на малую терцию - Dm, бемоли", "Bm7", "G", 3, "Bb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sheet := Sheet{Key: tt.key, Blocks: []Block{{Lines: []Line{{Chords: []Position{{Chord: tt.chord}}}}}}}
+			got := sheet.Transposed(Options{Transpose: tt.semitones}).Blocks[0].Lines[0].Chords[0].Chord
+			if got != tt.want {
+				t.Errorf("Transposed(%q в %q, %d) = %q; want %q", tt.chord, tt.key, tt.semitones, got, tt.want)
+			}
+		})
+	}
+}
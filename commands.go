@@ -0,0 +1,541 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"songLibrary/audiotags"
+	"songLibrary/blobstore"
+	"songLibrary/cache"
+	"songLibrary/initializers"
+	"songLibrary/logging"
+	"songLibrary/services"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// bootstrap настраивает логи и подключается к уже мигрированной БД
+func bootstrap(config initializers.Config) (*services.Library, error) {
+	if err := logging.Setup(config.Log); err != nil {
+		return nil, err
+	}
+
+	initializers.HTTPClient.Timeout = config.ExternalAPI.Timeout
+
+	if err := initializers.ConnectDB(config.DB); err != nil {
+		return nil, err
+	}
+
+	if err := initializers.CheckSchemaVersion(context.Background()); err != nil {
+		initializers.CloseDB(context.Background())
+		return nil, fmt.Errorf("%w, выполните команду migrate", err)
+	}
+
+	store, err := blobstore.New(config.Media)
+	if err != nil {
+		initializers.CloseDB(context.Background())
+		return nil, err
+	}
+
+	// Кеш ответов нужен только серверу, у команд свой короткоживущий процесс
+	library, err := services.NewLibrary(initializers.DB, config.ExternalAPI, config.Explicit, cache.Noop{}, store)
+	if err != nil {
+		initializers.CloseDB(context.Background())
+		return nil, err
+	}
+	return library, nil
+}
+
+// commandContext отменяется по SIGINT/SIGTERM
+func commandContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+func fail(err error) int {
+	log.WithError(err).Error("Команда завершилась с ошибкой")
+	writeJSON(map[string]string{"error": err.Error()})
+	return exitFailure
+}
+
+func runMigrate(args []string) int {
+	config, err := newCommandFlags("migrate").parse(args)
+	if err != nil {
+		return reportConfigError(err)
+	}
+
+	if err := logging.Setup(config.Log); err != nil {
+		return fail(err)
+	}
+
+	if err := initializers.Migrate(config.DB); err != nil {
+		return fail(err)
+	}
+	defer initializers.CloseDB(context.Background())
+
+	writeJSON(map[string]int{"schema_version": initializers.SchemaVersion})
+	return exitOK
+}
+
+func runSeed(args []string) int {
+	config, err := newCommandFlags("seed").parse(args)
+	if err != nil {
+		return reportConfigError(err)
+	}
+
+	library, err := bootstrap(config)
+	if err != nil {
+		return fail(err)
+	}
+	defer initializers.CloseDB(context.Background())
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	var result services.ImportResult
+	for _, record := range services.SeedRecords {
+		if err := library.ImportRecord(ctx, record, false); err != nil {
+			if errors.Is(err, services.ErrSongExists) {
+				result.Skipped++
+				continue
+			}
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("%s - %s: %s", record.Group, record.Song, err))
+			continue
+		}
+		result.Created++
+	}
+
+	writeJSON(result)
+	if result.Failed > 0 {
+		return exitPartial
+	}
+	return exitOK
+}
+
+func runImport(args []string) int {
+	fs := newCommandFlags("import")
+	file := fs.String("file", "-", "Файл JSON Lines, - для stdin")
+	fetchMissing := fs.Bool("fetch-missing", true, "Запрашивать у внешнего API песни без даты, ссылки и текста")
+
+	config, err := fs.parse(args)
+	if err != nil {
+		return reportConfigError(err)
+	}
+
+	library, err := bootstrap(config)
+	if err != nil {
+		return fail(err)
+	}
+	defer initializers.CloseDB(context.Background())
+
+	var r io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return fail(err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	result, err := library.Import(ctx, r, *fetchMissing)
+	if err != nil {
+		return fail(err)
+	}
+
+	writeJSON(result)
+	if result.Failed > 0 {
+		return exitPartial
+	}
+	return exitOK
+}
+
+func runImportAudio(args []string) int {
+	fs := newCommandFlags("import-audio")
+	dir := fs.String("dir", "", "Каталог с MP3/FLAC/OGG, обходится рекурсивно (обязательно)")
+	fetchMissing := fs.Bool("fetch-missing", false, "Запрашивать у провайдеров песни без текста в тегах (по умолчанию audio_import.fetch_missing)")
+
+	config, err := fs.parse(args)
+	if err != nil {
+		return reportConfigError(err)
+	}
+	if *dir == "" {
+		fmt.Fprintln(os.Stderr, "укажите -dir")
+		return exitUsage
+	}
+	if !flagPassed(fs.FlagSet, "fetch-missing") {
+		*fetchMissing = config.AudioImport.FetchMissing
+	}
+
+	var files []services.AudioFile
+	err = filepath.WalkDir(*dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || !audiotags.Supported(path) {
+			return nil
+		}
+		name, err := filepath.Rel(*dir, path)
+		if err != nil {
+			name = path
+		}
+		files = append(files, services.AudioFile{Name: name, Open: func() (io.ReadSeekCloser, error) {
+			return os.Open(path)
+		}})
+		return nil
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	library, err := bootstrap(config)
+	if err != nil {
+		return fail(err)
+	}
+	defer initializers.CloseDB(context.Background())
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	result := library.ImportAudio(ctx, files, *fetchMissing)
+
+	writeJSON(result)
+	if result.Failed > 0 {
+		return exitPartial
+	}
+	return exitOK
+}
+
+func runExport(args []string) int {
+	fs := newCommandFlags("export")
+	file := fs.String("file", "-", "Файл для записи JSON Lines, - для stdout")
+
+	config, err := fs.parse(args)
+	if err != nil {
+		return reportConfigError(err)
+	}
+
+	library, err := bootstrap(config)
+	if err != nil {
+		return fail(err)
+	}
+	defer initializers.CloseDB(context.Background())
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	if *file == "-" {
+		exported, err := library.Export(ctx, os.Stdout)
+		if err != nil {
+			log.WithError(err).Error("Экспорт прерван")
+			return exitFailure
+		}
+		log.WithField("exported", exported).Info("Экспорт завершён") // Info-лог
+		return exitOK
+	}
+
+	f, err := os.Create(*file)
+	if err != nil {
+		return fail(err)
+	}
+	defer f.Close()
+
+	exported, err := library.Export(ctx, f)
+	if err != nil {
+		return fail(err)
+	}
+
+	writeJSON(map[string]any{"exported": exported, "file": *file})
+	return exitOK
+}
+
+func runEnrich(args []string) int {
+	fs := newCommandFlags("enrich")
+	all := fs.Bool("all", false, "Проверить все песни, а не только с пустыми полями")
+	overwrite := fs.Bool("overwrite", false, "Перезаписывать заполненные поля")
+
+	config, err := fs.parse(args)
+	if err != nil {
+		return reportConfigError(err)
+	}
+
+	library, err := bootstrap(config)
+	if err != nil {
+		return fail(err)
+	}
+	defer initializers.CloseDB(context.Background())
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	result, err := library.Enrich(ctx, *all, *overwrite)
+	if err != nil {
+		return fail(err)
+	}
+
+	writeJSON(result)
+	if result.Failed > 0 {
+		return exitPartial
+	}
+	return exitOK
+}
+
+func runRefresh(args []string) int {
+	fs := newCommandFlags("refresh")
+	maxAge := fs.Duration("max-age", 0, "Сверять песни, которые не проверялись дольше (по умолчанию refresh.max_age)")
+	limit := fs.Int("limit", 0, "Сколько песен проверить (по умолчанию refresh.batch_size)")
+
+	config, err := fs.parse(args)
+	if err != nil {
+		return reportConfigError(err)
+	}
+	if *maxAge == 0 {
+		*maxAge = config.Refresh.MaxAge
+	}
+	if *limit == 0 {
+		*limit = config.Refresh.BatchSize
+	}
+
+	library, err := bootstrap(config)
+	if err != nil {
+		return fail(err)
+	}
+	defer initializers.CloseDB(context.Background())
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	result, err := library.Refresh(ctx, *maxAge, *limit)
+	if err != nil {
+		return fail(err)
+	}
+
+	writeJSON(result)
+	if result.Failed > 0 {
+		return exitPartial
+	}
+	return exitOK
+}
+
+func runReparseLyrics(args []string) int {
+	fs := newCommandFlags("reparse-lyrics")
+
+	config, err := fs.parse(args)
+	if err != nil {
+		return reportConfigError(err)
+	}
+
+	library, err := bootstrap(config)
+	if err != nil {
+		return fail(err)
+	}
+	defer initializers.CloseDB(context.Background())
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	reparsed, err := library.ReparseLyrics(ctx)
+	if err != nil {
+		return fail(err)
+	}
+
+	writeJSON(map[string]any{"reparsed": reparsed})
+	return exitOK
+}
+
+func runScanExplicit(args []string) int {
+	fs := newCommandFlags("scan-explicit")
+
+	config, err := fs.parse(args)
+	if err != nil {
+		return reportConfigError(err)
+	}
+
+	library, err := bootstrap(config)
+	if err != nil {
+		return fail(err)
+	}
+	defer initializers.CloseDB(context.Background())
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	result, err := library.ScanExplicit(ctx)
+	if err != nil {
+		return fail(err)
+	}
+
+	writeJSON(result)
+	return exitOK
+}
+
+func runSyncLinks(args []string) int {
+	fs := newCommandFlags("sync-links")
+
+	config, err := fs.parse(args)
+	if err != nil {
+		return reportConfigError(err)
+	}
+
+	library, err := bootstrap(config)
+	if err != nil {
+		return fail(err)
+	}
+	defer initializers.CloseDB(context.Background())
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	synced, err := library.SyncLinks(ctx)
+	if err != nil {
+		return fail(err)
+	}
+
+	writeJSON(map[string]any{"synced": synced})
+	return exitOK
+}
+
+func runCheckLinks(args []string) int {
+	fs := newCommandFlags("check-links")
+	limit := fs.Int("limit", 0, "Сколько ссылок проверить (по умолчанию link_check.batch_size)")
+
+	config, err := fs.parse(args)
+	if err != nil {
+		return reportConfigError(err)
+	}
+	if *limit > 0 {
+		config.LinkCheck.BatchSize = *limit
+	}
+
+	library, err := bootstrap(config)
+	if err != nil {
+		return fail(err)
+	}
+	defer initializers.CloseDB(context.Background())
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	result, err := library.CheckLinks(ctx, config.LinkCheck)
+	if err != nil {
+		return fail(err)
+	}
+
+	writeJSON(result)
+	return exitOK
+}
+
+func runPurgeTrash(args []string) int {
+	fs := newCommandFlags("purge-trash")
+	olderThan := fs.Duration("older-than", 0, "Удалять записи, удалённые раньше, чем столько назад (например 720h)")
+	dryRun := fs.Bool("dry-run", false, "Только посчитать записи")
+
+	config, err := fs.parse(args)
+	if err != nil {
+		return reportConfigError(err)
+	}
+
+	library, err := bootstrap(config)
+	if err != nil {
+		return fail(err)
+	}
+	defer initializers.CloseDB(context.Background())
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	result, err := library.PurgeTrash(ctx, time.Now().Add(-*olderThan), *dryRun)
+	if err != nil {
+		return fail(err)
+	}
+
+	writeJSON(result)
+	return exitOK
+}
+
+func runCreateAPIKey(args []string) int {
+	fs := newCommandFlags("create-api-key")
+	name := fs.String("name", "", "Имя ключа (обязательно)")
+
+	config, err := fs.parse(args)
+	if err != nil {
+		return reportConfigError(err)
+	}
+	if *name == "" {
+		fmt.Fprintln(os.Stderr, "укажите -name")
+		return exitUsage
+	}
+
+	library, err := bootstrap(config)
+	if err != nil {
+		return fail(err)
+	}
+	defer initializers.CloseDB(context.Background())
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	key, err := library.CreateAPIKey(ctx, *name)
+	if err != nil {
+		return fail(err)
+	}
+
+	// Ключ показывается один раз, в БД хранится только хеш
+	writeJSON(map[string]string{"name": *name, "key": key})
+	return exitOK
+}
+
+func runCheckConfig(args []string) int {
+	_, err := newCommandFlags("check-config").parse(args)
+	if errors.Is(err, errUsage) {
+		return exitUsage
+	}
+
+	var validationErr *initializers.ValidationError
+	switch {
+	case err == nil:
+		writeJSON(map[string]any{"valid": true})
+		return exitOK
+	case errors.As(err, &validationErr):
+		writeJSON(map[string]any{"valid": false, "problems": validationErr.Problems})
+	default:
+		writeJSON(map[string]any{"valid": false, "problems": []string{err.Error()}})
+	}
+	return exitUsage
+}
+
+func runConfig(args []string) int {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprintln(os.Stderr, "Использование: songLibrary config print [флаги]")
+		return exitUsage
+	}
+
+	// config print - вывести итоговый конфиг без секретов
+	config, err := newCommandFlags("config print").parse(args[1:])
+	if errors.Is(err, errUsage) {
+		return exitUsage
+	}
+
+	out, marshalErr := config.YAML()
+	if marshalErr != nil {
+		fmt.Fprintln(os.Stderr, marshalErr)
+		return exitFailure
+	}
+	fmt.Print(string(out))
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	return exitOK
+}
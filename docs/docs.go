@@ -15,67 +15,119 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/v1/library/songs": {
+        "/api/v1/admin/external-cache": {
             "get": {
-                "description": "**Получения списка песен**",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "**Ответы провайдеров, сохранённые в БД. group и song ищутся по подстроке без учёта регистра**",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Song"
+                    "Admin"
                 ],
-                "summary": "Получения списка песен",
+                "summary": "Сохранённые ответы провайдеров",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Имя провайдера",
+                        "name": "provider",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
                         "description": "Название группы",
-                        "name": "groupName",
+                        "name": "group",
                         "in": "query"
                     },
                     {
                         "type": "string",
                         "description": "Название песни",
-                        "name": "songTitle",
+                        "name": "song",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Дата выпуска песни",
-                        "name": "releaseDate",
+                        "description": "Страница",
+                        "name": "page",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Ссылка на песню",
-                        "name": "link",
+                        "description": "Ограничение вывода",
+                        "name": "limit",
                         "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.ExternalAPIResponsesList"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный API-ключ",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
                     },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "**Удалить ответы для пары group+song, одного провайдера, только устаревшие (expired=true) или все**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Очистить сохранённые ответы провайдеров",
+                "parameters": [
                     {
                         "type": "string",
-                        "description": "Фрагмент текста песни",
-                        "name": "lyrics",
+                        "description": "Имя провайдера",
+                        "name": "provider",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Страница",
-                        "name": "page",
-                        "in": "query",
-                        "required": true
+                        "description": "Название группы",
+                        "name": "group",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Ограничение вывода",
-                        "name": "limit",
-                        "in": "query",
-                        "required": true
+                        "description": "Название песни",
+                        "name": "song",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Только устаревшие",
+                        "name": "expired",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Успешный ответ",
+                        "description": "Количество удалённых записей",
                         "schema": {
-                            "$ref": "#/definitions/models.SongsList"
+                            "$ref": "#/definitions/models.PurgedCount"
                         }
                     },
                     "400": {
@@ -84,6 +136,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/utils.ProblemDetails"
                         }
                     },
+                    "401": {
+                        "description": "Нет или неверный API-ключ",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -93,40 +151,44 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/library/songs/:id/lyrics": {
-            "get": {
-                "description": "**Получение текста песни**",
+        "/api/v1/admin/import/audio": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "**Создаёт песни из тегов ID3v2 и Vorbis comment (MP3, FLAC, OGG): исполнитель, название, альбом, дата, текст из USLT/LYRICS и синхронизированный текст из SYLT. Провайдеров спрашиваем только о песнях без текста в тегах. Песни, которые уже есть, пропускаются, расхождения тегов с ними - в conflicts.**",
+                "consumes": [
+                    "multipart/form-data"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Song"
+                    "Admin"
                 ],
-                "summary": "Получение текста песни",
+                "summary": "Импорт из тегов аудиофайлов",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Страница",
-                        "name": "page",
-                        "in": "query",
+                        "type": "file",
+                        "description": "Аудиофайлы, поле можно повторять",
+                        "name": "files",
+                        "in": "formData",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Ограничение вывода",
-                        "name": "limit",
-                        "in": "query",
-                        "required": true
+                        "type": "boolean",
+                        "description": "Запрашивать у провайдеров песни без текста в тегах (по умолчанию audio_import.fetch_missing)",
+                        "name": "fetch_missing",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Успешный ответ",
+                        "description": "Итоги импорта",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Lyrics"
-                            }
+                            "$ref": "#/definitions/services.AudioImportResult"
                         }
                     },
                     "400": {
@@ -135,8 +197,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/utils.ProblemDetails"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Нет или неверный API-ключ",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "413": {
+                        "description": "Файлы больше audio_import.max_upload",
                         "schema": {
                             "$ref": "#/definitions/utils.ProblemDetails"
                         }
@@ -144,9 +212,48 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/library/songs/add": {
-            "post": {
-                "description": "**Добавить песню**",
+        "/api/v1/admin/log-levels": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "**Текущие уровни логирования по пакетам**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Уровни логирования",
+                "responses": {
+                    "200": {
+                        "description": "Уровни по пакетам",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный API-ключ",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/log-levels/{logger}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "**Изменить уровень логирования пакета без перезапуска**",
                 "consumes": [
                     "application/json"
                 ],
@@ -154,25 +261,98 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Song"
+                    "Admin"
                 ],
-                "summary": "Добавить песню",
+                "summary": "Изменить уровень логирования",
                 "parameters": [
                     {
-                        "description": "Информация о песне",
+                        "type": "string",
+                        "description": "Имя логгера (app, handlers, db, http, ...)",
+                        "name": "logger",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новый уровень",
                         "name": "Request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Input"
+                            "$ref": "#/definitions/models.LogLevel"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Уровни по пакетам",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный API-ключ",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
                         }
                     }
+                }
+            }
+        },
+        "/api/v1/admin/proposals": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "**Изменения от провайдеров для полей, которые правили вручную**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Предложенные изменения",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "pending, accepted или rejected",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID песни",
+                        "name": "song_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Страница",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ограничение вывода",
+                        "name": "limit",
+                        "in": "query"
+                    }
                 ],
                 "responses": {
                     "200": {
                         "description": "Успешный ответ",
                         "schema": {
-                            "$ref": "#/definitions/utils.RespOK"
+                            "$ref": "#/definitions/models.SongProposalsList"
                         }
                     },
                     "400": {
@@ -181,8 +361,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/utils.ProblemDetails"
                         }
                     },
-                    "409": {
-                        "description": "Песня уже существует",
+                    "401": {
+                        "description": "Нет или неверный API-ключ",
                         "schema": {
                             "$ref": "#/definitions/utils.ProblemDetails"
                         }
@@ -196,25 +376,48 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/library/songs/delete/:id": {
-            "delete": {
-                "description": "**Удаление песни**",
+        "/api/v1/admin/proposals/:id/accept": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "**Записать предложенное значение в песню**",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Song"
+                    "Admin"
                 ],
-                "summary": "Удаление песни",
+                "summary": "Принять изменение",
                 "responses": {
                     "200": {
                         "description": "Успешный ответ",
                         "schema": {
-                            "$ref": "#/definitions/utils.RespOK"
+                            "$ref": "#/definitions/models.SongProposal"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный API-ключ",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
                         }
                     },
                     "404": {
-                        "description": "Песня не найдена",
+                        "description": "Предложение или песня не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Предложение уже рассмотрено",
                         "schema": {
                             "$ref": "#/definitions/utils.ProblemDetails"
                         }
@@ -228,35 +431,26 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/library/songs/edit/:id": {
-            "put": {
-                "description": "**Редактирование данных песни, текста**",
-                "consumes": [
-                    "application/json"
+        "/api/v1/admin/proposals/:id/reject": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "**Отклонить предложение, это значение больше не будет предлагаться**",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Song"
-                ],
-                "summary": "Редактирование песни",
-                "parameters": [
-                    {
-                        "description": "Новая информация о песне",
-                        "name": "Request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.Edit"
-                        }
-                    }
+                    "Admin"
                 ],
+                "summary": "Отклонить изменение",
                 "responses": {
                     "200": {
                         "description": "Успешный ответ",
                         "schema": {
-                            "$ref": "#/definitions/models.Song"
+                            "$ref": "#/definitions/models.SongProposal"
                         }
                     },
                     "400": {
@@ -265,8 +459,20 @@ const docTemplate = `{
                             "$ref": "#/definitions/utils.ProblemDetails"
                         }
                     },
+                    "401": {
+                        "description": "Нет или неверный API-ключ",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
                     "404": {
-                        "description": "песня не найдена",
+                        "description": "Предложение не найдено",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Предложение уже рассмотрено",
                         "schema": {
                             "$ref": "#/definitions/utils.ProblemDetails"
                         }
@@ -279,50 +485,4887 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.Edit": {
-            "type": "object",
+        },
+        "/api/v1/library/albums": {
+            "get": {
+                "description": "**Альбомы с фильтрами по группе, названию и типу**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Albums"
+                ],
+                "summary": "Список альбомов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Название группы",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Название альбома",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "lp",
+                            "ep",
+                            "single",
+                            "compilation"
+                        ],
+                        "type": "string",
+                        "description": "Тип альбома",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Страница",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ограничение вывода",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.AlbumsList"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "**Группа указывается по имени и создаётся, если её нет. Тип по умолчанию lp.**",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Albums"
+                ],
+                "summary": "Добавить альбом",
+                "parameters": [
+                    {
+                        "description": "Альбом",
+                        "name": "Request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AlbumInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.Album"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "У группы уже есть альбом с таким названием",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/albums/:id": {
+            "get": {
+                "description": "**Альбом с песнями в порядке дисков и треков**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Albums"
+                ],
+                "summary": "Получение альбома",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.Album"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Альбом не найден",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "**Заменяет название, группу, дату выпуска и тип альбома**",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Albums"
+                ],
+                "summary": "Обновить альбом",
+                "parameters": [
+                    {
+                        "description": "Альбом",
+                        "name": "Request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AlbumInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.Album"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Альбом не найден",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "У группы уже есть альбом с таким названием",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "**Удаляет альбом, песни остаются в библиотеке без альбома**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Albums"
+                ],
+                "summary": "Удалить альбом",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/utils.RespOK"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Альбом не найден",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/albums/:id/tracks": {
+            "post": {
+                "description": "**Без номера трека песня встаёт в конец диска, диск по умолчанию первый. Песня другой группы допускается только в сборнике.**",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Albums"
+                ],
+                "summary": "Добавить песню в альбом",
+                "parameters": [
+                    {
+                        "description": "Трек",
+                        "name": "Request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TrackInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.Song"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Альбом или песня не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Номер трека уже занят",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/albums/:id/tracks/:song_id": {
+            "delete": {
+                "description": "**Песня остаётся в библиотеке, номер трека и диска сбрасываются**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Albums"
+                ],
+                "summary": "Убрать песню из альбома",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/utils.RespOK"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Песни нет в альбоме",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/genres": {
+            "get": {
+                "description": "**Корневые жанры с поджанрами в children**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Taxonomy"
+                ],
+                "summary": "Дерево жанров",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Genre"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "**slug строится из названия. parent_id делает жанр поджанром.**",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Taxonomy"
+                ],
+                "summary": "Добавить жанр",
+                "parameters": [
+                    {
+                        "description": "Жанр",
+                        "name": "Request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.GenreInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.Genre"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Родительский жанр не найден",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Жанр уже есть",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/genres/:id": {
+            "put": {
+                "description": "**Переименовать жанр или перенести под другого родителя, без parent_id жанр становится корневым**",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Taxonomy"
+                ],
+                "summary": "Обновить жанр",
+                "parameters": [
+                    {
+                        "description": "Жанр",
+                        "name": "Request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.GenreInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.Genre"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Жанр не найден",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Жанр уже есть",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "**Поджанры переходят к родителю удалённого жанра**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Taxonomy"
+                ],
+                "summary": "Удалить жанр",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/utils.RespOK"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Жанр не найден",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/groups/:id/genres": {
+            "put": {
+                "description": "**Заменяет жанры группы. В фильтрах и фасетах они достаются всем песням группы.**",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Taxonomy"
+                ],
+                "summary": "Жанры группы",
+                "parameters": [
+                    {
+                        "description": "Жанры",
+                        "name": "Request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.GenresInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Genre"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Группа или жанр не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/groups/:id/photo": {
+            "put": {
+                "description": "**Заменяет фотографию группы. Тип определяется по содержимому: JPEG, PNG, GIF, WebP. Ссылки на оригинал и миниатюру - в поле photo группы.**",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Images"
+                ],
+                "summary": "Загрузить фотографию группы",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Изображение",
+                        "name": "image",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.Image"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Группа не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "413": {
+                        "description": "Файл больше media.max_upload",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "415": {
+                        "description": "Не изображение или неподдерживаемый формат",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "**Удаляет фотографию группы вместе с файлами**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Images"
+                ],
+                "summary": "Удалить фотографию группы",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/utils.RespOK"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Группа или фотография не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/groups/:id/tags": {
+            "put": {
+                "description": "**Заменяет теги группы, новые теги создаются. В фильтрах и фасетах они достаются всем песням группы.**",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Taxonomy"
+                ],
+                "summary": "Теги группы",
+                "parameters": [
+                    {
+                        "description": "Теги",
+                        "name": "Request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TagsInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Tag"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Группа не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/images/:id": {
+            "get": {
+                "description": "**Оригинал загруженного изображения. Содержимое по ID не меняется, новая загрузка получает новый ID, поэтому ответ кешируется надолго.**",
+                "produces": [
+                    "image/jpeg",
+                    "image/png",
+                    "image/gif",
+                    "image/webp"
+                ],
+                "tags": [
+                    "Images"
+                ],
+                "summary": "Изображение",
+                "responses": {
+                    "200": {
+                        "description": "Изображение",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Изображение не найдено",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/images/:id/thumbnail": {
+            "get": {
+                "description": "**Миниатюра изображения в JPEG, вписана в квадрат media.thumbnail_size**",
+                "produces": [
+                    "image/jpeg"
+                ],
+                "tags": [
+                    "Images"
+                ],
+                "summary": "Миниатюра",
+                "responses": {
+                    "200": {
+                        "description": "Миниатюра",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Изображение не найдено",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/playlists": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "**Публичные плейлисты и, с API-ключом, плейлисты владельца ключа**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlists"
+                ],
+                "summary": "Список плейлистов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Страница",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ограничение вывода",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistsList"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверный API-ключ",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "**Владельцем становится API-ключ запроса. Видимость по умолчанию private, повторы по умолчанию разрешены.**",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlists"
+                ],
+                "summary": "Создать плейлист",
+                "parameters": [
+                    {
+                        "description": "Плейлист",
+                        "name": "Request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.Playlist"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный API-ключ",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/playlists/:id": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "**Плейлист с песнями по порядку. shared-плейлист открывается по token из share_token.**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlists"
+                ],
+                "summary": "Получение плейлиста",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Токен ссылки для shared-плейлиста",
+                        "name": "token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.Playlist"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Плейлист не найден",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "**Название, описание, видимость и политика повторов. Менять может только владелец.**",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlists"
+                ],
+                "summary": "Обновить плейлист",
+                "parameters": [
+                    {
+                        "description": "Плейлист",
+                        "name": "Request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.Playlist"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "Плейлист чужой",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Плейлист не найден",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "**Удалить может только владелец**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlists"
+                ],
+                "summary": "Удалить плейлист",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/utils.RespOK"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "Плейлист чужой",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Плейлист не найден",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/playlists/:id/entries": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "**Без позиции песня встаёт в конец. Повтор песни: allow - добавляется, skip - игнорируется, reject - 409.**",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlists"
+                ],
+                "summary": "Добавить песню в плейлист",
+                "parameters": [
+                    {
+                        "description": "Песня",
+                        "name": "Request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistEntryInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.Playlist"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "Плейлист чужой",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Плейлист или песня не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Песня уже есть в плейлисте",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/playlists/:id/entries/:entry_id": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "**Позиции следующих песен сдвигаются**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlists"
+                ],
+                "summary": "Убрать песню из плейлиста",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.Playlist"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "Плейлист чужой",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Плейлист или песня не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/playlists/:id/entries/:entry_id/position": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "**Позиция за концом плейлиста переносит песню в конец**",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlists"
+                ],
+                "summary": "Переместить песню в плейлисте",
+                "parameters": [
+                    {
+                        "description": "Новая позиция",
+                        "name": "Request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PositionInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.Playlist"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "Плейлист чужой",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Плейлист или песня не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/playlists/:id/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "**M3U8 или XSPF со ссылками песен. Песни без ссылок пропускаются.**",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Playlists"
+                ],
+                "summary": "Экспорт плейлиста",
+                "parameters": [
+                    {
+                        "enum": [
+                            "m3u8",
+                            "xspf"
+                        ],
+                        "type": "string",
+                        "description": "m3u8 (по умолчанию) или xspf",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Токен ссылки для shared-плейлиста",
+                        "name": "token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Плейлист",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Плейлист не найден",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/setlists": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "**Сетлисты владельца API-ключа, без песен**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Setlists"
+                ],
+                "summary": "Список сетлистов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Страница",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ограничение вывода",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.SetlistsList"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный API-ключ",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "**Пустой сетлист выступления, владельцем становится API-ключ запроса**",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Setlists"
+                ],
+                "summary": "Создать сетлист",
+                "parameters": [
+                    {
+                        "description": "Сетлист",
+                        "name": "Request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SetlistInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.Setlist"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный API-ключ",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/setlists/:id": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "**Песни по порядку и общая длительность. Песни без длительности в сумму не входят, их число - в untimed_entries.**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Setlists"
+                ],
+                "summary": "Получение сетлиста",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.Setlist"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Сетлист не найден",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "**Название, площадка и дата выступления**",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Setlists"
+                ],
+                "summary": "Обновить сетлист",
+                "parameters": [
+                    {
+                        "description": "Сетлист",
+                        "name": "Request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SetlistInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.Setlist"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Сетлист не найден",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "**Удаляет сетлист вместе с его песнями, сами песни остаются в библиотеке**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Setlists"
+                ],
+                "summary": "Удалить сетлист",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/utils.RespOK"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Сетлист не найден",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/setlists/:id/entries": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "**Без позиции песня встаёт в конец. key - тональность выступления, например Am или Bb, duration_sec - ожидаемая длительность.**",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Setlists"
+                ],
+                "summary": "Добавить песню в сетлист",
+                "parameters": [
+                    {
+                        "description": "Песня",
+                        "name": "Request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SetlistEntryInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.Setlist"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Сетлист или песня не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "В сетлисте слишком много песен",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/setlists/:id/entries/:entry_id": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "**Заменяет заметки, тональность и длительность. Без duration_sec длительность сбрасывается.**",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Setlists"
+                ],
+                "summary": "Изменить песню сетлиста",
+                "parameters": [
+                    {
+                        "description": "Заметки, тональность, длительность",
+                        "name": "Request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SetlistEntryUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.Setlist"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Сетлист или песня не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "**Позиции следующих песен сдвигаются**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Setlists"
+                ],
+                "summary": "Убрать песню из сетлиста",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.Setlist"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Сетлист или песня не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/setlists/:id/entries/:entry_id/position": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "**Позиция за концом сетлиста переносит песню в конец**",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Setlists"
+                ],
+                "summary": "Переместить песню в сетлисте",
+                "parameters": [
+                    {
+                        "description": "Новая позиция",
+                        "name": "Request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PositionInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.Setlist"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Сетлист или песня не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/setlists/:id/print": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "**Порядок песен с тональностями и длительностями, затем каждая песня: аккорды в тональности выступления, если загружен ChordPro, иначе текст**",
+                "produces": [
+                    "text/html",
+                    "text/plain"
+                ],
+                "tags": [
+                    "Setlists"
+                ],
+                "summary": "Печатная версия сетлиста",
+                "parameters": [
+                    {
+                        "enum": [
+                            "html",
+                            "text"
+                        ],
+                        "type": "string",
+                        "description": "html (по умолчанию) или text",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Сетлист не найден",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/songbooks": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "**Ставит сборку PDF или EPUB в очередь: оглавление, каждая песня с новой страницы, аккорды, если загружен ChordPro. Песни берутся из одного источника: song_ids, playlist_id, album_id или фильтров group, genre, tag. Статус - в GET /songbooks/:id, файл - в /songbooks/:id/download.**",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Songbooks"
+                ],
+                "summary": "Собрать песенник",
+                "parameters": [
+                    {
+                        "description": "Песенник",
+                        "name": "Request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SongbookInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Задание в очереди",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongbookJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный API-ключ",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "Приватный плейлист без токена",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня, плейлист или альбом не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/songbooks/:id": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "**pending - в очереди, running - собирается, done - можно скачать, failed - ошибка в error. Файл хранится до expires_at.**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Songbooks"
+                ],
+                "summary": "Статус песенника",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongbookJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Песенник не найден",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/songbooks/:id/download": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "**Готовый PDF или EPUB. Пока сборка идёт или если она не удалась - 409.**",
+                "produces": [
+                    "application/pdf",
+                    "application/epub+zip"
+                ],
+                "tags": [
+                    "Songbooks"
+                ],
+                "summary": "Скачать песенник",
+                "responses": {
+                    "200": {
+                        "description": "Песенник",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Песенник не найден",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Песенник ещё не собран или сборка не удалась",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/songs": {
+            "get": {
+                "description": "**Получения списка песен**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Song"
+                ],
+                "summary": "Получения списка песен",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Название группы",
+                        "name": "groupName",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Название песни",
+                        "name": "songTitle",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата выпуска песни",
+                        "name": "releaseDate",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ссылка на песню",
+                        "name": "link",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Фрагмент текста песни",
+                        "name": "lyrics",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Любой участник песни: основной исполнитель, приглашённый, автор",
+                        "name": "artist",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Жанры через запятую по slug, с поджанрами и жанрами группы",
+                        "name": "genres",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "any",
+                            "all"
+                        ],
+                        "type": "string",
+                        "description": "any - любой из жанров (по умолчанию), all - все",
+                        "name": "genre_mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Теги через запятую, вместе с тегами группы",
+                        "name": "tags",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "any",
+                            "all"
+                        ],
+                        "type": "string",
+                        "description": "any - любой из тегов (по умолчанию), all - все",
+                        "name": "tag_mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID альбома, песни идут в порядке треков",
+                        "name": "album_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Название альбома",
+                        "name": "album",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "lp",
+                            "ep",
+                            "single",
+                            "compilation"
+                        ],
+                        "type": "string",
+                        "description": "Тип альбома",
+                        "name": "album_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "true - только песни с ненормативной лексикой, false - только без неё",
+                        "name": "explicit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Страница",
+                        "name": "page",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ограничение вывода",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongsList"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/songs/:id": {
+            "get": {
+                "description": "**Получение песни с текстом**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Song"
+                ],
+                "summary": "Получение песни",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.Song"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/songs/:id/chords": {
+            "get": {
+                "description": "**Песня с аккордами в ChordPro, тексте с аккордами над строками или HTML. transpose сдвигает звучание на полутоны, capo пересчитывает аппликатуры под каподастр.**",
+                "produces": [
+                    "text/plain",
+                    "text/html"
+                ],
+                "tags": [
+                    "Chords"
+                ],
+                "summary": "Текст с аккордами",
+                "parameters": [
+                    {
+                        "enum": [
+                            "chordpro",
+                            "text",
+                            "html"
+                        ],
+                        "type": "string",
+                        "description": "chordpro (по умолчанию), text или html",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Сдвиг в полутонах, от -11 до 11",
+                        "name": "transpose",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "sharp",
+                            "flat"
+                        ],
+                        "type": "string",
+                        "description": "Знаки альтерации: sharp, flat, по умолчанию по тональности",
+                        "name": "accidentals",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Лад каподастра, от 0 до 12",
+                        "name": "capo",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена или у неё нет аккордов",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "**Сохраняет ChordPro и заменяет текст песни частями из него, у частей появляются аккорды. Текст отмечается как правленый вручную.**",
+                "consumes": [
+                    "text/plain"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Chords"
+                ],
+                "summary": "Загрузить ChordPro",
+                "parameters": [
+                    {
+                        "description": "Исходник ChordPro",
+                        "name": "Request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Lyrics"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ChordPro",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "**Удаляет ChordPro и аккорды частей, текст песни остаётся**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Chords"
+                ],
+                "summary": "Удалить аккорды",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/utils.RespOK"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена или у неё нет аккордов",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/songs/:id/cover": {
+            "put": {
+                "description": "**Заменяет обложку песни. Тип определяется по содержимому: JPEG, PNG, GIF, WebP. Миниатюра строится сразу, ссылки на оригинал и миниатюру - в поле cover песни.**",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Images"
+                ],
+                "summary": "Загрузить обложку",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Изображение",
+                        "name": "image",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.Image"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "413": {
+                        "description": "Файл больше media.max_upload",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "415": {
+                        "description": "Не изображение или неподдерживаемый формат",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "**Удаляет обложку песни вместе с файлами**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Images"
+                ],
+                "summary": "Удалить обложку",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/utils.RespOK"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня или обложка не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/songs/:id/credits": {
+            "get": {
+                "description": "**Исполнители и авторы песни с ролями main, featured, composer, lyricist, producer**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Credits"
+                ],
+                "summary": "Участники песни",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SongCredit"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "**Исполнитель ищется по имени и создаётся, если его нет. Роль по умолчанию featured.**",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Credits"
+                ],
+                "summary": "Добавить участника",
+                "parameters": [
+                    {
+                        "description": "Участник",
+                        "name": "Request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreditInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongCredit"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Участник уже указан в этой роли",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/songs/:id/credits/:credit_id": {
+            "delete": {
+                "description": "**Основного исполнителя из group_id удалить нельзя, он меняется через редактирование песни**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Credits"
+                ],
+                "summary": "Удалить участника",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/utils.RespOK"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Участник не найден",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/songs/:id/explicit": {
+            "put": {
+                "description": "**true или false заменяют найденное в тексте, null снимает ручную отметку. Части с найденной лексикой (explicit_verses) не меняются.**",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Song"
+                ],
+                "summary": "Ручная отметка ненормативной лексики",
+                "parameters": [
+                    {
+                        "description": "Отметка",
+                        "name": "Request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ExplicitInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.Song"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/songs/:id/genres": {
+            "put": {
+                "description": "**Заменяет жанры песни, пустой список снимает все**",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Taxonomy"
+                ],
+                "summary": "Жанры песни",
+                "parameters": [
+                    {
+                        "description": "Жанры",
+                        "name": "Request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.GenresInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Genre"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня или жанр не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/songs/:id/links": {
+            "get": {
+                "description": "**Ссылки на площадках с отметкой о битых**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Links"
+                ],
+                "summary": "Ссылки песни",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SongLink"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "**Площадка (YouTube, Spotify, Apple Music, Яндекс Музыка) определяется по ссылке, ссылка сохраняется в каноничном виде**",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Links"
+                ],
+                "summary": "Добавить ссылку",
+                "parameters": [
+                    {
+                        "description": "Ссылка",
+                        "name": "Request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.LinkInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongLink"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Ссылка уже добавлена",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/songs/:id/links/:link_id": {
+            "delete": {
+                "description": "**Удалить ссылку песни**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Links"
+                ],
+                "summary": "Удалить ссылку",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/utils.RespOK"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Ссылка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/songs/:id/lyrics": {
+            "get": {
+                "description": "**Получение текста песни**",
+                "produces": [
+                    "application/json",
+                    "text/plain"
+                ],
+                "tags": [
+                    "Song"
+                ],
+                "summary": "Получение текста песни",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Страница, обязательна для format=json",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ограничение вывода, обязательно для format=json",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "lrc",
+                            "vtt"
+                        ],
+                        "type": "string",
+                        "description": "json (по умолчанию), lrc или vtt",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Язык перевода: части текста отдаются в переводе",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Вместе с lang: оригинал и перевод рядом, ответ - []models.PairedVerse",
+                        "name": "paired",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Lyrics"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня или перевод не найдены, текст не синхронизирован",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/songs/:id/lyrics/active": {
+            "get": {
+                "description": "**Строка, которая звучит в позиции воспроизведения, и следующая за ней. До первой строки и в паузах line пустая.**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Lyrics"
+                ],
+                "summary": "Активная строка",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Позиция воспроизведения в миллисекундах",
+                        "name": "position_ms",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.ActiveLine"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена или текст не синхронизирован",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/songs/:id/lyrics/lines": {
+            "get": {
+                "description": "**Строки текста песни по порядку с таймингами, если текст синхронизирован**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Lyrics"
+                ],
+                "summary": "Строки текста",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.LyricLine"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/songs/:id/lyrics/lrc": {
+            "post": {
+                "description": "**Заменяет строки текста строками LRC с таймингами, поддерживается расширенный LRC с таймингами слов. Если у песни нет текста, он создаётся из LRC.**",
+                "consumes": [
+                    "text/plain"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Lyrics"
+                ],
+                "summary": "Импорт LRC",
+                "parameters": [
+                    {
+                        "description": "Содержимое LRC",
+                        "name": "Request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.LyricLine"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный LRC",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/songs/:id/relations": {
+            "post": {
+                "description": "**Песня из пути становится версией песни related_id. С inherit_lyrics, пока у версии нет своего текста, отдаётся текст оригинала.**",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Relations"
+                ],
+                "summary": "Связать с оригиналом",
+                "parameters": [
+                    {
+                        "description": "Связь",
+                        "name": "Request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RelationInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongRelation"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Связь уже добавлена",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/songs/:id/relations/:relation_id": {
+            "delete": {
+                "description": "**Удаляет связь, в которой песня версия или оригинал**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Relations"
+                ],
+                "summary": "Удалить связь",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/utils.RespOK"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Связь не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/songs/:id/tags": {
+            "put": {
+                "description": "**Заменяет теги песни, новые теги создаются, пустой список снимает все**",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Taxonomy"
+                ],
+                "summary": "Теги песни",
+                "parameters": [
+                    {
+                        "description": "Теги",
+                        "name": "Request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TagsInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Tag"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/songs/:id/translations": {
+            "post": {
+                "description": "**Перевод текста целиком: части разделяются пустой строкой, их число должно совпадать с оригиналом. Метки частей ([Припев], [Chorus]) необязательны, но если есть, должны совпадать по типу.**",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Translations"
+                ],
+                "summary": "Добавить перевод",
+                "parameters": [
+                    {
+                        "description": "Перевод",
+                        "name": "Request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TranslationInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.LyricsTranslation"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Перевод на этот язык уже добавлен",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/songs/:id/translations/:lang": {
+            "put": {
+                "description": "**Заменяет перевод на язык из пути, lang в теле не учитывается**",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Translations"
+                ],
+                "summary": "Обновить перевод",
+                "parameters": [
+                    {
+                        "description": "Перевод",
+                        "name": "Request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TranslationInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.LyricsTranslation"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня или перевод не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "**Удалить перевод песни на язык**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Translations"
+                ],
+                "summary": "Удалить перевод",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/utils.RespOK"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Перевод не найден",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/songs/:id/versions": {
+            "get": {
+                "description": "**Все версии, до которых можно дойти по связям в обе стороны: каверы, ремиксы, концертные версии, переводы, сэмплы**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Relations"
+                ],
+                "summary": "Версии песни",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongVersions"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/songs/add": {
+            "post": {
+                "description": "**Добавить песню**",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Song"
+                ],
+                "summary": "Добавить песню",
+                "parameters": [
+                    {
+                        "description": "Информация о песне",
+                        "name": "Request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Input"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/utils.RespOK"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Песня уже существует",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/songs/delete/:id": {
+            "delete": {
+                "description": "**Удаление песни**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Song"
+                ],
+                "summary": "Удаление песни",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/utils.RespOK"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/songs/edit/:id": {
+            "put": {
+                "description": "**Редактирование данных песни, текста**",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Song"
+                ],
+                "summary": "Редактирование песни",
+                "parameters": [
+                    {
+                        "description": "Новая информация о песне",
+                        "name": "Request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Edit"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.Song"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/tags": {
+            "get": {
+                "description": "**Все теги по алфавиту**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Taxonomy"
+                ],
+                "summary": "Список тегов",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Tag"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/tags/:id": {
+            "delete": {
+                "description": "**Тег снимается со всех песен и групп**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Taxonomy"
+                ],
+                "summary": "Удалить тег",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/utils.RespOK"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Тег не найден",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "**Проверка, что процесс жив**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Liveness",
+                "responses": {
+                    "200": {
+                        "description": "Процесс жив",
+                        "schema": {
+                            "$ref": "#/definitions/models.HealthStatus"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "**Проверка готовности сервиса: БД, версия схемы, /info каждого HTTP-провайдера**",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Readiness",
+                "responses": {
+                    "200": {
+                        "description": "Сервис готов",
+                        "schema": {
+                            "$ref": "#/definitions/models.HealthStatus"
+                        }
+                    },
+                    "503": {
+                        "description": "Сервис не готов",
+                        "schema": {
+                            "$ref": "#/definitions/models.HealthStatus"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.ActiveLine": {
+            "type": "object",
+            "properties": {
+                "line": {
+                    "$ref": "#/definitions/models.LyricLine"
+                },
+                "next": {
+                    "$ref": "#/definitions/models.LyricLine"
+                },
+                "position_ms": {
+                    "type": "integer",
+                    "example": 13000
+                }
+            }
+        },
+        "models.Album": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-11-23 18:55:28.896205+03"
+                },
+                "group_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "release_date": {
+                    "type": "string",
+                    "example": "16.01.2015"
+                },
+                "songs": {
+                    "description": "Треки по порядку дисков и номеров, заполняется только в ответе на запрос альбома",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Song"
+                    }
+                },
+                "title": {
+                    "type": "string",
+                    "example": "American Beauty/American Psycho"
+                },
+                "type": {
+                    "description": "lp, ep, single, compilation",
+                    "type": "string",
+                    "example": "lp"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2024-11-23 18:55:28.896205+03"
+                }
+            }
+        },
+        "models.AlbumInfo": {
+            "type": "object",
+            "properties": {
+                "disc_number": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "release_date": {
+                    "type": "string",
+                    "example": "16.01.2015"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "American Beauty/American Psycho"
+                },
+                "track_number": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "type": {
+                    "type": "string",
+                    "example": "lp"
+                }
+            }
+        },
+        "models.AlbumInput": {
+            "type": "object",
+            "properties": {
+                "group": {
+                    "description": "Группа по имени, создаётся, если её нет. Необязательна для сборника.",
+                    "type": "string",
+                    "example": "Fall Out Boy"
+                },
+                "release_date": {
+                    "type": "string",
+                    "example": "16.01.2015"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "American Beauty/American Psycho"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "lp"
+                }
+            }
+        },
+        "models.AlbumsList": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Album"
+                    }
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "page": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "total_count": {
+                    "type": "integer",
+                    "example": 100
+                }
+            }
+        },
+        "models.CreditInput": {
+            "type": "object",
+            "properties": {
+                "artist": {
+                    "type": "string",
+                    "example": "Rihanna"
+                },
+                "role": {
+                    "type": "string",
+                    "example": "featured"
+                }
+            }
+        },
+        "models.Edit": {
+            "type": "object",
+            "properties": {
+                "group_name": {
+                    "type": "string",
+                    "example": "Fall Out Boys"
+                },
+                "link": {
+                    "type": "string",
+                    "example": "https://www.youtube.com/watch?v=LBr7kECsjcQ"
+                },
+                "lyrics": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Lyrics"
+                    }
+                },
+                "release_date": {
+                    "type": "string",
+                    "example": "01.01.2019"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Centuries"
+                }
+            }
+        },
+        "models.ExplicitInput": {
+            "type": "object",
+            "properties": {
+                "explicit": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "models.ExternalAPIResponse": {
+            "type": "object",
+            "properties": {
+                "album": {
+                    "$ref": "#/definitions/models.AlbumInfo"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2024-11-30T18:55:28.896205+03:00"
+                },
+                "fetched_at": {
+                    "type": "string",
+                    "example": "2024-11-23T18:55:28.896205+03:00"
+                },
+                "group_key": {
+                    "type": "string",
+                    "example": "fall out boy"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "link": {
+                    "type": "string",
+                    "example": "https://www.youtube.com/watch?v=LBr7kECsjcQ"
+                },
+                "provider": {
+                    "type": "string",
+                    "example": "info"
+                },
+                "release_date": {
+                    "type": "string",
+                    "example": "01.01.2019"
+                },
+                "song_key": {
+                    "type": "string",
+                    "example": "centuries"
+                },
+                "text": {
+                    "type": "string",
+                    "example": "Some legends are told"
+                }
+            }
+        },
+        "models.ExternalAPIResponsesList": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ExternalAPIResponse"
+                    }
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "page": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "total_count": {
+                    "type": "integer",
+                    "example": 100
+                }
+            }
+        },
+        "models.FacetCount": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer",
+                    "example": 120
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Rock"
+                },
+                "parent_id": {
+                    "type": "integer"
+                },
+                "slug": {
+                    "type": "string",
+                    "example": "rock"
+                }
+            }
+        },
+        "models.Facets": {
+            "type": "object",
+            "properties": {
+                "genres": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FacetCount"
+                    }
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FacetCount"
+                    }
+                }
+            }
+        },
+        "models.Genre": {
+            "type": "object",
+            "properties": {
+                "children": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Genre"
+                    }
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-11-23 18:55:28.896205+03"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Alternative Rock"
+                },
+                "parent_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "slug": {
+                    "type": "string",
+                    "example": "alternative-rock"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2024-11-23 18:55:28.896205+03"
+                }
+            }
+        },
+        "models.GenreInput": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "Alternative Rock"
+                },
+                "parent_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.GenresInput": {
+            "type": "object",
+            "properties": {
+                "genres": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "alternative-rock"
+                    ]
+                }
+            }
+        },
+        "models.Group": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-11-23 18:55:28.896205+03"
+                },
+                "genres": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Genre"
+                    }
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "name": {
+                    "type": "string"
+                },
+                "photo": {
+                    "$ref": "#/definitions/models.Image"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Tag"
+                    }
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2024-11-23 18:55:28.896205+03"
+                }
+            }
+        },
+        "models.HealthCheck": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "string",
+                    "example": "schema version 1"
+                },
+                "duration_ms": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "models.HealthStatus": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.HealthCheck"
+                    }
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "models.Image": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "type": "string",
+                    "example": "image/jpeg"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-11-23 18:55:28.896205+03"
+                },
+                "height": {
+                    "type": "integer",
+                    "example": 1200
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 183204
+                },
+                "thumbnail_url": {
+                    "type": "string",
+                    "example": "/api/v1/library/images/1/thumbnail"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2024-11-23 18:55:28.896205+03"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "/api/v1/library/images/1"
+                },
+                "width": {
+                    "type": "integer",
+                    "example": 1200
+                }
+            }
+        },
+        "models.Input": {
+            "type": "object",
+            "properties": {
+                "group": {
+                    "type": "string",
+                    "example": "Fall Out Boys"
+                },
+                "song": {
+                    "type": "string",
+                    "example": "Centuries"
+                }
+            }
+        },
+        "models.LinkInput": {
+            "type": "object",
+            "properties": {
+                "url": {
+                    "type": "string",
+                    "example": "https://youtu.be/LBr7kECsjcQ"
+                }
+            }
+        },
+        "models.LogLevel": {
+            "type": "object",
+            "properties": {
+                "level": {
+                    "type": "string",
+                    "example": "debug"
+                }
+            }
+        },
+        "models.LyricLine": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-11-23 18:55:28.896205+03"
+                },
+                "end_ms": {
+                    "type": "integer",
+                    "example": 15400
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "lyrics_id": {
+                    "description": "Часть текста, к которой относится строка",
+                    "type": "integer",
+                    "example": 1
+                },
+                "order": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "song_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "start_ms": {
+                    "type": "integer",
+                    "example": 12000
+                },
+                "text": {
+                    "type": "string",
+                    "example": "Some legends are told"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2024-11-23 18:55:28.896205+03"
+                },
+                "words": {
+                    "description": "Тайминги слов из расширенного LRC",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.WordTiming"
+                    }
+                }
+            }
+        },
+        "models.Lyrics": {
+            "type": "object",
+            "properties": {
+                "chords": {
+                    "description": "Аккорды из ChordPro, если он загружен",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.VerseChord"
+                    }
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-11-23 18:55:28.896205+03"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "label": {
+                    "description": "Метка из текста, например \"Verse 2\"",
+                    "type": "string",
+                    "example": "Chorus"
+                },
+                "order": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "repeat_of_id": {
+                    "description": "ID первого вхождения повторяющейся части, обычно припева",
+                    "type": "integer",
+                    "example": 2
+                },
+                "song_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "type": {
+                    "description": "verse, chorus, pre_chorus, bridge, intro, outro, other",
+                    "type": "string",
+                    "example": "chorus"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2024-11-23 18:55:28.896205+03"
+                },
+                "verse": {
+                    "type": "string",
+                    "example": "Some legends are told"
+                }
+            }
+        },
+        "models.LyricsTranslation": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-11-23 18:55:28.896205+03"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "lang": {
+                    "type": "string",
+                    "example": "en"
+                },
+                "lyrics_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "song_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2024-11-23 18:55:28.896205+03"
+                },
+                "verse": {
+                    "type": "string",
+                    "example": "Some legends are told"
+                }
+            }
+        },
+        "models.Playlist": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-11-23 18:55:28.896205+03"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Для дальней дороги"
+                },
+                "duplicate_policy": {
+                    "description": "allow, skip, reject",
+                    "type": "string",
+                    "example": "skip"
+                },
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PlaylistEntry"
+                    }
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Road trip"
+                },
+                "owner": {
+                    "type": "string",
+                    "example": "cron"
+                },
+                "share_token": {
+                    "description": "Отдаётся только владельцу",
+                    "type": "string",
+                    "example": "9f86d081884c7d659a2feaa0c55ad015"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2024-11-23 18:55:28.896205+03"
+                },
+                "visibility": {
+                    "type": "string",
+                    "example": "shared"
+                }
+            }
+        },
+        "models.PlaylistEntry": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-11-23 18:55:28.896205+03"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "playlist_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "position": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "song": {
+                    "$ref": "#/definitions/models.Song"
+                },
+                "song_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2024-11-23 18:55:28.896205+03"
+                }
+            }
+        },
+        "models.PlaylistEntryInput": {
+            "type": "object",
+            "properties": {
+                "position": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "song_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.PlaylistInput": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "example": "Для дальней дороги"
+                },
+                "duplicate_policy": {
+                    "description": "allow (по умолчанию), skip, reject",
+                    "type": "string",
+                    "example": "skip"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Road trip"
+                },
+                "visibility": {
+                    "description": "private (по умолчанию), shared, public",
+                    "type": "string",
+                    "example": "shared"
+                }
+            }
+        },
+        "models.PlaylistsList": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Playlist"
+                    }
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "page": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "total_count": {
+                    "type": "integer",
+                    "example": 100
+                }
+            }
+        },
+        "models.PositionInput": {
+            "type": "object",
+            "properties": {
+                "position": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.PurgedCount": {
+            "type": "object",
+            "properties": {
+                "deleted": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "models.RelationInput": {
+            "type": "object",
+            "properties": {
+                "inherit_lyrics": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "related_id": {
+                    "description": "ID оригинала",
+                    "type": "integer",
+                    "example": 1
+                },
+                "type": {
+                    "type": "string",
+                    "example": "cover"
+                }
+            }
+        },
+        "models.Setlist": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-11-23 18:55:28.896205+03"
+                },
+                "date": {
+                    "type": "string",
+                    "example": "21.06.2025"
+                },
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SetlistEntry"
+                    }
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Summer Fest"
+                },
+                "owner": {
+                    "type": "string",
+                    "example": "cron"
+                },
+                "total_duration_sec": {
+                    "description": "Сумма указанных длительностей песен",
+                    "type": "integer",
+                    "example": 2700
+                },
+                "untimed_entries": {
+                    "description": "Песни без длительности, в сумму не вошли",
+                    "type": "integer",
+                    "example": 1
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2024-11-23 18:55:28.896205+03"
+                },
+                "venue": {
+                    "type": "string",
+                    "example": "Main Stage"
+                }
+            }
+        },
+        "models.SetlistEntry": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-11-23 18:55:28.896205+03"
+                },
+                "duration_sec": {
+                    "type": "integer",
+                    "example": 225
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "key": {
+                    "description": "Тональность выступления, аккорды в печатной версии транспонируются в неё",
+                    "type": "string",
+                    "example": "Am"
+                },
+                "notes": {
+                    "type": "string",
+                    "example": "Длинное вступление, гитара одна"
+                },
+                "position": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "setlist_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "song": {
+                    "$ref": "#/definitions/models.Song"
+                },
+                "song_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2024-11-23 18:55:28.896205+03"
+                }
+            }
+        },
+        "models.SetlistEntryInput": {
+            "type": "object",
+            "properties": {
+                "duration_sec": {
+                    "type": "integer",
+                    "example": 225
+                },
+                "key": {
+                    "type": "string",
+                    "example": "Am"
+                },
+                "notes": {
+                    "type": "string",
+                    "example": "Длинное вступление, гитара одна"
+                },
+                "position": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "song_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.SetlistEntryUpdate": {
+            "type": "object",
+            "properties": {
+                "duration_sec": {
+                    "type": "integer",
+                    "example": 180
+                },
+                "key": {
+                    "type": "string",
+                    "example": "Am"
+                },
+                "notes": {
+                    "type": "string",
+                    "example": "Без второго куплета"
+                }
+            }
+        },
+        "models.SetlistInput": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string",
+                    "example": "21.06.2025"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Summer Fest"
+                },
+                "venue": {
+                    "type": "string",
+                    "example": "Main Stage"
+                }
+            }
+        },
+        "models.SetlistsList": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Setlist"
+                    }
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "page": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "total_count": {
+                    "type": "integer",
+                    "example": 100
+                }
+            }
+        },
+        "models.Song": {
+            "type": "object",
+            "properties": {
+                "album": {
+                    "$ref": "#/definitions/models.Album"
+                },
+                "album_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "cover": {
+                    "$ref": "#/definitions/models.Image"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-11-23 18:55:28.896205+03"
+                },
+                "credits": {
+                    "description": "Все участники песни, основной исполнитель из group_id среди них с ролью main",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SongCredit"
+                    }
+                },
+                "disc_number": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "explicit": {
+                    "description": "Есть ли в тексте ненормативная лексика: ручная отметка, а без неё - найденное в тексте",
+                    "type": "boolean",
+                    "example": false
+                },
+                "explicit_override": {
+                    "description": "Ручная отметка, важнее найденного в тексте",
+                    "type": "boolean",
+                    "example": true
+                },
+                "explicit_verses": {
+                    "description": "Номера (order) частей текста, в которых найдена ненормативная лексика",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        2,
+                        4
+                    ]
+                },
+                "genres": {
+                    "description": "Собственные жанры и теги песни, в фильтрах к ним добавляются жанры и теги группы",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Genre"
+                    }
+                },
+                "group_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "link": {
+                    "type": "string",
+                    "example": "https://www.youtube.com/watch?v=LBr7kECsjcQ"
+                },
+                "links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SongLink"
+                    }
+                },
+                "lyrics": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Lyrics"
+                    }
+                },
+                "lyrics_source_id": {
+                    "description": "Песня, чей текст отдаётся вместо своего: у версии нет текста, а связь разрешает наследование",
+                    "type": "integer",
+                    "example": 1
+                },
+                "provenance": {
+                    "description": "Источник каждого поля: release_date, link, lyrics -\u003e имя провайдера, manual или import",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    },
+                    "example": {
+                        "lyrics": "manual",
+                        "release_date": "info"
+                    }
+                },
+                "refreshed_at": {
+                    "description": "Когда данные последний раз сверялись с провайдерами",
+                    "type": "string"
+                },
+                "release_date": {
+                    "type": "string",
+                    "example": "01.01.2019"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Tag"
+                    }
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Centuries"
+                },
+                "track_number": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2024-11-23 18:55:28.896205+03"
+                }
+            }
+        },
+        "models.SongCredit": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-11-23 18:55:28.896205+03"
+                },
+                "group": {
+                    "$ref": "#/definitions/models.Group"
+                },
+                "group_id": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "order": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "role": {
+                    "description": "main, featured, composer, lyricist, producer",
+                    "type": "string",
+                    "example": "featured"
+                },
+                "song_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2024-11-23 18:55:28.896205+03"
+                }
+            }
+        },
+        "models.SongLink": {
+            "type": "object",
+            "properties": {
+                "broken": {
+                    "description": "Ссылка не открывается: площадка ответила 404/410 или проверки подряд завершились ошибкой",
+                    "type": "boolean",
+                    "example": false
+                },
+                "checked_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-11-23 18:55:28.896205+03"
+                },
+                "external_id": {
+                    "type": "string",
+                    "example": "LBr7kECsjcQ"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_status": {
+                    "type": "integer",
+                    "example": 200
+                },
+                "song_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "type": {
+                    "type": "string",
+                    "example": "youtube"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2024-11-23 18:55:28.896205+03"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://www.youtube.com/watch?v=LBr7kECsjcQ"
+                }
+            }
+        },
+        "models.SongProposal": {
+            "type": "object",
             "properties": {
-                "group_name": {
+                "created_at": {
                     "type": "string",
-                    "example": "Fall Out Boys"
+                    "example": "2024-11-23 18:55:28.896205+03"
                 },
-                "link": {
+                "current_value": {
                     "type": "string",
                     "example": "https://www.youtube.com/watch?v=LBr7kECsjcQ"
                 },
-                "lyrics": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.Lyrics"
-                    }
+                "field": {
+                    "type": "string",
+                    "example": "link"
                 },
-                "release_date": {
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "proposed_value": {
                     "type": "string",
-                    "example": "01.01.2019"
+                    "example": "https://www.youtube.com/watch?v=Xsp3_a-PMTw"
                 },
-                "title": {
+                "provider": {
                     "type": "string",
-                    "example": "Centuries"
+                    "example": "info"
+                },
+                "resolved_at": {
+                    "type": "string"
+                },
+                "resolved_by": {
+                    "type": "string",
+                    "example": "cron"
+                },
+                "song_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "status": {
+                    "type": "string",
+                    "example": "pending"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2024-11-23 18:55:28.896205+03"
                 }
             }
         },
-        "models.Input": {
+        "models.SongProposalsList": {
             "type": "object",
             "properties": {
-                "group": {
-                    "type": "string",
-                    "example": "Fall Out Boys"
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SongProposal"
+                    }
                 },
-                "song": {
-                    "type": "string",
-                    "example": "Centuries"
+                "limit": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "page": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "total_count": {
+                    "type": "integer",
+                    "example": 100
                 }
             }
         },
-        "models.Lyrics": {
+        "models.SongRelation": {
             "type": "object",
             "properties": {
                 "created_at": {
@@ -333,56 +5376,163 @@ const docTemplate = `{
                     "type": "integer",
                     "example": 1
                 },
-                "order": {
+                "inherit_lyrics": {
+                    "description": "Пока у версии нет своего текста, отдаётся текст оригинала",
+                    "type": "boolean",
+                    "example": true
+                },
+                "related_id": {
                     "type": "integer",
                     "example": 1
                 },
                 "song_id": {
                     "type": "integer",
-                    "example": 1
+                    "example": 2
+                },
+                "type": {
+                    "description": "cover, remix, live, sample, translation",
+                    "type": "string",
+                    "example": "cover"
                 },
                 "updated_at": {
                     "type": "string",
                     "example": "2024-11-23 18:55:28.896205+03"
+                }
+            }
+        },
+        "models.SongVersions": {
+            "type": "object",
+            "properties": {
+                "originals": {
+                    "description": "Версии, которые сами ничьей версией не являются",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        1
+                    ]
                 },
-                "verse": {
+                "relations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SongRelation"
+                    }
+                },
+                "songs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Song"
+                    }
+                }
+            }
+        },
+        "models.SongbookInput": {
+            "type": "object",
+            "properties": {
+                "album_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "format": {
+                    "description": "pdf (по умолчанию), epub",
                     "type": "string",
-                    "example": "Some legends are told"
+                    "example": "pdf"
+                },
+                "genre": {
+                    "description": "slug жанра, песни поджанров тоже попадают",
+                    "type": "string",
+                    "example": "rock"
+                },
+                "group": {
+                    "type": "string",
+                    "example": "Кино"
+                },
+                "playlist_id": {
+                    "description": "shared-плейлист открывается по playlist_token",
+                    "type": "integer",
+                    "example": 1
+                },
+                "playlist_token": {
+                    "type": "string",
+                    "example": "9f86d081884c7d659a2feaa0c55ad015"
+                },
+                "song_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        1,
+                        2,
+                        3
+                    ]
+                },
+                "tag": {
+                    "type": "string",
+                    "example": "summer"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Песни у костра"
                 }
             }
         },
-        "models.Song": {
+        "models.SongbookJob": {
             "type": "object",
             "properties": {
                 "created_at": {
                     "type": "string",
                     "example": "2024-11-23 18:55:28.896205+03"
                 },
-                "group_id": {
-                    "type": "integer",
-                    "example": 1
+                "error": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "format": {
+                    "description": "pdf, epub",
+                    "type": "string",
+                    "example": "pdf"
                 },
                 "id": {
                     "type": "integer",
                     "example": 1
                 },
-                "link": {
+                "owner": {
                     "type": "string",
-                    "example": "https://www.youtube.com/watch?v=LBr7kECsjcQ"
+                    "example": "cron"
                 },
-                "lyrics": {
+                "size": {
+                    "type": "integer",
+                    "example": 48213
+                },
+                "song_ids": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Lyrics"
-                    }
+                        "type": "integer"
+                    },
+                    "example": [
+                        1,
+                        2,
+                        3
+                    ]
                 },
-                "release_date": {
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "pending, running, done, failed",
                     "type": "string",
-                    "example": "01.01.2019"
+                    "example": "done"
                 },
                 "title": {
                     "type": "string",
-                    "example": "Centuries"
+                    "example": "Песни у костра"
                 },
                 "updated_at": {
                     "type": "string",
@@ -399,6 +5549,14 @@ const docTemplate = `{
                         "$ref": "#/definitions/models.Song"
                     }
                 },
+                "facets": {
+                    "description": "Число песен по жанрам и тегам среди всех найденных, без учёта пагинации",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Facets"
+                        }
+                    ]
+                },
                 "limit": {
                     "type": "integer",
                     "example": 10
@@ -413,6 +5571,174 @@ const docTemplate = `{
                 }
             }
         },
+        "models.Tag": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-11-23 18:55:28.896205+03"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "example": "summer"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2024-11-23 18:55:28.896205+03"
+                }
+            }
+        },
+        "models.TagsInput": {
+            "type": "object",
+            "properties": {
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "summer"
+                    ]
+                }
+            }
+        },
+        "models.TrackInput": {
+            "type": "object",
+            "properties": {
+                "disc_number": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "song_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "track_number": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "models.TranslationInput": {
+            "type": "object",
+            "properties": {
+                "lang": {
+                    "type": "string",
+                    "example": "en"
+                },
+                "text": {
+                    "type": "string",
+                    "example": "Some legends are told\nSome turn to dust or to gold"
+                }
+            }
+        },
+        "models.VerseChord": {
+            "type": "object",
+            "properties": {
+                "chord": {
+                    "type": "string",
+                    "example": "Am7"
+                },
+                "line": {
+                    "description": "Номер строки в части, с нуля",
+                    "type": "integer",
+                    "example": 0
+                },
+                "pos": {
+                    "description": "Номер символа в строке, с нуля",
+                    "type": "integer",
+                    "example": 9
+                }
+            }
+        },
+        "models.WordTiming": {
+            "type": "object",
+            "properties": {
+                "start_ms": {
+                    "type": "integer",
+                    "example": 12000
+                },
+                "text": {
+                    "type": "string",
+                    "example": "Some"
+                }
+            }
+        },
+        "services.AudioConflict": {
+            "type": "object",
+            "properties": {
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.AudioFieldConflict"
+                    }
+                },
+                "file": {
+                    "type": "string",
+                    "example": "Muse/Black Holes and Revelations/03.mp3"
+                },
+                "group": {
+                    "type": "string",
+                    "example": "Muse"
+                },
+                "song": {
+                    "type": "string",
+                    "example": "Supermassive Black Hole"
+                },
+                "song_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "services.AudioFieldConflict": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "release_date"
+                },
+                "stored": {
+                    "type": "string",
+                    "example": "16.07.2006"
+                },
+                "tags": {
+                    "type": "string",
+                    "example": "19.06.2006"
+                }
+            }
+        },
+        "services.AudioImportResult": {
+            "type": "object",
+            "properties": {
+                "conflicts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.AudioConflict"
+                    }
+                },
+                "created": {
+                    "type": "integer"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "skipped": {
+                    "description": "Песня уже есть, в том числе с расхождениями из conflicts",
+                    "type": "integer"
+                }
+            }
+        },
         "utils.ProblemDetails": {
             "type": "object",
             "properties": {
@@ -424,6 +5750,10 @@ const docTemplate = `{
                     "type": "string",
                     "example": "POST"
                 },
+                "request_id": {
+                    "type": "string",
+                    "example": "4f9d1c0e8a7b4e2c9d3f6a1b2c3d4e5f"
+                },
                 "status_code": {
                     "type": "integer",
                     "example": 400
@@ -451,6 +5781,13 @@ const docTemplate = `{
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        }
     }
 }`
 
@@ -0,0 +1,211 @@
+package explicit
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io"
+	"os"
+	"songLibrary/initializers"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//go:embed words/*.txt
+var builtin embed.FS
+
+// Приставки, после которых ищутся корни с ~. Варианты с ъ нужны перед е/ё: подъебать.
+// Приставки могут идти подряд, поэтому отрезается до двух: понаехать.
+// Приставка на согласную перед е, ё, ю, я пишется только с ъ, поэтому "в" в "вебинар"
+// приставкой не считается.
+var prefixes = []string{
+	"без", "бес", "в", "во", "въ", "вз", "взъ", "вс", "вы", "до", "за", "из", "изъ", "ис",
+	"на", "над", "надъ", "наи", "недо", "низ", "нис", "о", "об", "объ", "обо", "от", "отъ",
+	"ото", "пере", "по", "под", "подъ", "поза", "пре", "пред", "предъ", "при", "про", "раз",
+	"разъ", "рас", "с", "съ", "со", "у", "через", "черес",
+}
+
+const maxPrefixes = 2
+
+// Гласные, перед которыми приставка на согласную требует ъ
+const iotatedVowels = "еёюя"
+
+type kind int
+
+const (
+	// слово - только эта форма
+	kindExact kind = iota
+	// основа* - основа с любым окончанием
+	kindStem
+	// *часть* - часть в любом месте слова
+	kindPart
+)
+
+type rule struct {
+	kind  kind
+	value string
+	// ~ - перед значением может стоять приставка
+	prefixed bool
+}
+
+// Detector ищет ненормативную лексику. Слова сравниваются в нижнем регистре, ё
+// приравнивается к е. Исключения важнее правил.
+type Detector struct {
+	rules      []rule
+	exceptions []rule
+}
+
+// New собирает словарь из встроенных списков и файла из конфига.
+// Если поиск выключен, возвращает nil.
+func New(config initializers.ExplicitConfig) (*Detector, error) {
+	if !config.Enabled {
+		return nil, nil
+	}
+
+	d := &Detector{}
+	for _, language := range config.Languages {
+		f, err := builtin.Open("words/" + language + ".txt")
+		if err != nil {
+			return nil, fmt.Errorf("неизвестный словарь %q", language)
+		}
+		err = d.load(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("словарь %s: %w", language, err)
+		}
+	}
+
+	if config.WordsFile != "" {
+		f, err := os.Open(config.WordsFile)
+		if err != nil {
+			return nil, err
+		}
+		err = d.load(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", config.WordsFile, err)
+		}
+	}
+
+	return d, nil
+}
+
+// load читает словарь: по правилу в строке, # - комментарий
+func (d *Detector) load(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		exception := strings.HasPrefix(text, "!")
+		parsed, err := parseRule(strings.TrimPrefix(text, "!"))
+		if err != nil {
+			return fmt.Errorf("строка %d: %w", line, err)
+		}
+		if exception {
+			d.exceptions = append(d.exceptions, parsed)
+		} else {
+			d.rules = append(d.rules, parsed)
+		}
+	}
+	return scanner.Err()
+}
+
+func parseRule(text string) (rule, error) {
+	var r rule
+	if strings.HasPrefix(text, "~") {
+		r.prefixed = true
+		text = text[1:]
+	}
+
+	switch {
+	case len(text) > 2 && strings.HasPrefix(text, "*") && strings.HasSuffix(text, "*"):
+		if r.prefixed {
+			return r, fmt.Errorf("~ нельзя сочетать с *часть*: %q", text)
+		}
+		r.kind, text = kindPart, text[1:len(text)-1]
+	case strings.HasSuffix(text, "*"):
+		r.kind, text = kindStem, text[:len(text)-1]
+	default:
+		r.kind = kindExact
+	}
+
+	r.value = normalize(text)
+	if r.value == "" || strings.ContainsFunc(r.value, func(c rune) bool { return !unicode.IsLetter(c) }) {
+		return r, fmt.Errorf("некорректное правило %q", text)
+	}
+	return r, nil
+}
+
+// Find возвращает найденные в тексте слова без повторов, в том виде, в каком они
+// нашлись после приведения к нижнему регистру
+func (d *Detector) Find(text string) []string {
+	var found []string
+	seen := map[string]bool{}
+	for _, word := range strings.FieldsFunc(text, func(c rune) bool { return !unicode.IsLetter(c) }) {
+		word = normalize(word)
+		if seen[word] {
+			continue
+		}
+		seen[word] = true
+
+		if matchAny(d.rules, word) && !matchAny(d.exceptions, word) {
+			found = append(found, word)
+		}
+	}
+	return found
+}
+
+func normalize(word string) string {
+	return strings.ReplaceAll(strings.ToLower(word), "ё", "е")
+}
+
+func matchAny(rules []rule, word string) bool {
+	for _, r := range rules {
+		if r.match(word, maxPrefixes) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r rule) match(word string, depth int) bool {
+	switch r.kind {
+	case kindExact:
+		if word == r.value {
+			return true
+		}
+	case kindStem:
+		if strings.HasPrefix(word, r.value) {
+			return true
+		}
+	case kindPart:
+		return strings.Contains(word, r.value)
+	}
+
+	if !r.prefixed || depth == 0 {
+		return false
+	}
+	for _, prefix := range prefixes {
+		rest, ok := strings.CutPrefix(word, prefix)
+		if !ok || rest == "" || !separable(prefix, rest) {
+			continue
+		}
+		if r.match(rest, depth-1) {
+			return true
+		}
+	}
+	return false
+}
+
+// separable - можно ли по правилам орфографии отделить приставку от остатка слова
+func separable(prefix, rest string) bool {
+	last, _ := utf8.DecodeLastRuneInString(prefix)
+	first, _ := utf8.DecodeRuneInString(rest)
+	return !strings.ContainsRune(iotatedVowels, first) || strings.ContainsRune("аеиоуыъ", last)
+}
@@ -0,0 +1,77 @@
+package explicit
+
+import (
+	"reflect"
+	"songLibrary/initializers"
+	"strings"
+	"testing"
+)
+
+func newDetector(t *testing.T) *Detector {
+	t.Helper()
+	d, err := New(initializers.ExplicitConfig{Enabled: true, Languages: []string{initializers.ExplicitLanguageRU, initializers.ExplicitLanguageEN}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestFindExplicit(t *testing.T) {
+	d := newDetector(t)
+	for _, word := range []string{
+		"Нахуй", "охуеть", "ПИЗДЕЦ", "распиздяй", "заебал", "подъёбка", "въебал", "съебался",
+		"ебёт", "уебок", "понаебали", "долбоёб", "блядский", "бля", "Сука",
+		"Fucking", "motherfucker", "bullshit", "Shitty",
+	} {
+		if len(d.Find(word)) == 0 {
+			t.Errorf("Find(%q): не нашлось", word)
+		}
+	}
+}
+
+func TestFindClean(t *testing.T) {
+	d := newDetector(t)
+	for _, text := range []string{
+		"Завтра вебинар, включи вебку",
+		"себе тебе небо хлеба стебли требую ребята",
+		"Себастьян, себорея, веб-камера",
+		"страхует застрахуем хуже",
+		"скука мандарин",
+		"shiitake Scunthorpe forked",
+	} {
+		if found := d.Find(text); len(found) > 0 {
+			t.Errorf("Find(%q) = %v, ожидалось пусто", text, found)
+		}
+	}
+}
+
+func TestFindDistinct(t *testing.T) {
+	d := newDetector(t)
+	got := d.Find("Ну и хуй с ним, ХУЙ!\nFuck it")
+	if want := []string{"хуй", "fuck"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Find() = %v; want %v", got, want)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	d := &Detector{}
+	if err := d.load(strings.NewReader("# комментарий\n~корень*\n!корнеплод*\n*часть*\nслово\n")); err != nil {
+		t.Fatal(err)
+	}
+	if len(d.rules) != 3 || len(d.exceptions) != 1 {
+		t.Errorf("rules = %d, exceptions = %d", len(d.rules), len(d.exceptions))
+	}
+
+	for _, bad := range []string{"~*часть*", "*", "сло-во", "~"} {
+		if err := (&Detector{}).load(strings.NewReader(bad)); err == nil {
+			t.Errorf("load(%q): ожидалась ошибка", bad)
+		}
+	}
+}
+
+func TestNewDisabled(t *testing.T) {
+	d, err := New(initializers.ExplicitConfig{Languages: []string{initializers.ExplicitLanguageRU}})
+	if d != nil || err != nil {
+		t.Errorf("New() = %v, %v; want nil, nil", d, err)
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"songLibrary/logging"
+	"songLibrary/models"
+	"songLibrary/utils"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// @Summary      Уровни логирования
+// @Description  **Текущие уровни логирования по пакетам**
+// @Tags         Admin
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Success      200  {object}  map[string]string "Уровни по пакетам"
+// @Failure      401  {object}  utils.ProblemDetails "Нет или неверный API-ключ"
+// @Router       /api/v1/admin/log-levels [get]
+func (h *Handler) GetLogLevels(c echo.Context) error {
+	return c.JSON(http.StatusOK, logging.Levels())
+}
+
+// @Summary      Изменить уровень логирования
+// @Description  **Изменить уровень логирования пакета без перезапуска**
+// @Tags         Admin
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        logger path string true "Имя логгера (app, handlers, db, http, ...)"
+// @Param        Request body  models.LogLevel  true  "Новый уровень"
+// @Success      200  {object}  map[string]string "Уровни по пакетам"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      401  {object}  utils.ProblemDetails "Нет или неверный API-ключ"
+// @Router       /api/v1/admin/log-levels/{logger} [put]
+func (h *Handler) SetLogLevel(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "SetLogLevel")
+
+	name := c.Param("logger")
+
+	var input models.LogLevel
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+
+	log.WithField("logger", name).WithField("level", input.Level).Info("Меняем уровень логирования") // Info-лог
+
+	if err := logging.SetLevel(name, input.Level); err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("не удалось изменить уровень: %s", err), http.StatusBadRequest, log, c))
+	}
+
+	return c.JSON(http.StatusOK, logging.Levels())
+}
+
+// @Summary      Сохранённые ответы провайдеров
+// @Description  **Ответы провайдеров, сохранённые в БД. group и song ищутся по подстроке без учёта регистра**
+// @Tags         Admin
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Param        provider query string false "Имя провайдера"
+// @Param        group query string false "Название группы"
+// @Param        song query string false "Название песни"
+// @Param        page query string false "Страница"
+// @Param        limit query string false "Ограничение вывода"
+// @Success      200  {object}  models.ExternalAPIResponsesList "Успешный ответ"
+// @Failure      401  {object}  utils.ProblemDetails "Нет или неверный API-ключ"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/admin/external-cache [get]
+func (h *Handler) GetExternalCache(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "GetExternalCache")
+
+	pageInt, err := strconv.Atoi(c.QueryParam("page"))
+	if err != nil || pageInt < 1 {
+		pageInt = 1
+	}
+	limitInt, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil || limitInt < 1 {
+		limitInt = 10
+	}
+
+	result, err := h.library.ListSongDetails(ctx, c.QueryParam("provider"), c.QueryParam("group"), c.QueryParam("song"), pageInt, limitInt)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusInternalServerError, log, c))
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary      Очистить сохранённые ответы провайдеров
+// @Description  **Удалить ответы для пары group+song, одного провайдера, только устаревшие (expired=true) или все**
+// @Tags         Admin
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Param        provider query string false "Имя провайдера"
+// @Param        group query string false "Название группы"
+// @Param        song query string false "Название песни"
+// @Param        expired query bool false "Только устаревшие"
+// @Success      200  {object}  models.PurgedCount "Количество удалённых записей"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      401  {object}  utils.ProblemDetails "Нет или неверный API-ключ"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/admin/external-cache [delete]
+func (h *Handler) PurgeExternalCache(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "PurgeExternalCache")
+
+	group := c.QueryParam("group")
+	song := c.QueryParam("song")
+	if (group == "") != (song == "") {
+		return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("group и song указываются вместе"), http.StatusBadRequest, log, c))
+	}
+
+	var expiredOnly bool
+	if expired := c.QueryParam("expired"); expired != "" {
+		parsed, err := strconv.ParseBool(expired)
+		if err != nil {
+			return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("expired должен быть true/false: %s", expired), http.StatusBadRequest, log, c))
+		}
+		expiredOnly = parsed
+	}
+
+	deleted, err := h.library.PurgeSongDetails(ctx, c.QueryParam("provider"), group, song, expiredOnly)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusInternalServerError, log, c))
+	}
+
+	return c.JSON(http.StatusOK, models.PurgedCount{Deleted: deleted})
+}
@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"songLibrary/logging"
+	"songLibrary/models"
+	"songLibrary/services"
+	"songLibrary/utils"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// albumError переводит ошибки сервиса альбомов в HTTP-ответ
+func albumError(c echo.Context, log *logrus.Entry, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidAlbum):
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	case errors.Is(err, services.ErrAlbumNotFound), errors.Is(err, services.ErrSongNotFound):
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusNotFound, log, c))
+	case errors.Is(err, services.ErrAlbumExists), errors.Is(err, services.ErrTrackTaken):
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusConflict, log, c))
+	}
+	return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusInternalServerError, log, c))
+}
+
+// parseAlbumID достаёт ID альбома из пути
+func parseAlbumID(c echo.Context) (int, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("не получается преобразовать значение id: %s", c.Param("id"))
+	}
+	return id, nil
+}
+
+// @Summary      Список альбомов
+// @Description  **Альбомы с фильтрами по группе, названию и типу**
+// @Tags         Albums
+// @Produce      json
+// @Param        group query string false "Название группы"
+// @Param        title query string false "Название альбома"
+// @Param        type query string false "Тип альбома" Enums(lp, ep, single, compilation)
+// @Param        page query string false "Страница"
+// @Param        limit query string false "Ограничение вывода"
+// @Success      200  {object}  models.AlbumsList "Успешный ответ"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/albums [get]
+func (h *Handler) GetAlbums(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "GetAlbums")
+
+	page, err := strconv.Atoi(c.QueryParam("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+
+	filter := services.AlbumFilter{
+		Group: strings.TrimSpace(c.QueryParam("group")),
+		Title: strings.TrimSpace(c.QueryParam("title")),
+		Type:  strings.TrimSpace(c.QueryParam("type")),
+	}
+
+	log.WithField("filter", filter).Debug("Фильтры альбомов") // Debug-лог
+
+	result, err := h.library.ListAlbums(ctx, filter, page, limit)
+	if err != nil {
+		return albumError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary      Добавить альбом
+// @Description  **Группа указывается по имени и создаётся, если её нет. Тип по умолчанию lp.**
+// @Tags         Albums
+// @Accept       json
+// @Produce      json
+// @Param        Request body  models.AlbumInput  true  "Альбом"
+// @Success      200  {object}  models.Album "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      409  {object}  utils.ProblemDetails "У группы уже есть альбом с таким названием"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/albums [post]
+func (h *Handler) CreateAlbum(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "CreateAlbum")
+
+	var input models.AlbumInput
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("неверные данные"), http.StatusBadRequest, log, c))
+	}
+
+	album, err := h.library.CreateAlbum(ctx, input)
+	if err != nil {
+		return albumError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, album)
+}
+
+// @Summary      Получение альбома
+// @Description  **Альбом с песнями в порядке дисков и треков**
+// @Tags         Albums
+// @Produce      json
+// @Success      200  {object}  models.Album "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Альбом не найден"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/albums/:id [get]
+func (h *Handler) GetAlbum(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "GetAlbum")
+
+	id, err := parseAlbumID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+
+	album, err := h.library.GetAlbum(ctx, id)
+	if err != nil {
+		return albumError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, album)
+}
+
+// @Summary      Обновить альбом
+// @Description  **Заменяет название, группу, дату выпуска и тип альбома**
+// @Tags         Albums
+// @Accept       json
+// @Produce      json
+// @Param        Request body  models.AlbumInput  true  "Альбом"
+// @Success      200  {object}  models.Album "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Альбом не найден"
+// @Failure      409  {object}  utils.ProblemDetails "У группы уже есть альбом с таким названием"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/albums/:id [put]
+func (h *Handler) UpdateAlbum(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "UpdateAlbum")
+
+	id, err := parseAlbumID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+
+	var input models.AlbumInput
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("неверные данные"), http.StatusBadRequest, log, c))
+	}
+
+	album, err := h.library.UpdateAlbum(ctx, id, input)
+	if err != nil {
+		return albumError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, album)
+}
+
+// @Summary      Удалить альбом
+// @Description  **Удаляет альбом, песни остаются в библиотеке без альбома**
+// @Tags         Albums
+// @Produce      json
+// @Success      200  {object}  utils.RespOK "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Альбом не найден"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/albums/:id [delete]
+func (h *Handler) DeleteAlbum(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "DeleteAlbum")
+
+	id, err := parseAlbumID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+
+	if err := h.library.DeleteAlbum(ctx, id); err != nil {
+		return albumError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "Альбом удалён"})
+}
+
+// @Summary      Добавить песню в альбом
+// @Description  **Без номера трека песня встаёт в конец диска, диск по умолчанию первый. Песня другой группы допускается только в сборнике.**
+// @Tags         Albums
+// @Accept       json
+// @Produce      json
+// @Param        Request body  models.TrackInput  true  "Трек"
+// @Success      200  {object}  models.Song "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Альбом или песня не найдены"
+// @Failure      409  {object}  utils.ProblemDetails "Номер трека уже занят"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/albums/:id/tracks [post]
+func (h *Handler) AttachAlbumSong(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "AttachAlbumSong")
+
+	id, err := parseAlbumID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+
+	var input models.TrackInput
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("неверные данные"), http.StatusBadRequest, log, c))
+	}
+	logging.AddField(ctx, logging.FieldSongID, input.SongID)
+
+	song, err := h.library.AttachSong(ctx, id, input)
+	if err != nil {
+		return albumError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, song)
+}
+
+// @Summary      Убрать песню из альбома
+// @Description  **Песня остаётся в библиотеке, номер трека и диска сбрасываются**
+// @Tags         Albums
+// @Produce      json
+// @Success      200  {object}  utils.RespOK "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Песни нет в альбоме"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/albums/:id/tracks/:song_id [delete]
+func (h *Handler) DetachAlbumSong(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "DetachAlbumSong")
+
+	id, err := parseAlbumID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+
+	songID, err := strconv.Atoi(c.Param("song_id"))
+	if err != nil || songID < 1 {
+		return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("не получается преобразовать значение song_id: %s", c.Param("song_id")), http.StatusBadRequest, log, c))
+	}
+	logging.AddField(ctx, logging.FieldSongID, songID)
+
+	if err := h.library.DetachSong(ctx, id, songID); err != nil {
+		return albumError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "Песня убрана из альбома"})
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"songLibrary/audiotags"
+	"songLibrary/services"
+	"songLibrary/utils"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// @Summary      Импорт из тегов аудиофайлов
+// @Description  **Создаёт песни из тегов ID3v2 и Vorbis comment (MP3, FLAC, OGG): исполнитель, название, альбом, дата, текст из USLT/LYRICS и синхронизированный текст из SYLT. Провайдеров спрашиваем только о песнях без текста в тегах. Песни, которые уже есть, пропускаются, расхождения тегов с ними - в conflicts.**
+// @Tags         Admin
+// @Security     ApiKeyAuth
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        files formData file true "Аудиофайлы, поле можно повторять"
+// @Param        fetch_missing query bool false "Запрашивать у провайдеров песни без текста в тегах (по умолчанию audio_import.fetch_missing)"
+// @Success      200  {object}  services.AudioImportResult "Итоги импорта"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      401  {object}  utils.ProblemDetails "Нет или неверный API-ключ"
+// @Failure      413  {object}  utils.ProblemDetails "Файлы больше audio_import.max_upload"
+// @Router       /api/v1/admin/import/audio [post]
+func (h *Handler) ImportAudio(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "ImportAudio")
+
+	fetchMissing := h.audioImport.FetchMissing
+	if raw := c.QueryParam("fetch_missing"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("fetch_missing должен быть true или false: %q", raw), http.StatusBadRequest, log, c))
+		}
+		fetchMissing = parsed
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("ожидается multipart/form-data с полем files"), http.StatusBadRequest, log, c))
+	}
+	defer form.RemoveAll()
+
+	headers := form.File["files"]
+	if len(headers) == 0 {
+		return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("не передано ни одного файла в поле files"), http.StatusBadRequest, log, c))
+	}
+	if len(headers) > h.audioImport.MaxFiles {
+		return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("за один запрос можно загрузить не больше %d файлов", h.audioImport.MaxFiles), http.StatusBadRequest, log, c))
+	}
+
+	files := make([]services.AudioFile, 0, len(headers))
+	for _, header := range headers {
+		if !audiotags.Supported(header.Filename) {
+			return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("файл %s: поддерживаются %s", header.Filename, strings.Join(audiotags.Extensions, ", ")), http.StatusBadRequest, log, c))
+		}
+		files = append(files, services.AudioFile{Name: header.Filename, Open: func() (io.ReadSeekCloser, error) {
+			return header.Open()
+		}})
+	}
+
+	log.WithField("count", len(files)).Debug("Файлов в запросе") // Debug-лог
+
+	return c.JSON(http.StatusOK, h.library.ImportAudio(ctx, files, fetchMissing))
+}
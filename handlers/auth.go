@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"songLibrary/logging"
+	"songLibrary/services"
+	"songLibrary/utils"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const HeaderAPIKey = "X-API-Key"
+
+// RequireAPIKey пропускает только запросы с действующим ключом из X-API-Key
+// или Authorization: Bearer. Имя ключа становится актором в логах.
+func (h *Handler) RequireAPIKey(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := c.Request().Context()
+		log := logger.Ctx(ctx).WithField("prefix", "RequireAPIKey")
+
+		key := apiKeyFromRequest(c)
+		if key == "" {
+			return c.JSON(utils.HttpResErrorRFC9457("auth error", errors.New("не указан API-ключ"), http.StatusUnauthorized, log, c))
+		}
+
+		apiKey, err := h.library.AuthenticateAPIKey(ctx, key)
+		if err != nil {
+			if errors.Is(err, services.ErrAPIKeyInvalid) {
+				return c.JSON(utils.HttpResErrorRFC9457("auth error", err, http.StatusUnauthorized, log, c))
+			}
+			return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusInternalServerError, log, c))
+		}
+
+		logging.AddField(ctx, logging.FieldActor, apiKey.Name)
+		c.Set(logging.FieldActor, apiKey.Name)
+
+		return next(c)
+	}
+}
+
+// OptionalAPIKey - RequireAPIKey для ручек, доступных и без ключа: запрос без ключа
+// проходит анонимно, с неверным ключом - 401
+func (h *Handler) OptionalAPIKey(next echo.HandlerFunc) echo.HandlerFunc {
+	require := h.RequireAPIKey(next)
+	return func(c echo.Context) error {
+		if apiKeyFromRequest(c) == "" {
+			return next(c)
+		}
+		return require(c)
+	}
+}
+
+// apiKeyFromRequest достаёт ключ из X-API-Key или Authorization: Bearer
+func apiKeyFromRequest(c echo.Context) string {
+	key := c.Request().Header.Get(HeaderAPIKey)
+	if key == "" {
+		key = strings.TrimPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+	}
+	return key
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Заголовок ответа: HIT - ответ из кеша, MISS - посчитан заново
+const headerCache = "X-Cache"
+
+// respondFromCache отдаёт сохранённый ответ, если он есть в кеше
+func (h *Handler) respondFromCache(c echo.Context, key string) (bool, error) {
+	return h.respondBlobFromCache(c, key, echo.MIMEApplicationJSON)
+}
+
+// respondBlobFromCache - respondFromCache для ответов не в JSON
+func (h *Handler) respondBlobFromCache(c echo.Context, key, contentType string) (bool, error) {
+	data, ok := h.library.Cache().Get(c.Request().Context(), key)
+	if !ok {
+		return false, nil
+	}
+
+	c.Response().Header().Set(headerCache, "HIT")
+	return true, c.Blob(http.StatusOK, contentType, data)
+}
+
+// respondAndCache сериализует ответ, кладёт его в кеш с тегами и отдаёт клиенту
+func (h *Handler) respondAndCache(c echo.Context, key string, value any, tags ...string) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return h.respondBlobAndCache(c, key, echo.MIMEApplicationJSON, data, tags...)
+}
+
+// respondBlobAndCache кладёт готовый ответ в кеш с тегами и отдаёт клиенту
+func (h *Handler) respondBlobAndCache(c echo.Context, key, contentType string, data []byte, tags ...string) error {
+	h.library.Cache().Set(c.Request().Context(), key, data, 0, tags...)
+
+	c.Response().Header().Set(headerCache, "MISS")
+	return c.Blob(http.StatusOK, contentType, data)
+}
+
+// parseSongID разбирает :id из пути, чтобы ключи и теги кеша не зависели от записи числа
+func parseSongID(c echo.Context) (int, error) {
+	raw := c.Param("id")
+	if raw == "" {
+		return 0, errors.New("id пустое, укажите значение параметра")
+	}
+
+	id, err := strconv.Atoi(raw)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("не получается преобразовать значение id: %s", raw)
+	}
+	return id, nil
+}
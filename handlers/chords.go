@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"songLibrary/cache"
+	"songLibrary/chords"
+	"songLibrary/logging"
+	"songLibrary/services"
+	"songLibrary/utils"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Content-Type для форматов вывода аккордов
+var chordsContentTypes = map[string]string{
+	chords.FormatChordPro: echo.MIMETextPlainCharsetUTF8,
+	chords.FormatText:     echo.MIMETextPlainCharsetUTF8,
+	chords.FormatHTML:     echo.MIMETextHTMLCharsetUTF8,
+}
+
+// @Summary      Текст с аккордами
+// @Description  **Песня с аккордами в ChordPro, тексте с аккордами над строками или HTML. transpose сдвигает звучание на полутоны, capo пересчитывает аппликатуры под каподастр.**
+// @Tags         Chords
+// @Produce      plain,html
+// @Param        format query string false "chordpro (по умолчанию), text или html" Enums(chordpro, text, html)
+// @Param        transpose query int false "Сдвиг в полутонах, от -11 до 11"
+// @Param        accidentals query string false "Знаки альтерации: sharp, flat, по умолчанию по тональности" Enums(sharp, flat)
+// @Param        capo query int false "Лад каподастра, от 0 до 12"
+// @Success      200  {string}  string "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Песня не найдена или у неё нет аккордов"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/songs/:id/chords [get]
+func (h *Handler) GetChords(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "GetChords")
+
+	id, err := parseSongID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+	logging.AddField(ctx, logging.FieldSongID, id)
+
+	format := c.QueryParam("format")
+	if format == "" {
+		format = chords.FormatChordPro
+	}
+	contentType, ok := chordsContentTypes[format]
+	if !ok {
+		return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("неизвестный формат: %s", format), http.StatusBadRequest, log, c))
+	}
+
+	var opts chords.Options
+
+	if raw := c.QueryParam("transpose"); raw != "" {
+		opts.Transpose, err = strconv.Atoi(raw)
+		if err != nil || opts.Transpose < -11 || opts.Transpose > 11 {
+			return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("transpose должно быть от -11 до 11: %s", raw), http.StatusBadRequest, log, c))
+		}
+	}
+
+	switch opts.Accidentals = c.QueryParam("accidentals"); opts.Accidentals {
+	case chords.AccidentalsAuto, chords.AccidentalsSharp, chords.AccidentalsFlat:
+	default:
+		return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("accidentals должно быть sharp или flat: %s", opts.Accidentals), http.StatusBadRequest, log, c))
+	}
+
+	if raw := c.QueryParam("capo"); raw != "" {
+		capo, err := strconv.Atoi(raw)
+		if err != nil || capo < 0 || capo > 12 {
+			return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("capo должно быть от 0 до 12: %s", raw), http.StatusBadRequest, log, c))
+		}
+		opts.Capo = &capo
+	}
+
+	log.WithField("format", format).WithField("transpose", opts.Transpose).Debug("Параметры вывода") // Debug-лог
+
+	cacheKey := cache.Key("chords", url.Values{
+		"id":          {strconv.Itoa(id)},
+		"format":      {format},
+		"transpose":   {strconv.Itoa(opts.Transpose)},
+		"accidentals": {opts.Accidentals},
+		"capo":        {c.QueryParam("capo")},
+	})
+	if ok, err := h.respondBlobFromCache(c, cacheKey, contentType); ok {
+		return err
+	}
+
+	sheet, err := h.library.ChordSheet(ctx, id)
+	if err != nil {
+		if errors.Is(err, services.ErrSongNotFound) || errors.Is(err, services.ErrNoChords) {
+			return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusNotFound, log, c))
+		}
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusInternalServerError, log, c))
+	}
+
+	rendered, err := sheet.Transposed(opts).Render(format)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusInternalServerError, log, c))
+	}
+
+	return h.respondBlobAndCache(c, cacheKey, contentType, []byte(rendered), cache.TagSong(id))
+}
+
+// @Summary      Загрузить ChordPro
+// @Description  **Сохраняет ChordPro и заменяет текст песни частями из него, у частей появляются аккорды. Текст отмечается как правленый вручную.**
+// @Tags         Chords
+// @Accept       plain
+// @Produce      json
+// @Param        Request body  string  true  "Исходник ChordPro"
+// @Success      200  {object}  []models.Lyrics "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Некорректный ChordPro"
+// @Failure      404  {object}  utils.ProblemDetails "Песня не найдена"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/songs/:id/chords [put]
+func (h *Handler) SetChords(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "SetChords")
+
+	id, err := parseSongID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+	logging.AddField(ctx, logging.FieldSongID, id)
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("неверные данные"), http.StatusBadRequest, log, c))
+	}
+
+	log.WithField("size", len(body)).Debug("Размер ChordPro") // Debug-лог
+
+	verses, err := h.library.SetChordPro(ctx, id, string(body))
+	if err != nil {
+		switch {
+		case errors.Is(err, chords.ErrInvalidChordPro):
+			return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+		case errors.Is(err, services.ErrSongNotFound):
+			return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusNotFound, log, c))
+		}
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusInternalServerError, log, c))
+	}
+
+	return c.JSON(http.StatusOK, verses)
+}
+
+// @Summary      Удалить аккорды
+// @Description  **Удаляет ChordPro и аккорды частей, текст песни остаётся**
+// @Tags         Chords
+// @Produce      json
+// @Success      200  {object}  utils.RespOK "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Песня не найдена или у неё нет аккордов"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/songs/:id/chords [delete]
+func (h *Handler) DeleteChords(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "DeleteChords")
+
+	id, err := parseSongID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+	logging.AddField(ctx, logging.FieldSongID, id)
+
+	if err := h.library.ClearChordPro(ctx, id); err != nil {
+		if errors.Is(err, services.ErrSongNotFound) || errors.Is(err, services.ErrNoChords) {
+			return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusNotFound, log, c))
+		}
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusInternalServerError, log, c))
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "Аккорды удалены"})
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"songLibrary/logging"
+	"songLibrary/models"
+	"songLibrary/services"
+	"songLibrary/utils"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// creditError переводит ошибки сервиса участников в HTTP-ответ
+func creditError(c echo.Context, log *logrus.Entry, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidCredit):
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	case errors.Is(err, services.ErrSongNotFound), errors.Is(err, services.ErrCreditNotFound):
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusNotFound, log, c))
+	case errors.Is(err, services.ErrCreditExists):
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusConflict, log, c))
+	}
+	return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusInternalServerError, log, c))
+}
+
+// @Summary      Участники песни
+// @Description  **Исполнители и авторы песни с ролями main, featured, composer, lyricist, producer**
+// @Tags         Credits
+// @Produce      json
+// @Success      200  {object}  []models.SongCredit "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Песня не найдена"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/songs/:id/credits [get]
+func (h *Handler) GetSongCredits(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "GetSongCredits")
+
+	id, err := parseSongID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+	logging.AddField(ctx, logging.FieldSongID, id)
+
+	credits, err := h.library.ListCredits(ctx, id)
+	if err != nil {
+		return creditError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, credits)
+}
+
+// @Summary      Добавить участника
+// @Description  **Исполнитель ищется по имени и создаётся, если его нет. Роль по умолчанию featured.**
+// @Tags         Credits
+// @Accept       json
+// @Produce      json
+// @Param        Request body  models.CreditInput  true  "Участник"
+// @Success      200  {object}  models.SongCredit "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Песня не найдена"
+// @Failure      409  {object}  utils.ProblemDetails "Участник уже указан в этой роли"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/songs/:id/credits [post]
+func (h *Handler) AddSongCredit(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "AddSongCredit")
+
+	id, err := parseSongID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+	logging.AddField(ctx, logging.FieldSongID, id)
+
+	var input models.CreditInput
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("неверные данные"), http.StatusBadRequest, log, c))
+	}
+
+	credit, err := h.library.AddCredit(ctx, id, input)
+	if err != nil {
+		return creditError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, credit)
+}
+
+// @Summary      Удалить участника
+// @Description  **Основного исполнителя из group_id удалить нельзя, он меняется через редактирование песни**
+// @Tags         Credits
+// @Produce      json
+// @Success      200  {object}  utils.RespOK "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Участник не найден"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/songs/:id/credits/:credit_id [delete]
+func (h *Handler) DeleteSongCredit(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "DeleteSongCredit")
+
+	id, err := parseSongID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+	logging.AddField(ctx, logging.FieldSongID, id)
+
+	creditID, err := strconv.Atoi(c.Param("credit_id"))
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("не получается преобразовать значение credit_id: %s", c.Param("credit_id")), http.StatusBadRequest, log, c))
+	}
+
+	if err := h.library.DeleteCredit(ctx, id, creditID); err != nil {
+		return creditError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "Участник удалён"})
+}
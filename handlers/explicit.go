@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"songLibrary/logging"
+	"songLibrary/models"
+	"songLibrary/services"
+	"songLibrary/utils"
+
+	"github.com/labstack/echo/v4"
+)
+
+// @Summary      Ручная отметка ненормативной лексики
+// @Description  **true или false заменяют найденное в тексте, null снимает ручную отметку. Части с найденной лексикой (explicit_verses) не меняются.**
+// @Tags         Song
+// @Accept       json
+// @Produce      json
+// @Param        Request body  models.ExplicitInput  true  "Отметка"
+// @Success      200  {object}  models.Song "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Песня не найдена"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/songs/:id/explicit [put]
+func (h *Handler) SetExplicit(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "SetExplicit")
+
+	id, err := parseSongID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+	logging.AddField(ctx, logging.FieldSongID, id)
+
+	var input models.ExplicitInput
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("неверные данные"), http.StatusBadRequest, log, c))
+	}
+
+	log.WithField("explicit", input.Explicit).Debug("Ручная отметка") // Debug-лог
+
+	song, err := h.library.SetExplicitOverride(ctx, id, input.Explicit)
+	if err != nil {
+		if errors.Is(err, services.ErrSongNotFound) {
+			return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusNotFound, log, c))
+		}
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusInternalServerError, log, c))
+	}
+
+	return c.JSON(http.StatusOK, song)
+}
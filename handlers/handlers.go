@@ -1,27 +1,37 @@
 package handlers
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
-	"os"
+	"slices"
+	"songLibrary/cache"
 	"songLibrary/initializers"
+	"songLibrary/logging"
 	"songLibrary/models"
+	"songLibrary/providers"
+	"songLibrary/services"
 	"songLibrary/utils"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
-	log "github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
-type Handler struct{}
+var logger = logging.New("handlers")
 
-func NewHandler() *Handler {
-	h := Handler{}
+type Handler struct {
+	library     *services.Library
+	songbook    initializers.SongbookConfig
+	audioImport initializers.AudioImportConfig
+	mediaConfig initializers.MediaConfig
+}
+
+func NewHandler(library *services.Library, songbook initializers.SongbookConfig, audioImport initializers.AudioImportConfig, mediaConfig initializers.MediaConfig) *Handler {
+	h := Handler{library: library, songbook: songbook, audioImport: audioImport, mediaConfig: mediaConfig}
 	return &h
 }
 
@@ -38,7 +48,7 @@ func NewHandler() *Handler {
 // @Router       /api/v1/library/songs/add [post]
 func (h *Handler) AddSong(c echo.Context) error {
 	ctx := c.Request().Context()
-	log := log.WithContext(ctx).WithField("prefix", "AddSong")
+	log := logger.Ctx(ctx).WithField("prefix", "AddSong")
 
 	var input models.Input
 
@@ -51,196 +61,248 @@ func (h *Handler) AddSong(c echo.Context) error {
 
 	log.Info("Валидация входных данных") // Info-лог
 
-	if len(input.Group) < 1 || len(input.Group) > 60 {
-		return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("значение group пустое или слишком длинное: %s", input.Group), http.StatusBadRequest, log, c))
-	}
-	if len(input.Song) < 1 || len(input.Song) > 100 {
-		return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("значение song пустое или слишком длинное: %s", input.Song), http.StatusBadRequest, log, c))
+	if err := services.ValidateSongInput(input.Group, input.Song); err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
 	}
 
-	log.Info("Проверка существования группы") // Info-лог
-
-	var group models.Group
-	if err := initializers.DB.Where("name = ?", input.Group).First(&group).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-
-			log.Info("Группа не найдена, создаём новую") // Info-лог
-
-			group = models.Group{Name: input.Group}
-			if err := initializers.DB.Create(&group).Error; err != nil {
-				return c.JSON(utils.HttpResErrorRFC9457("DB error", fmt.Errorf("не удалось создать группу: %s", err), http.StatusInternalServerError, log, c))
-			}
-		} else {
-			return c.JSON(utils.HttpResErrorRFC9457("DB error", fmt.Errorf("не удалось найти группу: %s", input.Group), http.StatusInternalServerError, log, c))
+	if _, err := h.library.AddSong(ctx, input.Group, input.Song); err != nil {
+		var apiErr *services.ExternalAPIError
+		switch {
+		case errors.Is(err, services.ErrSongExists):
+			return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusConflict, log, c))
+		case errors.As(err, &apiErr) && apiErr.StatusCode != 0:
+			return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+		case errors.As(err, &apiErr):
+			return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusInternalServerError, log, c))
+		default:
+			return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusInternalServerError, log, c))
 		}
 	}
 
-	log.Info("Проверка существования песни") // Info-лог
-
-	var song models.Song
-	if err := initializers.DB.Where("title = ? AND group_id = ?", input.Song, group.ID).First(&song).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-
-			log.Info("Песня не найдена, делаем запрос к внешнему API") // Info-лог
-
-			apiURL := fmt.Sprintf("%s/info?group=%s&song=%s", os.Getenv("EXTERNAL_API_ADDR"), url.QueryEscape(input.Group), url.QueryEscape(input.Song))
-			resp, err := http.Get(apiURL)
-			if err != nil {
-
-				log.Info("Не удалось получить положительный ответ от внешнего API") // Info-лог
-
-				return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("не удалось получить положительный ответ от внешнего API: %s", err), http.StatusInternalServerError, log, c))
-			}
-			defer resp.Body.Close()
+	return c.JSON(http.StatusOK, echo.Map{"message": "Песня добавлена"})
+}
 
-			if resp.StatusCode != http.StatusOK {
+// @Summary      Получение текста песни
+// @Description  **Получение текста песни**
+// @Tags         Song
+// @Produce      json,plain
+// @Param        page query string false "Страница, обязательна для format=json"
+// @Param        limit query string false "Ограничение вывода, обязательно для format=json"
+// @Param        format query string false "json (по умолчанию), lrc или vtt" Enums(json, lrc, vtt)
+// @Param        lang query string false "Язык перевода: части текста отдаются в переводе"
+// @Param        paired query bool false "Вместе с lang: оригинал и перевод рядом, ответ - []models.PairedVerse"
+// @Success      200  {object}  []models.Lyrics "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Песня или перевод не найдены, текст не синхронизирован"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/songs/:id/lyrics [get]
+func (h *Handler) GetLyrics(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "GetLyrics")
 
-				log.WithField("status_code", resp.StatusCode).Error("Внешний API вернул ошибку")
+	id, err := parseSongID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+	logging.AddField(ctx, logging.FieldSongID, id)
+
+	switch format := c.QueryParam("format"); format {
+	case "", lyricsFormatJSON:
+	case lyricsFormatLRC, lyricsFormatVTT:
+		return h.exportSyncedLyrics(c, id, format)
+	default:
+		return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("неизвестный формат: %s", format), http.StatusBadRequest, log, c))
+	}
 
-				return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("внешний API вернул ошибку: %d", resp.StatusCode), http.StatusBadRequest, log, c))
-			}
+	page := c.QueryParam("page")
+	limit := c.QueryParam("limit")
 
-			log.Info("Парсинг ответа от внешнего API") // Info-лог
+	log.WithField("song.id", id).Debug("ID песни") // Debug-лог
+	log.WithField("page", page).Debug("страница")  // Debug-лог
+	log.WithField("limit", limit).Debug("лимит")   // Debug-лог
 
-			var songDetail struct {
-				ReleaseDate string `json:"releaseDate"`
-				Text        string `json:"text"`
-				Link        string `json:"link"`
-			}
+	pageInt, err := strconv.Atoi(page)
+	if err != nil {
+		if len(page) == 0 {
+			return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("page пустое, укажите значение параметра"), http.StatusBadRequest, log, c))
+		}
+		return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("не получается преобразовать значение страницы: %s", page), http.StatusBadRequest, log, c))
+	}
 
-			if err := json.NewDecoder(resp.Body).Decode(&songDetail); err != nil {
-				log.Info("Не удалось распарсить ответ от внешнего API. Используем дефолтные значения") // Info-лог
+	limitInt, err := strconv.Atoi(limit)
+	if err != nil {
+		if len(limit) == 0 {
+			return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("limit пустое, укажите значение параметра"), http.StatusBadRequest, log, c))
+		}
+		return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("не получается преобразовать значение лимита: %s", limit), http.StatusBadRequest, log, c))
+	}
+	if limitInt == 0 {
 
-				return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("не удалось распарсить ответ от внешнего API: %s", err), http.StatusBadRequest, log, c))
-			}
+		log.Info("limitInt == 0 устанавливаем дефолтный размер страницы") // Info-лог
 
-			// Эмуляция внешнего API
-			// songDetail.ReleaseDate = "16.07.2006"
-			// songDetail.Text = "Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\nYou caught me under false pretenses\nHow long before you let me go?\n\nOoh\nYou set my soul alight\nOoh\nYou set my soul alight"
-			// songDetail.Link = "https://www.youtube.com/watch?v=Xsp3_a-PMTw"
+		limitInt = 5
+	}
 
-			log.Info("Создание новой записи песни") // Info-лог
+	var lang string
+	if raw := c.QueryParam("lang"); raw != "" {
+		if lang, err = services.NormalizeLang(raw); err != nil {
+			return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+		}
+	}
 
-			song = models.Song{
-				GroupID:     group.ID,
-				Title:       input.Song,
-				ReleaseDate: songDetail.ReleaseDate,
-				Link:        songDetail.Link,
-			}
+	var paired bool
+	if raw := c.QueryParam("paired"); raw != "" {
+		if paired, err = strconv.ParseBool(raw); err != nil {
+			return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("не получается преобразовать значение paired: %s", raw), http.StatusBadRequest, log, c))
+		}
+	}
+	if paired && lang == "" {
+		return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("для paired укажите lang"), http.StatusBadRequest, log, c))
+	}
 
-			log.WithField("song.GroupID", song.GroupID).Debug("ID группы")                        // Debug-лог
-			log.WithField("song.Title", song.Title).Debug("Имя песни")                            // Debug-лог
-			log.WithField("song.ReleaseDate", songDetail.ReleaseDate).Debug("Дата выпуска песни") // Debug-лог
-			log.WithField("song.Link", songDetail.Link).Debug("Ссылка на песню")                  // Debug-лог
-			log.WithField("songDetail.Text", songDetail.Text).Debug("Текст песни")                // Debug-лог
+	log.WithField("lang", lang).Debug("Язык перевода") // Debug-лог
 
-			log.Info("Разбиение текста песни на куплеты") // Info-лог
+	cacheKey := cache.Key("lyrics", url.Values{
+		"id":     {strconv.Itoa(id)},
+		"page":   {strconv.Itoa(pageInt)},
+		"limit":  {strconv.Itoa(limitInt)},
+		"lang":   {lang},
+		"paired": {strconv.FormatBool(paired)},
+	})
+	if ok, err := h.respondFromCache(c, cacheKey); ok {
+		return err
+	}
 
-			verses := utils.SplitIntoVerses(songDetail.Text)
+	log.Info("Проверяем, существует ли песня с данным ID") // Info-лог
 
-			log.Info("Начало транзакции") // Info-лог
+	var song models.Song
+	if err := initializers.DB.WithContext(ctx).First(&song, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.JSON(utils.HttpResErrorRFC9457("DB error", errors.New("песня не найдена"), http.StatusNotFound, log, c))
+		}
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusInternalServerError, log, c))
+	}
 
-			err = initializers.DB.Transaction(func(tx *gorm.DB) error {
-				if err := tx.Create(&song).Error; err != nil {
-					return err
-				}
+	// Версия без своего текста может наследовать текст оригинала
+	sourceID, err := h.library.LyricsSource(ctx, id)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusInternalServerError, log, c))
+	}
 
-				log.Info("Сохранение куплетов") // Info-лог
+	log.WithField("sourceID", sourceID).Debug("Песня, чей текст отдаём") // Debug-лог
 
-				for i, verse := range verses {
-					lyrics := models.Lyrics{
-						SongID: song.ID,
-						Verse:  verse,
-						Order:  i + 1,
-					}
+	var lyrics []models.Lyrics
+	result := initializers.DB.WithContext(ctx).Where("song_id = ?", sourceID).Order("\"order\"").Offset((pageInt - 1) * limitInt).Limit(limitInt).Find(&lyrics)
+	if result.Error != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", result.Error, http.StatusNotFound, log, c))
+	}
 
-					log.WithField("SongID", lyrics.SongID).Debug("ID песни, для которой добаялются куплеты") // Debug-лог
-					log.WithField("Verse", lyrics.Verse).Debug("Куплет песни")                               // Debug-лог
-					log.WithField("Order", lyrics.Order).Debug("Порядок куплета")                            // Debug-лог
+	if lang == "" {
+		return h.respondAndCache(c, cacheKey, lyrics, cache.TagSong(id), cache.TagSong(sourceID))
+	}
 
-					if err := tx.Create(&lyrics).Error; err != nil {
-						return err
-					}
-				}
+	log.Info("Получаем перевод текста") // Info-лог
 
-				return nil
-			})
-			if err != nil {
-				return c.JSON(utils.HttpResErrorRFC9457("Tx error", err, http.StatusInternalServerError, log, c))
-			}
+	verseIDs := make([]int, 0, len(lyrics))
+	for _, verse := range lyrics {
+		verseIDs = append(verseIDs, verse.ID)
+	}
 
-			log.Info("Завершение транзакции") // Info-лог
+	translations, err := h.library.VerseTranslations(ctx, sourceID, lang, verseIDs)
+	if err != nil {
+		if errors.Is(err, services.ErrTranslationNotFound) {
+			return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusNotFound, log, c))
+		}
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusInternalServerError, log, c))
+	}
 
-			return c.JSON(http.StatusOK, echo.Map{"message": "Песня добавлена"})
-		} else {
-			return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusInternalServerError, log, c))
+	if !paired {
+		for i := range lyrics {
+			lyrics[i].Verse = translations[lyrics[i].ID]
 		}
+		return h.respondAndCache(c, cacheKey, lyrics, cache.TagSong(id), cache.TagSong(sourceID))
 	}
 
-	return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("песня уже существует"), http.StatusConflict, log, c))
+	pairs := make([]models.PairedVerse, 0, len(lyrics))
+	for _, verse := range lyrics {
+		pairs = append(pairs, models.PairedVerse{
+			ID:          verse.ID,
+			Order:       verse.Order,
+			Type:        verse.Type,
+			Label:       verse.Label,
+			Verse:       verse.Verse,
+			Lang:        lang,
+			Translation: translations[verse.ID],
+		})
+	}
+
+	return h.respondAndCache(c, cacheKey, pairs, cache.TagSong(id), cache.TagSong(sourceID))
 }
 
-// @Summary      Получение текста песни
-// @Description  **Получение текста песни**
+// @Summary      Получение песни
+// @Description  **Получение песни с текстом**
 // @Tags         Song
 // @Produce      json
-// @Param        page query string true "Страница"
-// @Param        limit query string true "Ограничение вывода"
-// @Success      200  {object}  []models.Lyrics "Успешный ответ"
+// @Success      200  {object}  models.Song "Успешный ответ"
 // @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Песня не найдена"
 // @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
-// @Router       /api/v1/library/songs/:id/lyrics [get]
-func (h *Handler) GetLyrics(c echo.Context) error {
+// @Router       /api/v1/library/songs/:id [get]
+func (h *Handler) GetSong(c echo.Context) error {
 	ctx := c.Request().Context()
-	log := log.WithContext(ctx).WithField("prefix", "GetLyrics")
+	log := logger.Ctx(ctx).WithField("prefix", "GetSong")
 
-	id := c.Param("id")
-	page := c.QueryParam("page")
-	limit := c.QueryParam("limit")
+	id, err := parseSongID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+	logging.AddField(ctx, logging.FieldSongID, id)
 
-	log.WithField("song.id", id).Debug("ID песни") // Debug-лог
-	log.WithField("page", page).Debug("страница")  // Debug-лог
-	log.WithField("limit", limit).Debug("лимит")   // Debug-лог
+	cacheKey := cache.Key("song", url.Values{"id": {strconv.Itoa(id)}})
+	if ok, err := h.respondFromCache(c, cacheKey); ok {
+		return err
+	}
 
-	log.Info("Проверяем, существует ли песня с данным ID") // Info-лог
+	log.Info("Получаем песню из БД") // Info-лог
 
 	var song models.Song
-	if err := initializers.DB.First(&song, id).Error; err != nil {
+	err = initializers.DB.WithContext(ctx).Preload("Lyrics", func(db *gorm.DB) *gorm.DB {
+		return db.Order("\"order\"")
+	}).Preload("Links").Preload("Album").Preload("Credits", func(db *gorm.DB) *gorm.DB {
+		return db.Order("\"order\", id")
+	}).Preload("Credits.Group.Photo").Preload("Genres").Preload("Tags").Preload("Cover").First(&song, id).Error
+	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return c.JSON(utils.HttpResErrorRFC9457("DB error", errors.New("песня не найдена"), http.StatusNotFound, log, c))
 		}
 		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusInternalServerError, log, c))
 	}
 
-	pageInt, err := strconv.Atoi(page)
-	if err != nil {
-		if len(page) == 0 {
-			return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("page пустое, укажите значение параметра"), http.StatusBadRequest, log, c))
+	tags := []string{cache.TagSong(song.ID), cache.TagGroup(song.GroupID), cache.TagTaxonomy}
+	// Фотографии участников тоже в ответе
+	for _, credit := range song.Credits {
+		if credit.GroupID != song.GroupID {
+			tags = append(tags, cache.TagGroup(credit.GroupID))
 		}
-		return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("не получается преобразовать значение страницы: %s", page), http.StatusBadRequest, log, c))
 	}
-
-	limitInt, err := strconv.Atoi(limit)
-	if err != nil {
-		if len(limit) == 0 {
-			return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("limit пустое, укажите значение параметра"), http.StatusBadRequest, log, c))
+	if len(song.Lyrics) == 0 {
+		sourceID, err := h.library.LyricsSource(ctx, song.ID)
+		if err != nil {
+			return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusInternalServerError, log, c))
 		}
-		return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("не получается преобразовать значение лимита: %s", limit), http.StatusBadRequest, log, c))
-	}
-	if limitInt == 0 {
+		if sourceID != song.ID {
 
-		log.Info("limitInt == 0 устанавливаем дефолтный размер страницы") // Info-лог
+			log.WithField("sourceID", sourceID).Info("Текст наследуется у оригинала") // Info-лог
 
-		limitInt = 5
-	}
-
-	var lyrics []models.Lyrics
-	result := initializers.DB.Where("song_id = ?", id).Order("\"order\"").Offset((pageInt - 1) * limitInt).Limit(limitInt).Find(&lyrics)
-	if result.Error != nil {
-		return c.JSON(utils.HttpResErrorRFC9457("DB error", result.Error, http.StatusNotFound, log, c))
+			if err := initializers.DB.WithContext(ctx).Where("song_id = ?", sourceID).Order("\"order\"").Find(&song.Lyrics).Error; err != nil {
+				return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusInternalServerError, log, c))
+			}
+			song.LyricsSourceID = &sourceID
+			tags = append(tags, cache.TagSong(sourceID))
+		}
 	}
 
-	return c.JSON(http.StatusOK, lyrics)
+	return h.respondAndCache(c, cacheKey, song, tags...)
 }
 
 // @Summary      Удаление песни
@@ -253,16 +315,20 @@ func (h *Handler) GetLyrics(c echo.Context) error {
 // @Router       /api/v1/library/songs/delete/:id [delete]
 func (h *Handler) DeleteSong(c echo.Context) error {
 	ctx := c.Request().Context()
-	log := log.WithContext(ctx).WithField("prefix", "DeleteSong")
+	log := logger.Ctx(ctx).WithField("prefix", "DeleteSong")
 
-	id := c.Param("id")
+	id, err := parseSongID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+	logging.AddField(ctx, logging.FieldSongID, id)
 
 	log.WithField("song.id", id).Debug("ID песни") // Debug-лог
 
 	log.Info("Проверяем, существует ли песня с данным ID") // Info-лог
 
 	var song models.Song
-	if err := initializers.DB.First(&song, id).Error; err != nil {
+	if err := initializers.DB.WithContext(ctx).First(&song, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return c.JSON(utils.HttpResErrorRFC9457("DB error", errors.New("песня не найдена"), http.StatusNotFound, log, c))
 		}
@@ -271,7 +337,7 @@ func (h *Handler) DeleteSong(c echo.Context) error {
 
 	log.Info("Начинаем транзакцию") // Info-лог
 
-	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+	err = initializers.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 
 		log.Info("Удаление текстов песни") // Info-лог
 
@@ -280,6 +346,41 @@ func (h *Handler) DeleteSong(c echo.Context) error {
 			return err
 		}
 
+		log.Info("Удаление строк текста") // Info-лог
+
+		if err := tx.Where("song_id = ?", id).Delete(&models.LyricLine{}).Error; err != nil {
+			log.WithError(err).Error("error: не удалось удалить строки текста")
+			return err
+		}
+
+		log.Info("Удаление переводов текста") // Info-лог
+
+		if err := tx.Where("song_id = ?", id).Delete(&models.LyricsTranslation{}).Error; err != nil {
+			log.WithError(err).Error("error: не удалось удалить переводы")
+			return err
+		}
+
+		log.Info("Удаление участников песни") // Info-лог
+
+		if err := tx.Where("song_id = ?", id).Delete(&models.SongCredit{}).Error; err != nil {
+			log.WithError(err).Error("error: не удалось удалить участников")
+			return err
+		}
+
+		log.Info("Удаление связей с другими версиями") // Info-лог
+
+		if err := tx.Where("song_id = ? OR related_id = ?", id, id).Delete(&models.SongRelation{}).Error; err != nil {
+			log.WithError(err).Error("error: не удалось удалить связи")
+			return err
+		}
+
+		log.Info("Удаление ссылок песни") // Info-лог
+
+		if err := tx.Where("song_id = ?", id).Delete(&models.SongLink{}).Error; err != nil {
+			log.WithError(err).Error("error: не удалось удалить ссылки")
+			return err
+		}
+
 		log.Info("Удаление самой песни") // Info-лог
 
 		if err := tx.Delete(&models.Song{}, id).Error; err != nil {
@@ -295,6 +396,8 @@ func (h *Handler) DeleteSong(c echo.Context) error {
 
 	log.Info("Завершение транзакции") // Info-лог
 
+	h.library.InvalidateSong(ctx, song.ID, song.GroupID)
+
 	return c.JSON(http.StatusOK, echo.Map{"message": "Песня и слова песни удалены"})
 }
 
@@ -311,16 +414,20 @@ func (h *Handler) DeleteSong(c echo.Context) error {
 // @Router       /api/v1/library/songs/edit/:id [put]
 func (h *Handler) EditSong(c echo.Context) error {
 	ctx := c.Request().Context()
-	log := log.WithContext(ctx).WithField("prefix", "EditSong")
+	log := logger.Ctx(ctx).WithField("prefix", "EditSong")
 
-	id := c.Param("id")
+	id, err := parseSongID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+	logging.AddField(ctx, logging.FieldSongID, id)
 
 	log.WithField("song.id", id).Debug("ID песни") // Debug-лог
 
 	log.Info("Проверяем, существует ли песня с данным ID") // Info-лог
 
 	var song models.Song
-	if err := initializers.DB.First(&song, id).Error; err != nil {
+	if err := initializers.DB.WithContext(ctx).First(&song, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return c.JSON(utils.HttpResErrorRFC9457("DB error", errors.New("песня не найдена"), http.StatusNotFound, log, c))
 		}
@@ -337,10 +444,23 @@ func (h *Handler) EditSong(c echo.Context) error {
 
 	log.Info("Открытие транзакции") // Info-лог
 
-	tx := initializers.DB.Begin()
+	tx := initializers.DB.WithContext(ctx).Begin()
 
 	log.Info("Обновление информации о песне") // Info-лог
 
+	oldGroupID := song.GroupID
+
+	// Поля, изменённые вручную, больше не принадлежат провайдеру
+	if input.ReleaseDate != song.ReleaseDate {
+		song.Provenance.Set(models.ProvenanceManual, providers.FieldReleaseDate)
+	}
+	if input.Link != song.Link {
+		song.Provenance.Set(models.ProvenanceManual, providers.FieldLink)
+	}
+	if len(input.Lyrics) > 0 {
+		song.Provenance.Set(models.ProvenanceManual, providers.FieldLyrics)
+	}
+
 	song.Title = input.Title
 	song.ReleaseDate = input.ReleaseDate
 	song.Link = input.Link
@@ -377,6 +497,14 @@ func (h *Handler) EditSong(c echo.Context) error {
 		tx.Rollback()
 		return c.JSON(utils.HttpResErrorRFC9457("Tx error", err, http.StatusInternalServerError, log, c))
 	}
+	if err := services.SyncPrimaryLink(tx, &song); err != nil {
+		tx.Rollback()
+		return c.JSON(utils.HttpResErrorRFC9457("Tx error", err, http.StatusInternalServerError, log, c))
+	}
+	if err := services.SyncMainCredit(tx, &song, oldGroupID); err != nil {
+		tx.Rollback()
+		return c.JSON(utils.HttpResErrorRFC9457("Tx error", err, http.StatusInternalServerError, log, c))
+	}
 
 	log.Info("Обновление лирики") // Info-лог
 
@@ -402,10 +530,27 @@ func (h *Handler) EditSong(c echo.Context) error {
 			return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
 		}
 	}
+	if len(input.Lyrics) > 0 {
+		if err := services.RebuildLines(tx, song.ID); err != nil {
+			tx.Rollback()
+			return c.JSON(utils.HttpResErrorRFC9457("Tx error", err, http.StatusInternalServerError, log, c))
+		}
+
+		log.Info("Поиск ненормативной лексики") // Info-лог
+
+		if err := h.library.FlagExplicit(tx, &song); err != nil {
+			tx.Rollback()
+			return c.JSON(utils.HttpResErrorRFC9457("Tx error", err, http.StatusInternalServerError, log, c))
+		}
+	}
 
 	log.Info("Завершение транзакции") // Info-лог
 
-	tx.Commit()
+	if err := tx.Commit().Error; err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("Tx error", err, http.StatusInternalServerError, log, c))
+	}
+
+	h.library.InvalidateSong(ctx, song.ID, oldGroupID, song.GroupID)
 
 	return c.JSON(http.StatusOK, song)
 }
@@ -419,6 +564,15 @@ func (h *Handler) EditSong(c echo.Context) error {
 // @Param        releaseDate query string false "Дата выпуска песни"
 // @Param        link query string false "Ссылка на песню"
 // @Param        lyrics query string false "Фрагмент текста песни"
+// @Param        artist query string false "Любой участник песни: основной исполнитель, приглашённый, автор"
+// @Param        genres query string false "Жанры через запятую по slug, с поджанрами и жанрами группы"
+// @Param        genre_mode query string false "any - любой из жанров (по умолчанию), all - все" Enums(any, all)
+// @Param        tags query string false "Теги через запятую, вместе с тегами группы"
+// @Param        tag_mode query string false "any - любой из тегов (по умолчанию), all - все" Enums(any, all)
+// @Param        album_id query int false "ID альбома, песни идут в порядке треков"
+// @Param        album query string false "Название альбома"
+// @Param        album_type query string false "Тип альбома" Enums(lp, ep, single, compilation)
+// @Param        explicit query bool false "true - только песни с ненормативной лексикой, false - только без неё"
 // @Param        page query string true "Страница"
 // @Param        limit query string true "Ограничение вывода"
 // @Success      200  {object}  models.SongsList "Успешный ответ"
@@ -427,15 +581,23 @@ func (h *Handler) EditSong(c echo.Context) error {
 // @Router       /api/v1/library/songs [get]
 func (h *Handler) GetSongsList(c echo.Context) error {
 	ctx := c.Request().Context()
-	log := log.WithContext(ctx).WithField("prefix", "GetSongsList")
+	log := logger.Ctx(ctx).WithField("prefix", "GetSongsList")
 
 	log.Info("Получаем параметры фильтрации и пагинации из запроса") // Info-лог
 
-	groupName := c.QueryParam("group_name")
-	songTitle := c.QueryParam("song_title")
-	releaseDate := c.QueryParam("release_date")
-	link := c.QueryParam("link")
-	lyrics := c.QueryParam("lyrics")
+	groupName := strings.TrimSpace(c.QueryParam("group_name"))
+	songTitle := strings.TrimSpace(c.QueryParam("song_title"))
+	releaseDate := strings.TrimSpace(c.QueryParam("release_date"))
+	link := strings.TrimSpace(c.QueryParam("link"))
+	lyrics := strings.TrimSpace(c.QueryParam("lyrics"))
+	artist := strings.TrimSpace(c.QueryParam("artist"))
+	albumTitle := strings.TrimSpace(c.QueryParam("album"))
+	genres := splitList(c.QueryParam("genres"))
+	genreMode := strings.ToLower(strings.TrimSpace(c.QueryParam("genre_mode")))
+	tags := splitList(c.QueryParam("tags"))
+	tagMode := strings.ToLower(strings.TrimSpace(c.QueryParam("tag_mode")))
+	albumType := strings.ToLower(strings.TrimSpace(c.QueryParam("album_type")))
+	explicit := strings.ToLower(strings.TrimSpace(c.QueryParam("explicit")))
 	limit := c.QueryParam("limit")
 	page := c.QueryParam("page")
 
@@ -465,10 +627,61 @@ func (h *Handler) GetSongsList(c echo.Context) error {
 		}
 	}
 
+	albumID := 0
+	if raw := c.QueryParam("album_id"); raw != "" {
+		albumID, err = strconv.Atoi(raw)
+		if err != nil || albumID < 1 {
+			return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("некорректный album_id: %q", raw), http.StatusBadRequest, log, c))
+		}
+	}
+
+	if explicit != "" {
+		value, err := strconv.ParseBool(explicit)
+		if err != nil {
+			return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("explicit должен быть true или false: %q", explicit), http.StatusBadRequest, log, c))
+		}
+		explicit = strconv.FormatBool(value)
+	}
+
+	for name, mode := range map[string]*string{"genre_mode": &genreMode, "tag_mode": &tagMode} {
+		switch *mode {
+		case "":
+			*mode = filterModeAny
+		case filterModeAny, filterModeAll:
+		default:
+			return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("%s должен быть any или all: %q", name, *mode), http.StatusBadRequest, log, c))
+		}
+	}
+
+	// Фильтры сравниваются без учёта регистра, поэтому и ключ строим в нижнем регистре
+	cacheKey := cache.Key("songs", url.Values{
+		"group_name":   {strings.ToLower(groupName)},
+		"song_title":   {strings.ToLower(songTitle)},
+		"release_date": {releaseDate},
+		"link":         {strings.ToLower(link)},
+		"lyrics":       {strings.ToLower(lyrics)},
+		"artist":       {strings.ToLower(artist)},
+		"genres":       {strings.ToLower(strings.Join(genres, ","))},
+		"genre_mode":   {genreMode},
+		"tags":         {strings.ToLower(strings.Join(tags, ","))},
+		"tag_mode":     {tagMode},
+		"album_id":     {strconv.Itoa(albumID)},
+		"album":        {strings.ToLower(albumTitle)},
+		"album_type":   {albumType},
+		"explicit":     {explicit},
+		"page":         {strconv.Itoa(pageInt)},
+		"limit":        {strconv.Itoa(limitInt)},
+	})
+	if ok, err := h.respondFromCache(c, cacheKey); ok {
+		return err
+	}
+
 	var songs []models.Song
 	var totalCount int64
 
-	query := initializers.DB.Preload("Lyrics")
+	query := initializers.DB.WithContext(ctx).Preload("Lyrics").Preload("Links").Preload("Album").Preload("Credits", func(db *gorm.DB) *gorm.DB {
+		return db.Order("\"order\", id")
+	}).Preload("Credits.Group.Photo").Preload("Genres").Preload("Tags").Preload("Cover")
 
 	log.Info("Применяем фильтры") // Info-лог
 
@@ -487,9 +700,62 @@ func (h *Handler) GetSongsList(c echo.Context) error {
 	if lyrics != "" {
 		query = query.Joins("JOIN lyrics ON lyrics.song_id = songs.id").Where("LOWER(lyrics.verse) LIKE LOWER(?)", "%"+lyrics+"%")
 	}
+	if artist != "" && len(artist) < 255 {
+		credited := initializers.DB.WithContext(ctx).Model(&models.SongCredit{}).Select("song_credits.song_id").
+			Joins("JOIN groups AS credited ON credited.id = song_credits.group_id").
+			Where("LOWER(credited.name) LIKE LOWER(?)", "%"+artist+"%")
+		query = query.Where("songs.id IN (?)", credited)
+	}
+	if len(genres) > 0 {
+		sets, err := h.library.GenreSets(ctx, genres)
+		if err != nil {
+			if errors.Is(err, services.ErrGenreNotFound) {
+				return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+			}
+			return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusInternalServerError, log, c))
+		}
+		if genreMode == filterModeAll {
+			for _, set := range sets {
+				query = query.Where("songs.id IN (?)", h.library.SongsWithGenres(ctx, set))
+			}
+		} else {
+			query = query.Where("songs.id IN (?)", h.library.SongsWithGenres(ctx, slices.Concat(sets...)))
+		}
+	}
+	if len(tags) > 0 {
+		if tagMode == filterModeAll {
+			for _, tag := range tags {
+				query = query.Where("songs.id IN (?)", h.library.SongsWithTags(ctx, []string{tag}))
+			}
+		} else {
+			query = query.Where("songs.id IN (?)", h.library.SongsWithTags(ctx, tags))
+		}
+	}
+	if albumID > 0 {
+		query = query.Where("songs.album_id = ?", albumID)
+	}
+	if (albumTitle != "" && len(albumTitle) < 255) || albumType != "" {
+		query = query.Joins("JOIN albums ON albums.id = songs.album_id AND albums.deleted_at IS NULL")
+		if albumTitle != "" {
+			query = query.Where("LOWER(albums.title) LIKE LOWER(?)", "%"+albumTitle+"%")
+		}
+		if albumType != "" {
+			query = query.Where("albums.type = ?", albumType)
+		}
+	}
+	if explicit != "" {
+		query = query.Where("songs.explicit = ?", explicit == "true")
+	}
 
 	log.WithField("query", query).Debug("итоговый запрос") // Debug-лог
 
+	log.Info("Считаем фасеты по жанрам и тегам") // Info-лог
+
+	facets, err := h.library.SongFacets(ctx, query.Session(&gorm.Session{}).Model(&models.Song{}).Select("songs.id"))
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", errors.New("не удалось посчитать фасеты"), http.StatusInternalServerError, log, c))
+	}
+
 	log.Info("Получаем количество записей для пагинации") // Info-лог
 
 	if err := query.Model(&models.Song{}).Count(&totalCount).Error; err != nil {
@@ -500,14 +766,19 @@ func (h *Handler) GetSongsList(c echo.Context) error {
 
 	log.Info("Получаем данные с пагинацией") // Info-лог
 
+	if albumID > 0 {
+		query = query.Order("songs.disc_number NULLS LAST, songs.track_number NULLS LAST, songs.id")
+	}
+
 	if err := query.Offset(offset).Limit(limitInt).Find(&songs).Error; err != nil {
 		return c.JSON(utils.HttpResErrorRFC9457("DB error", errors.New("не удалось получить песни"), http.StatusInternalServerError, log, c))
 	}
 
-	return c.JSON(http.StatusOK, echo.Map{
-		"data":        songs,
-		"total_count": totalCount,
-		"page":        pageInt,
-		"limit":       limitInt,
-	})
+	return h.respondAndCache(c, cacheKey, models.SongsList{
+		Data:       songs,
+		TotalCount: totalCount,
+		Page:       pageInt,
+		Limit:      limitInt,
+		Facets:     &facets,
+	}, cache.TagSongsList, cache.TagTaxonomy)
 }
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"songLibrary/initializers"
+	"songLibrary/models"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	healthStatusOK           = "ok"
+	healthStatusFail         = "fail"
+	healthStatusShuttingDown = "shutting_down"
+)
+
+type HealthHandler struct {
+	config initializers.HealthConfig
+	// HTTP-провайдеры, файловым проверка не нужна
+	providers    []initializers.ProviderConfig
+	shuttingDown atomic.Bool
+}
+
+func NewHealthHandler(config initializers.HealthConfig, externalAPI initializers.ExternalAPIConfig) *HealthHandler {
+	var providers []initializers.ProviderConfig
+	for _, provider := range externalAPI.ProviderConfigs() {
+		if provider.Type == initializers.ProviderTypeHTTP {
+			providers = append(providers, provider)
+		}
+	}
+	return &HealthHandler{config: config, providers: providers}
+}
+
+// MarkShuttingDown переводит readiness в состояние отказа, чтобы балансировщик
+// перестал присылать новые запросы до остановки сервера
+func (h *HealthHandler) MarkShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// @Summary      Liveness
+// @Description  **Проверка, что процесс жив**
+// @Tags         Health
+// @Produce      json
+// @Success      200  {object}  models.HealthStatus "Процесс жив"
+// @Router       /healthz [get]
+func (h *HealthHandler) Healthz(c echo.Context) error {
+	return c.JSON(http.StatusOK, models.HealthStatus{Status: healthStatusOK})
+}
+
+// @Summary      Readiness
+// @Description  **Проверка готовности сервиса: БД, версия схемы, /info каждого HTTP-провайдера**
+// @Tags         Health
+// @Produce      json
+// @Success      200  {object}  models.HealthStatus "Сервис готов"
+// @Failure      503  {object}  models.HealthStatus "Сервис не готов"
+// @Router       /readyz [get]
+func (h *HealthHandler) Readyz(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "Readyz")
+
+	if h.shuttingDown.Load() {
+
+		log.Info("Сервер останавливается, readiness отключён") // Info-лог
+
+		return c.JSON(http.StatusServiceUnavailable, models.HealthStatus{Status: healthStatusShuttingDown})
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.config.Timeout)
+	defer cancel()
+
+	result := models.HealthStatus{Status: healthStatusOK, Checks: make(map[string]models.HealthCheck, len(h.config.Checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, name := range h.config.Checks {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+
+			start := time.Now()
+			detail, err := h.runHealthCheck(ctx, name)
+
+			check := models.HealthCheck{Status: healthStatusOK, DurationMs: time.Since(start).Milliseconds(), Detail: detail}
+			if err != nil {
+				check.Status = healthStatusFail
+				check.Detail = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			result.Checks[name] = check
+			if err != nil {
+				result.Status = healthStatusFail
+			}
+		}(name)
+	}
+	wg.Wait()
+
+	if result.Status != healthStatusOK {
+
+		log.WithField("checks", result.Checks).Warn("Сервис не готов") // Warn-лог
+
+		return c.JSON(http.StatusServiceUnavailable, result)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+func (h *HealthHandler) runHealthCheck(ctx context.Context, name string) (string, error) {
+	switch name {
+	case initializers.HealthCheckDB:
+		sqlDB, err := initializers.DB.DB()
+		if err != nil {
+			return "", err
+		}
+		return "", sqlDB.PingContext(ctx)
+	case initializers.HealthCheckMigrations:
+		if err := initializers.CheckSchemaVersion(ctx); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("schema version %d", initializers.SchemaVersion), nil
+	case initializers.HealthCheckExternalAPI:
+		return h.checkProviders(ctx)
+	default:
+		return "", fmt.Errorf("неизвестная проверка: %s", name)
+	}
+}
+
+// checkProviders опрашивает /info каждого HTTP-провайдера параллельно. Сервис не
+// готов, только если не отвечает ни один: без части провайдеров песни добавляются
+// через оставшиеся и сохранённые ответы. Состояние каждого - в detail.
+func (h *HealthHandler) checkProviders(ctx context.Context) (string, error) {
+	if len(h.providers) == 0 {
+		return "нет HTTP-провайдеров", nil
+	}
+
+	details := make([]string, len(h.providers))
+	errs := make([]error, len(h.providers))
+
+	var wg sync.WaitGroup
+	for i, provider := range h.providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			status, err := probeProvider(ctx, provider.Addr)
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", provider.Name, err)
+				details[i] = errs[i].Error()
+				return
+			}
+			details[i] = fmt.Sprintf("%s: status code %d", provider.Name, status)
+		}()
+	}
+	wg.Wait()
+
+	if !slices.ContainsFunc(errs, func(err error) bool { return err == nil }) {
+		return "", errors.Join(errs...)
+	}
+	return strings.Join(details, "; "), nil
+}
+
+func probeProvider(ctx context.Context, addr string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, addr+"/info", nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := initializers.HTTPClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// 4xx означает, что API отвечает, просто без параметров запроса
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return 0, fmt.Errorf("внешний API вернул ошибку: %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
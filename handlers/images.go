@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"songLibrary/blobstore"
+	"songLibrary/logging"
+	"songLibrary/media"
+	"songLibrary/services"
+	"songLibrary/utils"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// imageError переводит ошибки сервиса изображений в HTTP-ответ
+func imageError(c echo.Context, log *logrus.Entry, err error) error {
+	switch {
+	case errors.Is(err, media.ErrInvalidImage):
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusUnsupportedMediaType, log, c))
+	case errors.Is(err, services.ErrImageNotFound), errors.Is(err, services.ErrSongNotFound),
+		errors.Is(err, services.ErrGroupNotFound), errors.Is(err, blobstore.ErrNotFound):
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusNotFound, log, c))
+	}
+	return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusInternalServerError, log, c))
+}
+
+// readImage читает файл из поля image формы. Размер ограничен media.max_upload на маршруте.
+func readImage(c echo.Context) ([]byte, error) {
+	header, err := c.FormFile("image")
+	if err != nil {
+		return nil, errors.New("ожидается multipart/form-data с файлом в поле image")
+	}
+	f, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// @Summary      Загрузить обложку
+// @Description  **Заменяет обложку песни. Тип определяется по содержимому: JPEG, PNG, GIF, WebP. Миниатюра строится сразу, ссылки на оригинал и миниатюру - в поле cover песни.**
+// @Tags         Images
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        image formData file true "Изображение"
+// @Success      200  {object}  models.Image "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Песня не найдена"
+// @Failure      413  {object}  utils.ProblemDetails "Файл больше media.max_upload"
+// @Failure      415  {object}  utils.ProblemDetails "Не изображение или неподдерживаемый формат"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/songs/:id/cover [put]
+func (h *Handler) SetSongCover(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "SetSongCover")
+
+	id, err := parseSongID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+	logging.AddField(ctx, logging.FieldSongID, id)
+
+	data, err := readImage(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+
+	log.WithField("size", len(data)).Debug("Размер изображения") // Debug-лог
+
+	image, err := h.library.SetSongCover(ctx, id, data, h.mediaConfig.ThumbnailSize)
+	if err != nil {
+		return imageError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, image)
+}
+
+// @Summary      Удалить обложку
+// @Description  **Удаляет обложку песни вместе с файлами**
+// @Tags         Images
+// @Produce      json
+// @Success      200  {object}  utils.RespOK "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Песня или обложка не найдены"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/songs/:id/cover [delete]
+func (h *Handler) DeleteSongCover(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "DeleteSongCover")
+
+	id, err := parseSongID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+	logging.AddField(ctx, logging.FieldSongID, id)
+
+	if err := h.library.DeleteSongCover(ctx, id); err != nil {
+		return imageError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "Обложка удалена"})
+}
+
+// @Summary      Загрузить фотографию группы
+// @Description  **Заменяет фотографию группы. Тип определяется по содержимому: JPEG, PNG, GIF, WebP. Ссылки на оригинал и миниатюру - в поле photo группы.**
+// @Tags         Images
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        image formData file true "Изображение"
+// @Success      200  {object}  models.Image "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Группа не найдена"
+// @Failure      413  {object}  utils.ProblemDetails "Файл больше media.max_upload"
+// @Failure      415  {object}  utils.ProblemDetails "Не изображение или неподдерживаемый формат"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/groups/:id/photo [put]
+func (h *Handler) SetGroupPhoto(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "SetGroupPhoto")
+
+	id, err := parseTaxonomyID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+
+	data, err := readImage(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+
+	log.WithField("size", len(data)).Debug("Размер изображения") // Debug-лог
+
+	image, err := h.library.SetGroupPhoto(ctx, id, data, h.mediaConfig.ThumbnailSize)
+	if err != nil {
+		return imageError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, image)
+}
+
+// @Summary      Удалить фотографию группы
+// @Description  **Удаляет фотографию группы вместе с файлами**
+// @Tags         Images
+// @Produce      json
+// @Success      200  {object}  utils.RespOK "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Группа или фотография не найдены"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/groups/:id/photo [delete]
+func (h *Handler) DeleteGroupPhoto(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "DeleteGroupPhoto")
+
+	id, err := parseTaxonomyID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+
+	if err := h.library.DeleteGroupPhoto(ctx, id); err != nil {
+		return imageError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "Фотография удалена"})
+}
+
+// @Summary      Изображение
+// @Description  **Оригинал загруженного изображения. Содержимое по ID не меняется, новая загрузка получает новый ID, поэтому ответ кешируется надолго.**
+// @Tags         Images
+// @Produce      image/jpeg,image/png,image/gif,image/webp
+// @Success      200  {file}    file "Изображение"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Изображение не найдено"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/images/:id [get]
+func (h *Handler) GetImage(c echo.Context) error {
+	return h.streamImage(c, "GetImage", false)
+}
+
+// @Summary      Миниатюра
+// @Description  **Миниатюра изображения в JPEG, вписана в квадрат media.thumbnail_size**
+// @Tags         Images
+// @Produce      image/jpeg
+// @Success      200  {file}    file "Миниатюра"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Изображение не найдено"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/images/:id/thumbnail [get]
+func (h *Handler) GetImageThumbnail(c echo.Context) error {
+	return h.streamImage(c, "GetImageThumbnail", true)
+}
+
+func (h *Handler) streamImage(c echo.Context, prefix string, thumbnail bool) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", prefix)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id < 1 {
+		return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("не получается преобразовать значение id: %s", c.Param("id")), http.StatusBadRequest, log, c))
+	}
+
+	r, contentType, err := h.library.OpenImage(ctx, id, thumbnail)
+	if err != nil {
+		return imageError(c, log, err)
+	}
+	defer r.Close()
+
+	c.Response().Header().Set(echo.HeaderCacheControl, "public, max-age=31536000, immutable")
+	return c.Stream(http.StatusOK, contentType, r)
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"songLibrary/cache"
+	"songLibrary/logging"
+	"songLibrary/lyrics"
+	"songLibrary/services"
+	"songLibrary/utils"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Форматы выдачи текста песни
+const (
+	lyricsFormatJSON = "json"
+	lyricsFormatLRC  = "lrc"
+	lyricsFormatVTT  = "vtt"
+)
+
+// exportSyncedLyrics отдаёт синхронизированный текст в LRC или WebVTT
+func (h *Handler) exportSyncedLyrics(c echo.Context, id int, format string) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "exportSyncedLyrics")
+
+	contentType := "application/x-lrc; charset=utf-8"
+	if format == lyricsFormatVTT {
+		contentType = "text/vtt; charset=utf-8"
+	}
+
+	cacheKey := cache.Key("lyrics", url.Values{"id": {strconv.Itoa(id)}, "format": {format}})
+	if ok, err := h.respondBlobFromCache(c, cacheKey, contentType); ok {
+		return err
+	}
+
+	log.WithField("format", format).Info("Экспортируем синхронизированный текст") // Info-лог
+
+	tags, lines, err := h.library.SyncedLyrics(ctx, id)
+	if err != nil {
+		if errors.Is(err, services.ErrSongNotFound) || errors.Is(err, services.ErrNotSynced) {
+			return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusNotFound, log, c))
+		}
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusInternalServerError, log, c))
+	}
+
+	data := lyrics.FormatLRC(tags, lines)
+	if format == lyricsFormatVTT {
+		data = lyrics.FormatWebVTT(lines)
+	}
+
+	return h.respondBlobAndCache(c, cacheKey, contentType, []byte(data), cache.TagSong(id))
+}
+
+// @Summary      Строки текста
+// @Description  **Строки текста песни по порядку с таймингами, если текст синхронизирован**
+// @Tags         Lyrics
+// @Produce      json
+// @Success      200  {object}  []models.LyricLine "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Песня не найдена"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/songs/:id/lyrics/lines [get]
+func (h *Handler) GetLyricLines(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "GetLyricLines")
+
+	id, err := parseSongID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+	logging.AddField(ctx, logging.FieldSongID, id)
+
+	cacheKey := cache.Key("lines", url.Values{"id": {strconv.Itoa(id)}})
+	if ok, err := h.respondFromCache(c, cacheKey); ok {
+		return err
+	}
+
+	result, err := h.library.ListLines(ctx, id)
+	if err != nil {
+		if errors.Is(err, services.ErrSongNotFound) {
+			return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusNotFound, log, c))
+		}
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusInternalServerError, log, c))
+	}
+
+	return h.respondAndCache(c, cacheKey, result, cache.TagSong(id))
+}
+
+// @Summary      Импорт LRC
+// @Description  **Заменяет строки текста строками LRC с таймингами, поддерживается расширенный LRC с таймингами слов. Если у песни нет текста, он создаётся из LRC.**
+// @Tags         Lyrics
+// @Accept       plain
+// @Produce      json
+// @Param        Request body  string  true  "Содержимое LRC"
+// @Success      200  {object}  []models.LyricLine "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Некорректный LRC"
+// @Failure      404  {object}  utils.ProblemDetails "Песня не найдена"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/songs/:id/lyrics/lrc [post]
+func (h *Handler) ImportLyricsLRC(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "ImportLyricsLRC")
+
+	id, err := parseSongID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+	logging.AddField(ctx, logging.FieldSongID, id)
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("неверные данные"), http.StatusBadRequest, log, c))
+	}
+
+	log.WithField("size", len(body)).Debug("Размер LRC") // Debug-лог
+
+	result, err := h.library.ImportLRC(ctx, id, string(body))
+	if err != nil {
+		switch {
+		case errors.Is(err, lyrics.ErrInvalidLRC):
+			return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+		case errors.Is(err, services.ErrSongNotFound):
+			return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusNotFound, log, c))
+		}
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusInternalServerError, log, c))
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary      Активная строка
+// @Description  **Строка, которая звучит в позиции воспроизведения, и следующая за ней. До первой строки и в паузах line пустая.**
+// @Tags         Lyrics
+// @Produce      json
+// @Param        position_ms query int true "Позиция воспроизведения в миллисекундах"
+// @Success      200  {object}  models.ActiveLine "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Песня не найдена или текст не синхронизирован"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/songs/:id/lyrics/active [get]
+func (h *Handler) GetActiveLine(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "GetActiveLine")
+
+	id, err := parseSongID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+	logging.AddField(ctx, logging.FieldSongID, id)
+
+	raw := c.QueryParam("position_ms")
+	position, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || position < 0 {
+		if raw == "" {
+			return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("position_ms пустое, укажите значение параметра"), http.StatusBadRequest, log, c))
+		}
+		return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("не получается преобразовать значение position_ms: %s", raw), http.StatusBadRequest, log, c))
+	}
+
+	result, err := h.library.ActiveLine(ctx, id, position)
+	if err != nil {
+		if errors.Is(err, services.ErrSongNotFound) || errors.Is(err, services.ErrNotSynced) {
+			return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusNotFound, log, c))
+		}
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusInternalServerError, log, c))
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"songLibrary/links"
+	"songLibrary/logging"
+	"songLibrary/models"
+	"songLibrary/services"
+	"songLibrary/utils"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// @Summary      Ссылки песни
+// @Description  **Ссылки на площадках с отметкой о битых**
+// @Tags         Links
+// @Produce      json
+// @Success      200  {object}  []models.SongLink "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Песня не найдена"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/songs/:id/links [get]
+func (h *Handler) GetSongLinks(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "GetSongLinks")
+
+	id, err := parseSongID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+	logging.AddField(ctx, logging.FieldSongID, id)
+
+	result, err := h.library.ListLinks(ctx, id)
+	if err != nil {
+		if errors.Is(err, services.ErrSongNotFound) {
+			return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusNotFound, log, c))
+		}
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusInternalServerError, log, c))
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary      Добавить ссылку
+// @Description  **Площадка (YouTube, Spotify, Apple Music, Яндекс Музыка) определяется по ссылке, ссылка сохраняется в каноничном виде**
+// @Tags         Links
+// @Accept       json
+// @Produce      json
+// @Param        Request body  models.LinkInput  true  "Ссылка"
+// @Success      200  {object}  models.SongLink "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Песня не найдена"
+// @Failure      409  {object}  utils.ProblemDetails "Ссылка уже добавлена"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/songs/:id/links [post]
+func (h *Handler) AddSongLink(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "AddSongLink")
+
+	id, err := parseSongID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+	logging.AddField(ctx, logging.FieldSongID, id)
+
+	var input models.LinkInput
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("неверные данные"), http.StatusBadRequest, log, c))
+	}
+
+	log.WithField("url", input.URL).Debug("Ссылка") // Debug-лог
+
+	songLink, err := h.library.AddLink(ctx, id, input.URL)
+	if err != nil {
+		switch {
+		case errors.Is(err, links.ErrInvalidURL):
+			return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+		case errors.Is(err, services.ErrSongNotFound):
+			return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusNotFound, log, c))
+		case errors.Is(err, services.ErrLinkExists):
+			return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusConflict, log, c))
+		}
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusInternalServerError, log, c))
+	}
+
+	return c.JSON(http.StatusOK, songLink)
+}
+
+// @Summary      Удалить ссылку
+// @Description  **Удалить ссылку песни**
+// @Tags         Links
+// @Produce      json
+// @Success      200  {object}  utils.RespOK "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Ссылка не найдена"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/songs/:id/links/:link_id [delete]
+func (h *Handler) DeleteSongLink(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "DeleteSongLink")
+
+	id, err := parseSongID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+	logging.AddField(ctx, logging.FieldSongID, id)
+
+	linkID, err := strconv.Atoi(c.Param("link_id"))
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("не получается преобразовать значение link_id: %s", c.Param("link_id")), http.StatusBadRequest, log, c))
+	}
+
+	if err := h.library.DeleteLink(ctx, id, linkID); err != nil {
+		if errors.Is(err, services.ErrLinkNotFound) {
+			return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusNotFound, log, c))
+		}
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusInternalServerError, log, c))
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "Ссылка удалена"})
+}
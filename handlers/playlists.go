@@ -0,0 +1,385 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"songLibrary/logging"
+	"songLibrary/models"
+	"songLibrary/playlist"
+	"songLibrary/services"
+	"songLibrary/utils"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// Форматы экспорта плейлиста
+const (
+	playlistFormatM3U8 = "m3u8"
+	playlistFormatXSPF = "xspf"
+)
+
+// playlistError переводит ошибки сервиса плейлистов в HTTP-ответ
+func playlistError(c echo.Context, log *logrus.Entry, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidPlaylist):
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	case errors.Is(err, services.ErrPlaylistForbidden):
+		return c.JSON(utils.HttpResErrorRFC9457("auth error", err, http.StatusForbidden, log, c))
+	case errors.Is(err, services.ErrPlaylistNotFound), errors.Is(err, services.ErrEntryNotFound), errors.Is(err, services.ErrSongNotFound):
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusNotFound, log, c))
+	case errors.Is(err, services.ErrPlaylistDuplicate), errors.Is(err, services.ErrPlaylistFull):
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusConflict, log, c))
+	}
+	return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusInternalServerError, log, c))
+}
+
+// parsePlaylistID достаёт ID плейлиста из пути
+func parsePlaylistID(c echo.Context) (int, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("не получается преобразовать значение id: %s", c.Param("id"))
+	}
+	return id, nil
+}
+
+// parseEntryID достаёт из пути ID песни плейлиста или сетлиста
+func parseEntryID(c echo.Context) (int, error) {
+	id, err := strconv.Atoi(c.Param("entry_id"))
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("не получается преобразовать значение entry_id: %s", c.Param("entry_id"))
+	}
+	return id, nil
+}
+
+// @Summary      Список плейлистов
+// @Description  **Публичные плейлисты и, с API-ключом, плейлисты владельца ключа**
+// @Tags         Playlists
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Param        page query string false "Страница"
+// @Param        limit query string false "Ограничение вывода"
+// @Success      200  {object}  models.PlaylistsList "Успешный ответ"
+// @Failure      401  {object}  utils.ProblemDetails "Неверный API-ключ"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/playlists [get]
+func (h *Handler) GetPlaylists(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "GetPlaylists")
+
+	page, err := strconv.Atoi(c.QueryParam("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+
+	actor, _ := c.Get(logging.FieldActor).(string)
+
+	result, err := h.library.ListPlaylists(ctx, actor, page, limit)
+	if err != nil {
+		return playlistError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary      Создать плейлист
+// @Description  **Владельцем становится API-ключ запроса. Видимость по умолчанию private, повторы по умолчанию разрешены.**
+// @Tags         Playlists
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        Request body  models.PlaylistInput  true  "Плейлист"
+// @Success      200  {object}  models.Playlist "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      401  {object}  utils.ProblemDetails "Нет или неверный API-ключ"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/playlists [post]
+func (h *Handler) CreatePlaylist(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "CreatePlaylist")
+
+	actor, _ := c.Get(logging.FieldActor).(string)
+	if actor == "" {
+		return c.JSON(utils.HttpResErrorRFC9457("auth error", errors.New("для плейлиста нужен API-ключ владельца"), http.StatusUnauthorized, log, c))
+	}
+
+	var input models.PlaylistInput
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("неверные данные"), http.StatusBadRequest, log, c))
+	}
+
+	result, err := h.library.CreatePlaylist(ctx, actor, input)
+	if err != nil {
+		return playlistError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary      Получение плейлиста
+// @Description  **Плейлист с песнями по порядку. shared-плейлист открывается по token из share_token.**
+// @Tags         Playlists
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Param        token query string false "Токен ссылки для shared-плейлиста"
+// @Success      200  {object}  models.Playlist "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Плейлист не найден"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/playlists/:id [get]
+func (h *Handler) GetPlaylist(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "GetPlaylist")
+
+	id, err := parsePlaylistID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+
+	actor, _ := c.Get(logging.FieldActor).(string)
+
+	result, err := h.library.GetPlaylist(ctx, id, actor, c.QueryParam("token"))
+	if err != nil {
+		return playlistError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary      Обновить плейлист
+// @Description  **Название, описание, видимость и политика повторов. Менять может только владелец.**
+// @Tags         Playlists
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        Request body  models.PlaylistInput  true  "Плейлист"
+// @Success      200  {object}  models.Playlist "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      403  {object}  utils.ProblemDetails "Плейлист чужой"
+// @Failure      404  {object}  utils.ProblemDetails "Плейлист не найден"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/playlists/:id [put]
+func (h *Handler) UpdatePlaylist(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "UpdatePlaylist")
+
+	id, err := parsePlaylistID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+
+	var input models.PlaylistInput
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("неверные данные"), http.StatusBadRequest, log, c))
+	}
+
+	actor, _ := c.Get(logging.FieldActor).(string)
+
+	result, err := h.library.UpdatePlaylist(ctx, id, actor, input)
+	if err != nil {
+		return playlistError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary      Удалить плейлист
+// @Description  **Удалить может только владелец**
+// @Tags         Playlists
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Success      200  {object}  utils.RespOK "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      403  {object}  utils.ProblemDetails "Плейлист чужой"
+// @Failure      404  {object}  utils.ProblemDetails "Плейлист не найден"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/playlists/:id [delete]
+func (h *Handler) DeletePlaylist(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "DeletePlaylist")
+
+	id, err := parsePlaylistID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+
+	actor, _ := c.Get(logging.FieldActor).(string)
+
+	if err := h.library.DeletePlaylist(ctx, id, actor); err != nil {
+		return playlistError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "Плейлист удалён"})
+}
+
+// @Summary      Добавить песню в плейлист
+// @Description  **Без позиции песня встаёт в конец. Повтор песни: allow - добавляется, skip - игнорируется, reject - 409.**
+// @Tags         Playlists
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        Request body  models.PlaylistEntryInput  true  "Песня"
+// @Success      200  {object}  models.Playlist "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      403  {object}  utils.ProblemDetails "Плейлист чужой"
+// @Failure      404  {object}  utils.ProblemDetails "Плейлист или песня не найдены"
+// @Failure      409  {object}  utils.ProblemDetails "Песня уже есть в плейлисте"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/playlists/:id/entries [post]
+func (h *Handler) AddPlaylistEntry(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "AddPlaylistEntry")
+
+	id, err := parsePlaylistID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+
+	var input models.PlaylistEntryInput
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("неверные данные"), http.StatusBadRequest, log, c))
+	}
+	logging.AddField(ctx, logging.FieldSongID, input.SongID)
+
+	actor, _ := c.Get(logging.FieldActor).(string)
+
+	result, err := h.library.AddPlaylistEntry(ctx, id, actor, input)
+	if err != nil {
+		return playlistError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary      Переместить песню в плейлисте
+// @Description  **Позиция за концом плейлиста переносит песню в конец**
+// @Tags         Playlists
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        Request body  models.PositionInput  true  "Новая позиция"
+// @Success      200  {object}  models.Playlist "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      403  {object}  utils.ProblemDetails "Плейлист чужой"
+// @Failure      404  {object}  utils.ProblemDetails "Плейлист или песня не найдены"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/playlists/:id/entries/:entry_id/position [put]
+func (h *Handler) MovePlaylistEntry(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "MovePlaylistEntry")
+
+	id, err := parsePlaylistID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+	entryID, err := parseEntryID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+
+	var input models.PositionInput
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("неверные данные"), http.StatusBadRequest, log, c))
+	}
+
+	actor, _ := c.Get(logging.FieldActor).(string)
+
+	result, err := h.library.MovePlaylistEntry(ctx, id, actor, entryID, input.Position)
+	if err != nil {
+		return playlistError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary      Убрать песню из плейлиста
+// @Description  **Позиции следующих песен сдвигаются**
+// @Tags         Playlists
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Success      200  {object}  models.Playlist "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      403  {object}  utils.ProblemDetails "Плейлист чужой"
+// @Failure      404  {object}  utils.ProblemDetails "Плейлист или песня не найдены"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/playlists/:id/entries/:entry_id [delete]
+func (h *Handler) RemovePlaylistEntry(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "RemovePlaylistEntry")
+
+	id, err := parsePlaylistID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+	entryID, err := parseEntryID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+
+	actor, _ := c.Get(logging.FieldActor).(string)
+
+	result, err := h.library.RemovePlaylistEntry(ctx, id, actor, entryID)
+	if err != nil {
+		return playlistError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary      Экспорт плейлиста
+// @Description  **M3U8 или XSPF со ссылками песен. Песни без ссылок пропускаются.**
+// @Tags         Playlists
+// @Security     ApiKeyAuth
+// @Produce      plain
+// @Param        format query string false "m3u8 (по умолчанию) или xspf" Enums(m3u8, xspf)
+// @Param        token query string false "Токен ссылки для shared-плейлиста"
+// @Success      200  {string}  string "Плейлист"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Плейлист не найден"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/playlists/:id/export [get]
+func (h *Handler) ExportPlaylist(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "ExportPlaylist")
+
+	id, err := parsePlaylistID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+
+	format := c.QueryParam("format")
+	switch format {
+	case "":
+		format = playlistFormatM3U8
+	case playlistFormatM3U8, playlistFormatXSPF:
+	default:
+		return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("неизвестный формат: %s", format), http.StatusBadRequest, log, c))
+	}
+
+	actor, _ := c.Get(logging.FieldActor).(string)
+
+	p, tracks, err := h.library.PlaylistTracks(ctx, id, actor, c.QueryParam("token"))
+	if err != nil {
+		return playlistError(c, log, err)
+	}
+
+	log.WithField("format", format).WithField("count", len(tracks)).Info("Экспортируем плейлист") // Info-лог
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"playlist-%d.%s\"", p.ID, format))
+
+	if format == playlistFormatXSPF {
+		data, err := playlist.FormatXSPF(p.Name, p.Owner, tracks)
+		if err != nil {
+			return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusInternalServerError, log, c))
+		}
+		return c.Blob(http.StatusOK, "application/xspf+xml; charset=utf-8", data)
+	}
+
+	return c.Blob(http.StatusOK, "audio/x-mpegurl; charset=utf-8", []byte(playlist.FormatM3U8(p.Name, tracks)))
+}
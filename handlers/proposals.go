@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"songLibrary/logging"
+	"songLibrary/models"
+	"songLibrary/services"
+	"songLibrary/utils"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// @Summary      Предложенные изменения
+// @Description  **Изменения от провайдеров для полей, которые правили вручную**
+// @Tags         Admin
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Param        status query string false "pending, accepted или rejected"
+// @Param        song_id query int false "ID песни"
+// @Param        page query string false "Страница"
+// @Param        limit query string false "Ограничение вывода"
+// @Success      200  {object}  models.SongProposalsList "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      401  {object}  utils.ProblemDetails "Нет или неверный API-ключ"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/admin/proposals [get]
+func (h *Handler) GetProposals(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "GetProposals")
+
+	status := c.QueryParam("status")
+	switch status {
+	case "", models.ProposalPending, models.ProposalAccepted, models.ProposalRejected:
+	default:
+		return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("неизвестный статус: %s", status), http.StatusBadRequest, log, c))
+	}
+
+	var songID int
+	if raw := c.QueryParam("song_id"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("не получается преобразовать значение song_id: %s", raw), http.StatusBadRequest, log, c))
+		}
+		songID = parsed
+	}
+
+	pageInt, err := strconv.Atoi(c.QueryParam("page"))
+	if err != nil || pageInt < 1 {
+		pageInt = 1
+	}
+	limitInt, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil || limitInt < 1 {
+		limitInt = 10
+	}
+
+	result, err := h.library.ListProposals(ctx, status, songID, pageInt, limitInt)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusInternalServerError, log, c))
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary      Принять изменение
+// @Description  **Записать предложенное значение в песню**
+// @Tags         Admin
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Success      200  {object}  models.SongProposal "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      401  {object}  utils.ProblemDetails "Нет или неверный API-ключ"
+// @Failure      404  {object}  utils.ProblemDetails "Предложение или песня не найдены"
+// @Failure      409  {object}  utils.ProblemDetails "Предложение уже рассмотрено"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/admin/proposals/:id/accept [post]
+func (h *Handler) AcceptProposal(c echo.Context) error {
+	return h.resolveProposal(c, true)
+}
+
+// @Summary      Отклонить изменение
+// @Description  **Отклонить предложение, это значение больше не будет предлагаться**
+// @Tags         Admin
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Success      200  {object}  models.SongProposal "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      401  {object}  utils.ProblemDetails "Нет или неверный API-ключ"
+// @Failure      404  {object}  utils.ProblemDetails "Предложение не найдено"
+// @Failure      409  {object}  utils.ProblemDetails "Предложение уже рассмотрено"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/admin/proposals/:id/reject [post]
+func (h *Handler) RejectProposal(c echo.Context) error {
+	return h.resolveProposal(c, false)
+}
+
+func (h *Handler) resolveProposal(c echo.Context, accept bool) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "ResolveProposal")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("не получается преобразовать значение id: %s", c.Param("id")), http.StatusBadRequest, log, c))
+	}
+
+	actor, _ := c.Get(logging.FieldActor).(string)
+
+	proposal, err := h.library.ResolveProposal(ctx, id, accept, actor)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrProposalNotFound), errors.Is(err, services.ErrSongNotFound):
+			return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusNotFound, log, c))
+		case errors.Is(err, services.ErrProposalResolved):
+			return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusConflict, log, c))
+		}
+		return c.JSON(utils.HttpResErrorRFC9457("Tx error", err, http.StatusInternalServerError, log, c))
+	}
+
+	return c.JSON(http.StatusOK, proposal)
+}
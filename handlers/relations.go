@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"songLibrary/logging"
+	"songLibrary/models"
+	"songLibrary/services"
+	"songLibrary/utils"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// relationError переводит ошибки сервиса связей в HTTP-ответ
+func relationError(c echo.Context, log *logrus.Entry, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidRelation):
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	case errors.Is(err, services.ErrSongNotFound), errors.Is(err, services.ErrRelationNotFound):
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusNotFound, log, c))
+	case errors.Is(err, services.ErrRelationExists):
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusConflict, log, c))
+	}
+	return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusInternalServerError, log, c))
+}
+
+// @Summary      Версии песни
+// @Description  **Все версии, до которых можно дойти по связям в обе стороны: каверы, ремиксы, концертные версии, переводы, сэмплы**
+// @Tags         Relations
+// @Produce      json
+// @Success      200  {object}  models.SongVersions "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Песня не найдена"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/songs/:id/versions [get]
+func (h *Handler) GetSongVersions(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "GetSongVersions")
+
+	id, err := parseSongID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+	logging.AddField(ctx, logging.FieldSongID, id)
+
+	versions, err := h.library.Versions(ctx, id)
+	if err != nil {
+		return relationError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, versions)
+}
+
+// @Summary      Связать с оригиналом
+// @Description  **Песня из пути становится версией песни related_id. С inherit_lyrics, пока у версии нет своего текста, отдаётся текст оригинала.**
+// @Tags         Relations
+// @Accept       json
+// @Produce      json
+// @Param        Request body  models.RelationInput  true  "Связь"
+// @Success      200  {object}  models.SongRelation "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Песня не найдена"
+// @Failure      409  {object}  utils.ProblemDetails "Связь уже добавлена"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/songs/:id/relations [post]
+func (h *Handler) AddSongRelation(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "AddSongRelation")
+
+	id, err := parseSongID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+	logging.AddField(ctx, logging.FieldSongID, id)
+
+	var input models.RelationInput
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("неверные данные"), http.StatusBadRequest, log, c))
+	}
+
+	relation, err := h.library.AddRelation(ctx, id, input)
+	if err != nil {
+		return relationError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, relation)
+}
+
+// @Summary      Удалить связь
+// @Description  **Удаляет связь, в которой песня версия или оригинал**
+// @Tags         Relations
+// @Produce      json
+// @Success      200  {object}  utils.RespOK "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Связь не найдена"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/songs/:id/relations/:relation_id [delete]
+func (h *Handler) DeleteSongRelation(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "DeleteSongRelation")
+
+	id, err := parseSongID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+	logging.AddField(ctx, logging.FieldSongID, id)
+
+	relationID, err := strconv.Atoi(c.Param("relation_id"))
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("не получается преобразовать значение relation_id: %s", c.Param("relation_id")), http.StatusBadRequest, log, c))
+	}
+
+	if err := h.library.DeleteRelation(ctx, id, relationID); err != nil {
+		return relationError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "Связь удалена"})
+}
@@ -0,0 +1,397 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"songLibrary/logging"
+	"songLibrary/models"
+	"songLibrary/services"
+	"songLibrary/setlist"
+	"songLibrary/utils"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// Content-Type для форматов печатной версии сетлиста
+var setlistContentTypes = map[string]string{
+	setlist.FormatHTML: echo.MIMETextHTMLCharsetUTF8,
+	setlist.FormatText: echo.MIMETextPlainCharsetUTF8,
+}
+
+// setlistError переводит ошибки сервиса сетлистов в HTTP-ответ
+func setlistError(c echo.Context, log *logrus.Entry, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidSetlist):
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	case errors.Is(err, services.ErrSetlistNotFound), errors.Is(err, services.ErrSetlistEntryNotFound), errors.Is(err, services.ErrSongNotFound):
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusNotFound, log, c))
+	case errors.Is(err, services.ErrSetlistFull):
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusConflict, log, c))
+	}
+	return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusInternalServerError, log, c))
+}
+
+// parseSetlistID достаёт ID сетлиста из пути
+func parseSetlistID(c echo.Context) (int, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("не получается преобразовать значение id: %s", c.Param("id"))
+	}
+	return id, nil
+}
+
+// @Summary      Список сетлистов
+// @Description  **Сетлисты владельца API-ключа, без песен**
+// @Tags         Setlists
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Param        page query string false "Страница"
+// @Param        limit query string false "Ограничение вывода"
+// @Success      200  {object}  models.SetlistsList "Успешный ответ"
+// @Failure      401  {object}  utils.ProblemDetails "Нет или неверный API-ключ"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/setlists [get]
+func (h *Handler) GetSetlists(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "GetSetlists")
+
+	page, err := strconv.Atoi(c.QueryParam("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+
+	actor, _ := c.Get(logging.FieldActor).(string)
+
+	result, err := h.library.ListSetlists(ctx, actor, page, limit)
+	if err != nil {
+		return setlistError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary      Создать сетлист
+// @Description  **Пустой сетлист выступления, владельцем становится API-ключ запроса**
+// @Tags         Setlists
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        Request body  models.SetlistInput  true  "Сетлист"
+// @Success      200  {object}  models.Setlist "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      401  {object}  utils.ProblemDetails "Нет или неверный API-ключ"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/setlists [post]
+func (h *Handler) CreateSetlist(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "CreateSetlist")
+
+	var input models.SetlistInput
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("неверные данные"), http.StatusBadRequest, log, c))
+	}
+
+	actor, _ := c.Get(logging.FieldActor).(string)
+
+	result, err := h.library.CreateSetlist(ctx, actor, input)
+	if err != nil {
+		return setlistError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary      Получение сетлиста
+// @Description  **Песни по порядку и общая длительность. Песни без длительности в сумму не входят, их число - в untimed_entries.**
+// @Tags         Setlists
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Success      200  {object}  models.Setlist "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Сетлист не найден"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/setlists/:id [get]
+func (h *Handler) GetSetlist(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "GetSetlist")
+
+	id, err := parseSetlistID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+
+	actor, _ := c.Get(logging.FieldActor).(string)
+
+	result, err := h.library.GetSetlist(ctx, id, actor)
+	if err != nil {
+		return setlistError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary      Обновить сетлист
+// @Description  **Название, площадка и дата выступления**
+// @Tags         Setlists
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        Request body  models.SetlistInput  true  "Сетлист"
+// @Success      200  {object}  models.Setlist "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Сетлист не найден"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/setlists/:id [put]
+func (h *Handler) UpdateSetlist(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "UpdateSetlist")
+
+	id, err := parseSetlistID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+
+	var input models.SetlistInput
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("неверные данные"), http.StatusBadRequest, log, c))
+	}
+
+	actor, _ := c.Get(logging.FieldActor).(string)
+
+	result, err := h.library.UpdateSetlist(ctx, id, actor, input)
+	if err != nil {
+		return setlistError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary      Удалить сетлист
+// @Description  **Удаляет сетлист вместе с его песнями, сами песни остаются в библиотеке**
+// @Tags         Setlists
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Success      200  {object}  utils.RespOK "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Сетлист не найден"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/setlists/:id [delete]
+func (h *Handler) DeleteSetlist(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "DeleteSetlist")
+
+	id, err := parseSetlistID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+
+	actor, _ := c.Get(logging.FieldActor).(string)
+
+	if err := h.library.DeleteSetlist(ctx, id, actor); err != nil {
+		return setlistError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "Сетлист удалён"})
+}
+
+// @Summary      Добавить песню в сетлист
+// @Description  **Без позиции песня встаёт в конец. key - тональность выступления, например Am или Bb, duration_sec - ожидаемая длительность.**
+// @Tags         Setlists
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        Request body  models.SetlistEntryInput  true  "Песня"
+// @Success      200  {object}  models.Setlist "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Сетлист или песня не найдены"
+// @Failure      409  {object}  utils.ProblemDetails "В сетлисте слишком много песен"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/setlists/:id/entries [post]
+func (h *Handler) AddSetlistEntry(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "AddSetlistEntry")
+
+	id, err := parseSetlistID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+
+	var input models.SetlistEntryInput
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("неверные данные"), http.StatusBadRequest, log, c))
+	}
+	logging.AddField(ctx, logging.FieldSongID, input.SongID)
+
+	actor, _ := c.Get(logging.FieldActor).(string)
+
+	result, err := h.library.AddSetlistEntry(ctx, id, actor, input)
+	if err != nil {
+		return setlistError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary      Изменить песню сетлиста
+// @Description  **Заменяет заметки, тональность и длительность. Без duration_sec длительность сбрасывается.**
+// @Tags         Setlists
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        Request body  models.SetlistEntryUpdate  true  "Заметки, тональность, длительность"
+// @Success      200  {object}  models.Setlist "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Сетлист или песня не найдены"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/setlists/:id/entries/:entry_id [put]
+func (h *Handler) UpdateSetlistEntry(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "UpdateSetlistEntry")
+
+	id, err := parseSetlistID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+	entryID, err := parseEntryID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+
+	var update models.SetlistEntryUpdate
+	if err := c.Bind(&update); err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("неверные данные"), http.StatusBadRequest, log, c))
+	}
+
+	actor, _ := c.Get(logging.FieldActor).(string)
+
+	result, err := h.library.UpdateSetlistEntry(ctx, id, actor, entryID, update)
+	if err != nil {
+		return setlistError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary      Переместить песню в сетлисте
+// @Description  **Позиция за концом сетлиста переносит песню в конец**
+// @Tags         Setlists
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        Request body  models.PositionInput  true  "Новая позиция"
+// @Success      200  {object}  models.Setlist "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Сетлист или песня не найдены"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/setlists/:id/entries/:entry_id/position [put]
+func (h *Handler) MoveSetlistEntry(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "MoveSetlistEntry")
+
+	id, err := parseSetlistID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+	entryID, err := parseEntryID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+
+	var input models.PositionInput
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("неверные данные"), http.StatusBadRequest, log, c))
+	}
+
+	actor, _ := c.Get(logging.FieldActor).(string)
+
+	result, err := h.library.MoveSetlistEntry(ctx, id, actor, entryID, input.Position)
+	if err != nil {
+		return setlistError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary      Убрать песню из сетлиста
+// @Description  **Позиции следующих песен сдвигаются**
+// @Tags         Setlists
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Success      200  {object}  models.Setlist "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Сетлист или песня не найдены"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/setlists/:id/entries/:entry_id [delete]
+func (h *Handler) RemoveSetlistEntry(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "RemoveSetlistEntry")
+
+	id, err := parseSetlistID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+	entryID, err := parseEntryID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+
+	actor, _ := c.Get(logging.FieldActor).(string)
+
+	result, err := h.library.RemoveSetlistEntry(ctx, id, actor, entryID)
+	if err != nil {
+		return setlistError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary      Печатная версия сетлиста
+// @Description  **Порядок песен с тональностями и длительностями, затем каждая песня: аккорды в тональности выступления, если загружен ChordPro, иначе текст**
+// @Tags         Setlists
+// @Security     ApiKeyAuth
+// @Produce      html,plain
+// @Param        format query string false "html (по умолчанию) или text" Enums(html, text)
+// @Success      200  {string}  string "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Сетлист не найден"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/setlists/:id/print [get]
+func (h *Handler) PrintSetlist(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "PrintSetlist")
+
+	id, err := parseSetlistID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+
+	format := c.QueryParam("format")
+	if format == "" {
+		format = setlist.FormatHTML
+	}
+	contentType, ok := setlistContentTypes[format]
+	if !ok {
+		return c.JSON(utils.HttpResErrorRFC9457("error", fmt.Errorf("неизвестный формат: %s", format), http.StatusBadRequest, log, c))
+	}
+
+	actor, _ := c.Get(logging.FieldActor).(string)
+
+	doc, err := h.library.PrintSetlist(ctx, id, actor)
+	if err != nil {
+		return setlistError(c, log, err)
+	}
+
+	rendered, err := doc.Render(format)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusInternalServerError, log, c))
+	}
+
+	return c.Blob(http.StatusOK, contentType, []byte(rendered))
+}
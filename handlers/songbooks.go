@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"songLibrary/logging"
+	"songLibrary/models"
+	"songLibrary/services"
+	"songLibrary/songbook"
+	"songLibrary/utils"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// songbookError переводит ошибки сервиса песенников в HTTP-ответ
+func songbookError(c echo.Context, log *logrus.Entry, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidSongbook), errors.Is(err, services.ErrGenreNotFound):
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	case errors.Is(err, services.ErrPlaylistForbidden):
+		return c.JSON(utils.HttpResErrorRFC9457("auth error", err, http.StatusForbidden, log, c))
+	case errors.Is(err, services.ErrSongbookNotFound), errors.Is(err, services.ErrSongNotFound),
+		errors.Is(err, services.ErrPlaylistNotFound), errors.Is(err, services.ErrAlbumNotFound):
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusNotFound, log, c))
+	case errors.Is(err, services.ErrSongbookNotReady), errors.Is(err, services.ErrSongbookFailed):
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusConflict, log, c))
+	}
+	return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusInternalServerError, log, c))
+}
+
+// parseSongbookID достаёт ID задания песенника из пути
+func parseSongbookID(c echo.Context) (int, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("не получается преобразовать значение id: %s", c.Param("id"))
+	}
+	return id, nil
+}
+
+// @Summary      Собрать песенник
+// @Description  **Ставит сборку PDF или EPUB в очередь: оглавление, каждая песня с новой страницы, аккорды, если загружен ChordPro. Песни берутся из одного источника: song_ids, playlist_id, album_id или фильтров group, genre, tag. Статус - в GET /songbooks/:id, файл - в /songbooks/:id/download.**
+// @Tags         Songbooks
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        Request body  models.SongbookInput  true  "Песенник"
+// @Success      202  {object}  models.SongbookJob "Задание в очереди"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      401  {object}  utils.ProblemDetails "Нет или неверный API-ключ"
+// @Failure      403  {object}  utils.ProblemDetails "Приватный плейлист без токена"
+// @Failure      404  {object}  utils.ProblemDetails "Песня, плейлист или альбом не найдены"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/songbooks [post]
+func (h *Handler) CreateSongbook(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "CreateSongbook")
+
+	var input models.SongbookInput
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("неверные данные"), http.StatusBadRequest, log, c))
+	}
+
+	actor, _ := c.Get(logging.FieldActor).(string)
+
+	job, err := h.library.CreateSongbook(ctx, actor, input, h.songbook.MaxSongs)
+	if err != nil {
+		return songbookError(c, log, err)
+	}
+
+	return c.JSON(http.StatusAccepted, job)
+}
+
+// @Summary      Статус песенника
+// @Description  **pending - в очереди, running - собирается, done - можно скачать, failed - ошибка в error. Файл хранится до expires_at.**
+// @Tags         Songbooks
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Success      200  {object}  models.SongbookJob "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Песенник не найден"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/songbooks/:id [get]
+func (h *Handler) GetSongbook(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "GetSongbook")
+
+	id, err := parseSongbookID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+
+	actor, _ := c.Get(logging.FieldActor).(string)
+
+	job, err := h.library.GetSongbook(ctx, id, actor)
+	if err != nil {
+		return songbookError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, job)
+}
+
+// @Summary      Скачать песенник
+// @Description  **Готовый PDF или EPUB. Пока сборка идёт или если она не удалась - 409.**
+// @Tags         Songbooks
+// @Security     ApiKeyAuth
+// @Produce      application/pdf,application/epub+zip
+// @Success      200  {file}    file "Песенник"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Песенник не найден"
+// @Failure      409  {object}  utils.ProblemDetails "Песенник ещё не собран или сборка не удалась"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/songbooks/:id/download [get]
+func (h *Handler) DownloadSongbook(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "DownloadSongbook")
+
+	id, err := parseSongbookID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+
+	actor, _ := c.Get(logging.FieldActor).(string)
+
+	job, err := h.library.SongbookFile(ctx, id, actor)
+	if err != nil {
+		return songbookError(c, log, err)
+	}
+
+	log.WithField("size", len(job.Data)).Debug("Отдаём песенник") // Debug-лог
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"songbook-%d.%s\"", job.ID, job.Format))
+	return c.Blob(http.StatusOK, songbook.ContentType(job.Format), job.Data)
+}
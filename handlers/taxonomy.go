@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"songLibrary/logging"
+	"songLibrary/models"
+	"songLibrary/services"
+	"songLibrary/utils"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// Режимы фильтров по нескольким жанрам и тегам
+const (
+	filterModeAny = "any"
+	filterModeAll = "all"
+)
+
+// splitList разбирает значения через запятую, пустые отбрасываются
+func splitList(raw string) []string {
+	var values []string
+	for _, value := range strings.Split(raw, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// taxonomyError переводит ошибки сервиса жанров и тегов в HTTP-ответ
+func taxonomyError(c echo.Context, log *logrus.Entry, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidGenre), errors.Is(err, services.ErrInvalidTag):
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	case errors.Is(err, services.ErrGenreNotFound), errors.Is(err, services.ErrTagNotFound),
+		errors.Is(err, services.ErrSongNotFound), errors.Is(err, services.ErrGroupNotFound):
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusNotFound, log, c))
+	case errors.Is(err, services.ErrGenreExists):
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusConflict, log, c))
+	}
+	return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusInternalServerError, log, c))
+}
+
+// parseTaxonomyID достаёт числовой ID жанра, тега или группы из пути
+func parseTaxonomyID(c echo.Context) (int, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("не получается преобразовать значение id: %s", c.Param("id"))
+	}
+	return id, nil
+}
+
+// @Summary      Дерево жанров
+// @Description  **Корневые жанры с поджанрами в children**
+// @Tags         Taxonomy
+// @Produce      json
+// @Success      200  {object}  []models.Genre "Успешный ответ"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/genres [get]
+func (h *Handler) GetGenres(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "GetGenres")
+
+	genres, err := h.library.ListGenres(ctx)
+	if err != nil {
+		return taxonomyError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, genres)
+}
+
+// @Summary      Добавить жанр
+// @Description  **slug строится из названия. parent_id делает жанр поджанром.**
+// @Tags         Taxonomy
+// @Accept       json
+// @Produce      json
+// @Param        Request body  models.GenreInput  true  "Жанр"
+// @Success      200  {object}  models.Genre "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Родительский жанр не найден"
+// @Failure      409  {object}  utils.ProblemDetails "Жанр уже есть"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/genres [post]
+func (h *Handler) CreateGenre(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "CreateGenre")
+
+	var input models.GenreInput
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("неверные данные"), http.StatusBadRequest, log, c))
+	}
+
+	genre, err := h.library.CreateGenre(ctx, input)
+	if err != nil {
+		return taxonomyError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, genre)
+}
+
+// @Summary      Обновить жанр
+// @Description  **Переименовать жанр или перенести под другого родителя, без parent_id жанр становится корневым**
+// @Tags         Taxonomy
+// @Accept       json
+// @Produce      json
+// @Param        Request body  models.GenreInput  true  "Жанр"
+// @Success      200  {object}  models.Genre "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Жанр не найден"
+// @Failure      409  {object}  utils.ProblemDetails "Жанр уже есть"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/genres/:id [put]
+func (h *Handler) UpdateGenre(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "UpdateGenre")
+
+	id, err := parseTaxonomyID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+
+	var input models.GenreInput
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("неверные данные"), http.StatusBadRequest, log, c))
+	}
+
+	genre, err := h.library.UpdateGenre(ctx, id, input)
+	if err != nil {
+		return taxonomyError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, genre)
+}
+
+// @Summary      Удалить жанр
+// @Description  **Поджанры переходят к родителю удалённого жанра**
+// @Tags         Taxonomy
+// @Produce      json
+// @Success      200  {object}  utils.RespOK "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Жанр не найден"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/genres/:id [delete]
+func (h *Handler) DeleteGenre(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "DeleteGenre")
+
+	id, err := parseTaxonomyID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+
+	if err := h.library.DeleteGenre(ctx, id); err != nil {
+		return taxonomyError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "Жанр удалён"})
+}
+
+// @Summary      Список тегов
+// @Description  **Все теги по алфавиту**
+// @Tags         Taxonomy
+// @Produce      json
+// @Success      200  {object}  []models.Tag "Успешный ответ"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/tags [get]
+func (h *Handler) GetTags(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "GetTags")
+
+	tags, err := h.library.ListTags(ctx)
+	if err != nil {
+		return taxonomyError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, tags)
+}
+
+// @Summary      Удалить тег
+// @Description  **Тег снимается со всех песен и групп**
+// @Tags         Taxonomy
+// @Produce      json
+// @Success      200  {object}  utils.RespOK "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Тег не найден"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/tags/:id [delete]
+func (h *Handler) DeleteTag(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "DeleteTag")
+
+	id, err := parseTaxonomyID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+
+	if err := h.library.DeleteTag(ctx, id); err != nil {
+		return taxonomyError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "Тег удалён"})
+}
+
+// @Summary      Жанры песни
+// @Description  **Заменяет жанры песни, пустой список снимает все**
+// @Tags         Taxonomy
+// @Accept       json
+// @Produce      json
+// @Param        Request body  models.GenresInput  true  "Жанры"
+// @Success      200  {object}  []models.Genre "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Песня или жанр не найдены"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/songs/:id/genres [put]
+func (h *Handler) SetSongGenres(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "SetSongGenres")
+
+	id, err := parseSongID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+	logging.AddField(ctx, logging.FieldSongID, id)
+
+	var input models.GenresInput
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("неверные данные"), http.StatusBadRequest, log, c))
+	}
+
+	genres, err := h.library.SetSongGenres(ctx, id, input.Genres)
+	if err != nil {
+		return taxonomyError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, genres)
+}
+
+// @Summary      Теги песни
+// @Description  **Заменяет теги песни, новые теги создаются, пустой список снимает все**
+// @Tags         Taxonomy
+// @Accept       json
+// @Produce      json
+// @Param        Request body  models.TagsInput  true  "Теги"
+// @Success      200  {object}  []models.Tag "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Песня не найдена"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/songs/:id/tags [put]
+func (h *Handler) SetSongTags(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "SetSongTags")
+
+	id, err := parseSongID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+	logging.AddField(ctx, logging.FieldSongID, id)
+
+	var input models.TagsInput
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("неверные данные"), http.StatusBadRequest, log, c))
+	}
+
+	tags, err := h.library.SetSongTags(ctx, id, input.Tags)
+	if err != nil {
+		return taxonomyError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, tags)
+}
+
+// @Summary      Жанры группы
+// @Description  **Заменяет жанры группы. В фильтрах и фасетах они достаются всем песням группы.**
+// @Tags         Taxonomy
+// @Accept       json
+// @Produce      json
+// @Param        Request body  models.GenresInput  true  "Жанры"
+// @Success      200  {object}  []models.Genre "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Группа или жанр не найдены"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/groups/:id/genres [put]
+func (h *Handler) SetGroupGenres(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "SetGroupGenres")
+
+	id, err := parseTaxonomyID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+
+	var input models.GenresInput
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("неверные данные"), http.StatusBadRequest, log, c))
+	}
+
+	genres, err := h.library.SetGroupGenres(ctx, id, input.Genres)
+	if err != nil {
+		return taxonomyError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, genres)
+}
+
+// @Summary      Теги группы
+// @Description  **Заменяет теги группы, новые теги создаются. В фильтрах и фасетах они достаются всем песням группы.**
+// @Tags         Taxonomy
+// @Accept       json
+// @Produce      json
+// @Param        Request body  models.TagsInput  true  "Теги"
+// @Success      200  {object}  []models.Tag "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Группа не найдена"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/groups/:id/tags [put]
+func (h *Handler) SetGroupTags(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "SetGroupTags")
+
+	id, err := parseTaxonomyID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+
+	var input models.TagsInput
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("неверные данные"), http.StatusBadRequest, log, c))
+	}
+
+	tags, err := h.library.SetGroupTags(ctx, id, input.Tags)
+	if err != nil {
+		return taxonomyError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, tags)
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"songLibrary/logging"
+	"songLibrary/models"
+	"songLibrary/services"
+	"songLibrary/utils"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// translationError переводит ошибки сервиса переводов в HTTP-ответ
+func translationError(c echo.Context, log *logrus.Entry, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidLang), errors.Is(err, services.ErrInvalidTranslation):
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	case errors.Is(err, services.ErrSongNotFound), errors.Is(err, services.ErrTranslationNotFound):
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusNotFound, log, c))
+	case errors.Is(err, services.ErrTranslationExists):
+		return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusConflict, log, c))
+	}
+	return c.JSON(utils.HttpResErrorRFC9457("DB error", err, http.StatusInternalServerError, log, c))
+}
+
+// @Summary      Добавить перевод
+// @Description  **Перевод текста целиком: части разделяются пустой строкой, их число должно совпадать с оригиналом. Метки частей ([Припев], [Chorus]) необязательны, но если есть, должны совпадать по типу.**
+// @Tags         Translations
+// @Accept       json
+// @Produce      json
+// @Param        Request body  models.TranslationInput  true  "Перевод"
+// @Success      200  {object}  []models.LyricsTranslation "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Песня не найдена"
+// @Failure      409  {object}  utils.ProblemDetails "Перевод на этот язык уже добавлен"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/songs/:id/translations [post]
+func (h *Handler) AddTranslation(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "AddTranslation")
+
+	id, err := parseSongID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+	logging.AddField(ctx, logging.FieldSongID, id)
+
+	var input models.TranslationInput
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("неверные данные"), http.StatusBadRequest, log, c))
+	}
+
+	log.WithField("lang", input.Lang).Debug("Язык перевода") // Debug-лог
+
+	result, err := h.library.AddTranslation(ctx, id, input.Lang, input.Text)
+	if err != nil {
+		return translationError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary      Обновить перевод
+// @Description  **Заменяет перевод на язык из пути, lang в теле не учитывается**
+// @Tags         Translations
+// @Accept       json
+// @Produce      json
+// @Param        Request body  models.TranslationInput  true  "Перевод"
+// @Success      200  {object}  []models.LyricsTranslation "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Песня или перевод не найдены"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/songs/:id/translations/:lang [put]
+func (h *Handler) UpdateTranslation(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "UpdateTranslation")
+
+	id, err := parseSongID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+	logging.AddField(ctx, logging.FieldSongID, id)
+
+	var input models.TranslationInput
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", errors.New("неверные данные"), http.StatusBadRequest, log, c))
+	}
+
+	log.WithField("lang", c.Param("lang")).Debug("Язык перевода") // Debug-лог
+
+	result, err := h.library.UpdateTranslation(ctx, id, c.Param("lang"), input.Text)
+	if err != nil {
+		return translationError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary      Удалить перевод
+// @Description  **Удалить перевод песни на язык**
+// @Tags         Translations
+// @Produce      json
+// @Success      200  {object}  utils.RespOK "Успешный ответ"
+// @Failure      400  {object}  utils.ProblemDetails "Ошибка валидации"
+// @Failure      404  {object}  utils.ProblemDetails "Перевод не найден"
+// @Failure      500  {object}  utils.ProblemDetails "Internal Server Error"
+// @Router       /api/v1/library/songs/:id/translations/:lang [delete]
+func (h *Handler) DeleteTranslation(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.Ctx(ctx).WithField("prefix", "DeleteTranslation")
+
+	id, err := parseSongID(c)
+	if err != nil {
+		return c.JSON(utils.HttpResErrorRFC9457("error", err, http.StatusBadRequest, log, c))
+	}
+	logging.AddField(ctx, logging.FieldSongID, id)
+
+	if err := h.library.DeleteTranslation(ctx, id, c.Param("lang")); err != nil {
+		return translationError(c, log, err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "Перевод удалён"})
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"songLibrary/handlers"
+	"songLibrary/initializers"
 
 	_ "songLibrary/docs"
 
@@ -10,13 +11,35 @@ import (
 	echoSwagger "github.com/swaggo/echo-swagger"
 )
 
-func registerHandlers(e *echo.Echo, h *handlers.Handler) {
+// Маршруты загрузки файлов, для них общий предел размера запроса не действует
+var uploadPaths = []string{
+	"/api/v1/admin/import/audio",
+	"/api/v1/library/songs/:id/cover",
+	"/api/v1/library/groups/:id/photo",
+}
+
+func registerHandlers(e *echo.Echo, h *handlers.Handler, health *handlers.HealthHandler, audioImport initializers.AudioImportConfig, mediaConfig initializers.MediaConfig) {
+	// Health
+	e.GET("/healthz", health.Healthz)
+	e.GET("/readyz", health.Readyz)
+
 	api := e.Group("/api/v1")
 	api.Use(middleware.CORS())
 
 	// Swagger doc
 	api.GET("/doc/*", echoSwagger.EchoWrapHandler())
 
+	// Admin
+	admin := api.Group("/admin", h.RequireAPIKey)
+	admin.GET("/log-levels", h.GetLogLevels)
+	admin.PUT("/log-levels/:logger", h.SetLogLevel)
+	admin.GET("/external-cache", h.GetExternalCache)
+	admin.DELETE("/external-cache", h.PurgeExternalCache)
+	admin.GET("/proposals", h.GetProposals)
+	admin.POST("/proposals/:id/accept", h.AcceptProposal)
+	admin.POST("/proposals/:id/reject", h.RejectProposal)
+	admin.POST("/import/audio", h.ImportAudio, middleware.BodyLimit(audioImport.MaxUpload))
+
 	// Library
 	library := api.Group("/library")
 
@@ -30,11 +53,340 @@ func registerHandlers(e *echo.Echo, h *handlers.Handler) {
 		AllowMethods: []string{echo.POST},
 	}))
 
+	library.GET("/songs/:id", h.GetSong, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.GET},
+	}))
+
 	library.GET("/songs/:id/lyrics", h.GetLyrics, middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins: []string{"*"},
 		AllowMethods: []string{echo.GET},
 	}))
 
+	library.GET("/songs/:id/lyrics/lines", h.GetLyricLines, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.GET},
+	}))
+
+	library.POST("/songs/:id/lyrics/lrc", h.ImportLyricsLRC, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.POST},
+	}))
+
+	library.GET("/songs/:id/lyrics/active", h.GetActiveLine, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.GET},
+	}))
+
+	library.POST("/songs/:id/translations", h.AddTranslation, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.POST},
+	}))
+
+	library.PUT("/songs/:id/translations/:lang", h.UpdateTranslation, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.PUT},
+	}))
+
+	library.DELETE("/songs/:id/translations/:lang", h.DeleteTranslation, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.DELETE},
+	}))
+
+	library.GET("/songs/:id/chords", h.GetChords, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.GET},
+	}))
+
+	library.PUT("/songs/:id/chords", h.SetChords, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.PUT},
+	}))
+
+	library.DELETE("/songs/:id/chords", h.DeleteChords, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.DELETE},
+	}))
+
+	library.GET("/albums", h.GetAlbums, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.GET},
+	}))
+
+	library.POST("/albums", h.CreateAlbum, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.POST},
+	}))
+
+	library.GET("/albums/:id", h.GetAlbum, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.GET},
+	}))
+
+	library.PUT("/albums/:id", h.UpdateAlbum, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.PUT},
+	}))
+
+	library.DELETE("/albums/:id", h.DeleteAlbum, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.DELETE},
+	}))
+
+	library.POST("/albums/:id/tracks", h.AttachAlbumSong, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.POST},
+	}))
+
+	library.DELETE("/albums/:id/tracks/:song_id", h.DetachAlbumSong, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.DELETE},
+	}))
+
+	library.GET("/songs/:id/credits", h.GetSongCredits, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.GET},
+	}))
+
+	library.POST("/songs/:id/credits", h.AddSongCredit, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.POST},
+	}))
+
+	library.DELETE("/songs/:id/credits/:credit_id", h.DeleteSongCredit, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.DELETE},
+	}))
+
+	library.GET("/songs/:id/versions", h.GetSongVersions, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.GET},
+	}))
+
+	library.POST("/songs/:id/relations", h.AddSongRelation, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.POST},
+	}))
+
+	library.DELETE("/songs/:id/relations/:relation_id", h.DeleteSongRelation, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.DELETE},
+	}))
+
+	library.GET("/genres", h.GetGenres, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.GET},
+	}))
+
+	library.POST("/genres", h.CreateGenre, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.POST},
+	}))
+
+	library.PUT("/genres/:id", h.UpdateGenre, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.PUT},
+	}))
+
+	library.DELETE("/genres/:id", h.DeleteGenre, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.DELETE},
+	}))
+
+	library.GET("/tags", h.GetTags, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.GET},
+	}))
+
+	library.DELETE("/tags/:id", h.DeleteTag, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.DELETE},
+	}))
+
+	library.PUT("/groups/:id/genres", h.SetGroupGenres, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.PUT},
+	}))
+
+	library.PUT("/groups/:id/tags", h.SetGroupTags, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.PUT},
+	}))
+
+	library.PUT("/songs/:id/genres", h.SetSongGenres, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.PUT},
+	}))
+
+	library.PUT("/songs/:id/tags", h.SetSongTags, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.PUT},
+	}))
+
+	// Плейлисты доступны без ключа, с ключом владелец видит и меняет свои
+	library.GET("/playlists", h.GetPlaylists, h.OptionalAPIKey, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.GET},
+	}))
+
+	library.POST("/playlists", h.CreatePlaylist, h.OptionalAPIKey, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.POST},
+	}))
+
+	library.GET("/playlists/:id", h.GetPlaylist, h.OptionalAPIKey, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.GET},
+	}))
+
+	library.PUT("/playlists/:id", h.UpdatePlaylist, h.OptionalAPIKey, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.PUT},
+	}))
+
+	library.DELETE("/playlists/:id", h.DeletePlaylist, h.OptionalAPIKey, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.DELETE},
+	}))
+
+	library.GET("/playlists/:id/export", h.ExportPlaylist, h.OptionalAPIKey, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.GET},
+	}))
+
+	library.POST("/playlists/:id/entries", h.AddPlaylistEntry, h.OptionalAPIKey, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.POST},
+	}))
+
+	library.PUT("/playlists/:id/entries/:entry_id/position", h.MovePlaylistEntry, h.OptionalAPIKey, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.PUT},
+	}))
+
+	library.DELETE("/playlists/:id/entries/:entry_id", h.RemovePlaylistEntry, h.OptionalAPIKey, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.DELETE},
+	}))
+
+	// Сетлисты видит и меняет только владелец ключа
+	library.GET("/setlists", h.GetSetlists, h.RequireAPIKey, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.GET},
+	}))
+
+	library.POST("/setlists", h.CreateSetlist, h.RequireAPIKey, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.POST},
+	}))
+
+	library.GET("/setlists/:id", h.GetSetlist, h.RequireAPIKey, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.GET},
+	}))
+
+	library.PUT("/setlists/:id", h.UpdateSetlist, h.RequireAPIKey, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.PUT},
+	}))
+
+	library.DELETE("/setlists/:id", h.DeleteSetlist, h.RequireAPIKey, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.DELETE},
+	}))
+
+	library.GET("/setlists/:id/print", h.PrintSetlist, h.RequireAPIKey, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.GET},
+	}))
+
+	library.POST("/setlists/:id/entries", h.AddSetlistEntry, h.RequireAPIKey, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.POST},
+	}))
+
+	library.PUT("/setlists/:id/entries/:entry_id", h.UpdateSetlistEntry, h.RequireAPIKey, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.PUT},
+	}))
+
+	library.PUT("/setlists/:id/entries/:entry_id/position", h.MoveSetlistEntry, h.RequireAPIKey, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.PUT},
+	}))
+
+	library.DELETE("/setlists/:id/entries/:entry_id", h.RemoveSetlistEntry, h.RequireAPIKey, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.DELETE},
+	}))
+
+	// Песенники собираются в фоне, статус и файл доступны владельцу ключа
+	library.POST("/songbooks", h.CreateSongbook, h.RequireAPIKey, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.POST},
+	}))
+
+	library.GET("/songbooks/:id", h.GetSongbook, h.RequireAPIKey, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.GET},
+	}))
+
+	library.GET("/songbooks/:id/download", h.DownloadSongbook, h.RequireAPIKey, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.GET},
+	}))
+
+	// Обложки и фотографии: загрузка с пределом media.max_upload и раздача из хранилища файлов
+	library.PUT("/songs/:id/cover", h.SetSongCover, middleware.BodyLimit(mediaConfig.MaxUpload), middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.PUT},
+	}))
+
+	library.DELETE("/songs/:id/cover", h.DeleteSongCover, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.DELETE},
+	}))
+
+	library.PUT("/groups/:id/photo", h.SetGroupPhoto, middleware.BodyLimit(mediaConfig.MaxUpload), middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.PUT},
+	}))
+
+	library.DELETE("/groups/:id/photo", h.DeleteGroupPhoto, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.DELETE},
+	}))
+
+	library.GET("/images/:id", h.GetImage, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.GET},
+	}))
+
+	library.GET("/images/:id/thumbnail", h.GetImageThumbnail, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.GET},
+	}))
+
+	library.PUT("/songs/:id/explicit", h.SetExplicit, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.PUT},
+	}))
+
+	library.GET("/songs/:id/links", h.GetSongLinks, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.GET},
+	}))
+
+	library.POST("/songs/:id/links", h.AddSongLink, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.POST},
+	}))
+
+	library.DELETE("/songs/:id/links/:link_id", h.DeleteSongLink, middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{echo.DELETE},
+	}))
+
 	library.PUT("/songs/edit/:id", h.EditSong, middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins: []string{"*"},
 		AllowMethods: []string{echo.PUT},
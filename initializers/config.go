@@ -1,100 +1,842 @@
 package initializers
 
 import (
+	"bytes"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
-	log "github.com/sirupsen/logrus"
+	"songLibrary/logging"
+
+	"github.com/BurntSushi/toml"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	HealthCheckDB          = "db"
+	HealthCheckMigrations  = "migrations"
+	HealthCheckExternalAPI = "external_api"
+)
+
+const (
+	ProviderTypeHTTP = "http"
+	ProviderTypeFile = "file"
+
+	// Первый успешно ответивший провайдер отдаёт все поля
+	ProviderStrategyPriority = "priority"
+	// Каждое поле берётся у первого провайдера, который его заполнил
+	ProviderStrategyMerge = "merge"
+
+	// Имя провайдера по умолчанию - внешний API /info
+	DefaultProviderName = "info"
+)
+
+// Имена источников, которые не являются провайдерами, см. models.Provenance
+var reservedProviderNames = []string{"manual", "import", "tags"}
+
+// Значение, которым заменяются секреты при выводе конфига
+const redacted = "******"
+
+var (
+	dbNameRegexp    = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	bodyLimitRegexp = regexp.MustCompile(`^[0-9]+[KMGTP]?$`)
 )
 
+// Config собирается из нескольких источников, каждый следующий перекрывает предыдущий:
+// значения по умолчанию -> файл (YAML/TOML) -> переменные окружения -> флаги
+type Config struct {
+	Server      ServerConfig      `yaml:"server" toml:"server"`
+	DB          DBConfig          `yaml:"db" toml:"db"`
+	ExternalAPI ExternalAPIConfig `yaml:"external_api" toml:"external_api"`
+	Cache       CacheConfig       `yaml:"cache" toml:"cache"`
+	Refresh     RefreshConfig     `yaml:"refresh" toml:"refresh"`
+	LinkCheck   LinkCheckConfig   `yaml:"link_check" toml:"link_check"`
+	Songbook    SongbookConfig    `yaml:"songbook" toml:"songbook"`
+	AudioImport AudioImportConfig `yaml:"audio_import" toml:"audio_import"`
+	Media       MediaConfig       `yaml:"media" toml:"media"`
+	Explicit    ExplicitConfig    `yaml:"explicit" toml:"explicit"`
+	Log         logging.Config    `yaml:"log" toml:"log"`
+}
+
 type ServerConfig struct {
-	Port      int
-	Domain    string
-	DebugMode bool
+	Port      int           `yaml:"port" toml:"port"`
+	Domain    string        `yaml:"domain" toml:"domain"`
+	DebugMode bool          `yaml:"debug" toml:"debug"`
+	Tracing   TracingConfig `yaml:"tracing" toml:"tracing"`
+	Health    HealthConfig  `yaml:"health" toml:"health"`
+	HTTP      HTTPConfig    `yaml:"http" toml:"http"`
+}
+
+type HealthConfig struct {
+	Checks  []string      `yaml:"checks" toml:"checks"`
+	Timeout time.Duration `yaml:"timeout" toml:"timeout"`
+}
+
+type HTTPConfig struct {
+	ReadTimeout       time.Duration `yaml:"read_timeout" toml:"read_timeout"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" toml:"read_header_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	MaxHeaderBytes    int           `yaml:"max_header_bytes" toml:"max_header_bytes"`
+	BodyLimit         string        `yaml:"body_limit" toml:"body_limit"`
+	// Сколько ждать завершения текущих запросов при остановке
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	// Пауза между отключением readiness и остановкой приёма соединений
+	ShutdownDelay time.Duration `yaml:"shutdown_delay" toml:"shutdown_delay"`
 }
 
 type DBConfig struct {
-	Port int
-	User string
-	Pswd string
-	Name string
-	Host string
-	DSN  string
+	Port int    `yaml:"port" toml:"port"`
+	User string `yaml:"user" toml:"user"`
+	Pswd string `yaml:"password" toml:"password"`
+	Name string `yaml:"name" toml:"name"`
+	Host string `yaml:"host" toml:"host"`
+	// Запросы дольше порога пишутся в лог с уровнем warn
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold" toml:"slow_query_threshold"`
 }
 
-func FormServerConfig() ServerConfig {
-	var config ServerConfig
+type ExternalAPIConfig struct {
+	Addr    string        `yaml:"addr" toml:"addr"`
+	Timeout time.Duration `yaml:"timeout" toml:"timeout"`
+	// Сколько хранить ответы /info в БД, 0 - не сохранять
+	CacheTTL time.Duration `yaml:"cache_ttl" toml:"cache_ttl"`
+	// Отдавать сохранённый ответ (даже устаревший), если внешний API недоступен
+	OfflineFallback bool `yaml:"offline_fallback" toml:"offline_fallback"`
+	// priority или merge
+	Strategy string `yaml:"strategy" toml:"strategy"`
+	// Провайдеры в порядке приоритета, задаются только в файле конфигурации.
+	// Если пусто - один HTTP-провайдер с адресом addr.
+	Providers []ProviderConfig `yaml:"providers" toml:"providers"`
+}
 
-	log.Info("Начинаем формировать серверный конфиг") // Info-лог
+// ProviderConfigs - провайдеры в порядке приоритета с подставленными значениями по
+// умолчанию: без списка один HTTP-провайдер, у HTTP без addr - адрес external_api.addr
+func (c ExternalAPIConfig) ProviderConfigs() []ProviderConfig {
+	if len(c.Providers) == 0 {
+		return []ProviderConfig{{Name: DefaultProviderName, Type: ProviderTypeHTTP, Addr: c.Addr}}
+	}
 
-	port, err := strconv.ParseInt(os.Getenv("PORT"), 10, 64)
-	if err != nil {
-		fmt.Printf("error: ошибка парсинга %s использую дефолтное значение\n", os.Getenv("PORT"))
-		config.Port = 8080
+	configs := slices.Clone(c.Providers)
+	for i := range configs {
+		if configs[i].Type == ProviderTypeHTTP && configs[i].Addr == "" {
+			configs[i].Addr = c.Addr
+		}
 	}
-	config.Port = int(port)
+	return configs
+}
+
+type ProviderConfig struct {
+	Name string `yaml:"name" toml:"name"`
+	Type string `yaml:"type" toml:"type"`
+	// Для http: адрес API с ручкой /info, по умолчанию external_api.addr
+	Addr string `yaml:"addr,omitempty" toml:"addr,omitempty"`
+	// Для file: путь к каталогу в формате JSON Lines (как вывод команды export)
+	Path string `yaml:"path,omitempty" toml:"path,omitempty"`
+}
+
+// CacheConfig настраивает кеш ответов ручек чтения. Кеш живёт в памяти процесса,
+// поэтому изменения из CLI видны серверу только после истечения TTL.
+type CacheConfig struct {
+	Enabled bool          `yaml:"enabled" toml:"enabled"`
+	Size    int           `yaml:"size" toml:"size"`
+	TTL     time.Duration `yaml:"ttl" toml:"ttl"`
+}
+
+// RefreshConfig настраивает периодическую сверку песен с провайдерами
+type RefreshConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// Как часто запускать проход
+	Interval time.Duration `yaml:"interval" toml:"interval"`
+	// Песни, которые не сверялись дольше, попадают в проход
+	MaxAge time.Duration `yaml:"max_age" toml:"max_age"`
+	// Сколько песен проверять за один проход
+	BatchSize int `yaml:"batch_size" toml:"batch_size"`
+}
+
+// LinkCheckConfig настраивает фоновую проверку ссылок на песни
+type LinkCheckConfig struct {
+	Enabled  bool          `yaml:"enabled" toml:"enabled"`
+	Interval time.Duration `yaml:"interval" toml:"interval"`
+	// Ссылки, проверенные раньше, проверяются снова
+	RecheckAfter time.Duration `yaml:"recheck_after" toml:"recheck_after"`
+	BatchSize    int           `yaml:"batch_size" toml:"batch_size"`
+	// Не больше стольких запросов в секунду
+	RatePerSecond float64 `yaml:"rate_per_second" toml:"rate_per_second"`
+	// После скольких неудачных проверок подряд ссылка считается битой (404/410 - сразу)
+	FailureThreshold int `yaml:"failure_threshold" toml:"failure_threshold"`
+}
+
+// SongbookConfig настраивает фоновую сборку песенников PDF и EPUB
+type SongbookConfig struct {
+	// Как часто проверять очередь заданий
+	Interval time.Duration `yaml:"interval" toml:"interval"`
+	// Сколько хранить готовый файл
+	TTL time.Duration `yaml:"ttl" toml:"ttl"`
+	// Больше песен в один песенник не берём
+	MaxSongs int `yaml:"max_songs" toml:"max_songs"`
+	// TTF-шрифты для PDF. Без них - встроенные в приложение шрифты Go с кириллицей.
+	Font     string `yaml:"font" toml:"font"`
+	FontBold string `yaml:"font_bold" toml:"font_bold"`
+}
+
+// AudioImportConfig настраивает импорт песен из тегов аудиофайлов
+type AudioImportConfig struct {
+	// Предел размера запроса с файлами, для ручки импорта вместо server.http.body_limit
+	MaxUpload string `yaml:"max_upload" toml:"max_upload"`
+	// Больше файлов в одном запросе не принимаем
+	MaxFiles int `yaml:"max_files" toml:"max_files"`
+	// Дозапрашивать у провайдеров песни, в тегах которых нет текста
+	FetchMissing bool `yaml:"fetch_missing" toml:"fetch_missing"`
+}
+
+// Хранилище файлов на локальном диске, S3-совместимое добавится отдельным значением
+const MediaStorageLocal = "local"
+
+// MediaConfig настраивает хранение обложек песен и фотографий групп
+type MediaConfig struct {
+	// Хранилище файлов, пока только local
+	Storage string `yaml:"storage" toml:"storage"`
+	// Для local: каталог с файлами
+	Path string `yaml:"path" toml:"path"`
+	// Предел размера загружаемого изображения, для ручек загрузки вместо server.http.body_limit
+	MaxUpload string `yaml:"max_upload" toml:"max_upload"`
+	// Сторона квадрата, в который вписывается миниатюра, px
+	ThumbnailSize int `yaml:"thumbnail_size" toml:"thumbnail_size"`
+}
+
+// Встроенные словари ненормативной лексики
+const (
+	ExplicitLanguageRU = "ru"
+	ExplicitLanguageEN = "en"
+)
+
+// ExplicitConfig настраивает поиск ненормативной лексики в текстах песен
+type ExplicitConfig struct {
+	// Выключенный поиск не трогает уже сохранённые отметки
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// Встроенные словари: ru, en
+	Languages []string `yaml:"languages" toml:"languages"`
+	// Файл с дополнительными словами и исключениями в формате встроенных словарей
+	WordsFile string `yaml:"words_file" toml:"words_file"`
+}
+
+// DSN строка подключения к БД. Содержит пароль, поэтому нигде не логируется.
+func (c DBConfig) DSN() string {
+	return c.dsn(c.Name)
+}
+
+func (c DBConfig) dsn(dbName string) string {
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=disable", c.Host, c.User, c.Pswd, dbName, c.Port)
+}
+
+// ValidationError содержит все найденные проблемы конфигурации сразу
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "некорректная конфигурация:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Server: ServerConfig{
+			Port:   8080,
+			Domain: "localhost",
+			Tracing: TracingConfig{
+				ServiceName: "songLibrary",
+				Exporter:    TracingExporterNone,
+				SampleRatio: 1,
+			},
+			Health: HealthConfig{
+				Checks:  []string{HealthCheckDB, HealthCheckMigrations, HealthCheckExternalAPI},
+				Timeout: 2 * time.Second,
+			},
+			HTTP: HTTPConfig{
+				ReadTimeout:       15 * time.Second,
+				ReadHeaderTimeout: 5 * time.Second,
+				WriteTimeout:      30 * time.Second,
+				IdleTimeout:       120 * time.Second,
+				MaxHeaderBytes:    1 << 20,
+				BodyLimit:         "2M",
+				ShutdownTimeout:   20 * time.Second,
+			},
+		},
+		DB: DBConfig{
+			Port:               5432,
+			Name:               "postgres",
+			Host:               "localhost",
+			SlowQueryThreshold: 200 * time.Millisecond,
+		},
+		ExternalAPI: ExternalAPIConfig{
+			Timeout:         10 * time.Second,
+			CacheTTL:        7 * 24 * time.Hour,
+			OfflineFallback: true,
+			Strategy:        ProviderStrategyPriority,
+		},
+		Cache: CacheConfig{
+			Enabled: true,
+			Size:    1000,
+			TTL:     time.Minute,
+		},
+		Refresh: RefreshConfig{
+			Interval:  time.Hour,
+			MaxAge:    30 * 24 * time.Hour,
+			BatchSize: 50,
+		},
+		LinkCheck: LinkCheckConfig{
+			Interval:         time.Hour,
+			RecheckAfter:     24 * time.Hour,
+			BatchSize:        100,
+			RatePerSecond:    2,
+			FailureThreshold: 3,
+		},
+		Songbook: SongbookConfig{
+			Interval: 5 * time.Second,
+			TTL:      24 * time.Hour,
+			MaxSongs: 500,
+		},
+		AudioImport: AudioImportConfig{
+			MaxUpload:    "256M",
+			MaxFiles:     100,
+			FetchMissing: true,
+		},
+		Media: MediaConfig{
+			Storage:       MediaStorageLocal,
+			Path:          "uploads",
+			MaxUpload:     "10M",
+			ThumbnailSize: 320,
+		},
+		Explicit: ExplicitConfig{
+			Enabled:   true,
+			Languages: []string{ExplicitLanguageRU, ExplicitLanguageEN},
+		},
+		Log: logging.Config{
+			Format: logging.FormatJSON,
+			Level:  "info",
+		},
+	}
+}
+
+// configField описывает параметр, который можно задать через env и флаг
+type configField struct {
+	key string // имя ключа в файле, из него же формируется имя флага
+	env string
+	set func(c *Config, value string) error
+}
+
+func stringField(key, env string, target func(c *Config) *string) configField {
+	return configField{key: key, env: env, set: func(c *Config, value string) error {
+		*target(c) = value
+		return nil
+	}}
+}
+
+func intField(key, env string, target func(c *Config) *int) configField {
+	return configField{key: key, env: env, set: func(c *Config, value string) error {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("ожидается целое число, получено %q", value)
+		}
+		*target(c) = parsed
+		return nil
+	}}
+}
+
+func floatField(key, env string, target func(c *Config) *float64) configField {
+	return configField{key: key, env: env, set: func(c *Config, value string) error {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("ожидается число, получено %q", value)
+		}
+		*target(c) = parsed
+		return nil
+	}}
+}
+
+func boolField(key, env string, target func(c *Config) *bool) configField {
+	return configField{key: key, env: env, set: func(c *Config, value string) error {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("ожидается true/false, получено %q", value)
+		}
+		*target(c) = parsed
+		return nil
+	}}
+}
+
+func durationField(key, env string, target func(c *Config) *time.Duration) configField {
+	return configField{key: key, env: env, set: func(c *Config, value string) error {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("ожидается длительность вида 10s/1m, получено %q", value)
+		}
+		*target(c) = parsed
+		return nil
+	}}
+}
+
+func listField(key, env string, target func(c *Config) *[]string) configField {
+	return configField{key: key, env: env, set: func(c *Config, value string) error {
+		var list []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		*target(c) = list
+		return nil
+	}}
+}
+
+// mapField разбирает значения вида key1=value1,key2=value2
+func mapField(key, env string, target func(c *Config) *map[string]string) configField {
+	return configField{key: key, env: env, set: func(c *Config, value string) error {
+		result := make(map[string]string)
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			k, v, ok := strings.Cut(item, "=")
+			if !ok {
+				return fmt.Errorf("ожидается ключ=значение, получено %q", item)
+			}
+			result[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+		*target(c) = result
+		return nil
+	}}
+}
+
+var configFields = []configField{
+	intField("server.port", "PORT", func(c *Config) *int { return &c.Server.Port }),
+	stringField("server.domain", "DOMAIN_NAME", func(c *Config) *string { return &c.Server.Domain }),
+	boolField("server.debug", "DEBUG", func(c *Config) *bool { return &c.Server.DebugMode }),
+
+	stringField("server.tracing.service_name", "TRACING_SERVICE_NAME", func(c *Config) *string { return &c.Server.Tracing.ServiceName }),
+	stringField("server.tracing.exporter", "TRACING_EXPORTER", func(c *Config) *string { return &c.Server.Tracing.Exporter }),
+	stringField("server.tracing.otlp_endpoint", "TRACING_OTLP_ENDPOINT", func(c *Config) *string { return &c.Server.Tracing.OTLPEndpoint }),
+	boolField("server.tracing.otlp_insecure", "TRACING_OTLP_INSECURE", func(c *Config) *bool { return &c.Server.Tracing.OTLPInsecure }),
+	floatField("server.tracing.sample_ratio", "TRACING_SAMPLE_RATIO", func(c *Config) *float64 { return &c.Server.Tracing.SampleRatio }),
+
+	listField("server.health.checks", "HEALTH_CHECKS", func(c *Config) *[]string { return &c.Server.Health.Checks }),
+	durationField("server.health.timeout", "HEALTH_CHECK_TIMEOUT", func(c *Config) *time.Duration { return &c.Server.Health.Timeout }),
+
+	durationField("server.http.read_timeout", "HTTP_READ_TIMEOUT", func(c *Config) *time.Duration { return &c.Server.HTTP.ReadTimeout }),
+	durationField("server.http.read_header_timeout", "HTTP_READ_HEADER_TIMEOUT", func(c *Config) *time.Duration { return &c.Server.HTTP.ReadHeaderTimeout }),
+	durationField("server.http.write_timeout", "HTTP_WRITE_TIMEOUT", func(c *Config) *time.Duration { return &c.Server.HTTP.WriteTimeout }),
+	durationField("server.http.idle_timeout", "HTTP_IDLE_TIMEOUT", func(c *Config) *time.Duration { return &c.Server.HTTP.IdleTimeout }),
+	intField("server.http.max_header_bytes", "HTTP_MAX_HEADER_BYTES", func(c *Config) *int { return &c.Server.HTTP.MaxHeaderBytes }),
+	stringField("server.http.body_limit", "HTTP_BODY_LIMIT", func(c *Config) *string { return &c.Server.HTTP.BodyLimit }),
+	durationField("server.http.shutdown_timeout", "HTTP_SHUTDOWN_TIMEOUT", func(c *Config) *time.Duration { return &c.Server.HTTP.ShutdownTimeout }),
+	durationField("server.http.shutdown_delay", "HTTP_SHUTDOWN_DELAY", func(c *Config) *time.Duration { return &c.Server.HTTP.ShutdownDelay }),
+
+	stringField("db.host", "DB_HOST", func(c *Config) *string { return &c.DB.Host }),
+	intField("db.port", "DB_PORT", func(c *Config) *int { return &c.DB.Port }),
+	stringField("db.user", "DB_USER", func(c *Config) *string { return &c.DB.User }),
+	stringField("db.password", "DB_PSWD", func(c *Config) *string { return &c.DB.Pswd }),
+	stringField("db.name", "DB_NAME", func(c *Config) *string { return &c.DB.Name }),
+	durationField("db.slow_query_threshold", "DB_SLOW_QUERY_THRESHOLD", func(c *Config) *time.Duration { return &c.DB.SlowQueryThreshold }),
+
+	stringField("external_api.addr", "EXTERNAL_API_ADDR", func(c *Config) *string { return &c.ExternalAPI.Addr }),
+	durationField("external_api.timeout", "EXTERNAL_API_TIMEOUT", func(c *Config) *time.Duration { return &c.ExternalAPI.Timeout }),
+	durationField("external_api.cache_ttl", "EXTERNAL_API_CACHE_TTL", func(c *Config) *time.Duration { return &c.ExternalAPI.CacheTTL }),
+	boolField("external_api.offline_fallback", "EXTERNAL_API_OFFLINE_FALLBACK", func(c *Config) *bool { return &c.ExternalAPI.OfflineFallback }),
+	stringField("external_api.strategy", "EXTERNAL_API_STRATEGY", func(c *Config) *string { return &c.ExternalAPI.Strategy }),
+
+	boolField("cache.enabled", "CACHE_ENABLED", func(c *Config) *bool { return &c.Cache.Enabled }),
+	intField("cache.size", "CACHE_SIZE", func(c *Config) *int { return &c.Cache.Size }),
+	durationField("cache.ttl", "CACHE_TTL", func(c *Config) *time.Duration { return &c.Cache.TTL }),
+
+	boolField("refresh.enabled", "REFRESH_ENABLED", func(c *Config) *bool { return &c.Refresh.Enabled }),
+	durationField("refresh.interval", "REFRESH_INTERVAL", func(c *Config) *time.Duration { return &c.Refresh.Interval }),
+	durationField("refresh.max_age", "REFRESH_MAX_AGE", func(c *Config) *time.Duration { return &c.Refresh.MaxAge }),
+	intField("refresh.batch_size", "REFRESH_BATCH_SIZE", func(c *Config) *int { return &c.Refresh.BatchSize }),
+
+	boolField("link_check.enabled", "LINK_CHECK_ENABLED", func(c *Config) *bool { return &c.LinkCheck.Enabled }),
+	durationField("link_check.interval", "LINK_CHECK_INTERVAL", func(c *Config) *time.Duration { return &c.LinkCheck.Interval }),
+	durationField("link_check.recheck_after", "LINK_CHECK_RECHECK_AFTER", func(c *Config) *time.Duration { return &c.LinkCheck.RecheckAfter }),
+	intField("link_check.batch_size", "LINK_CHECK_BATCH_SIZE", func(c *Config) *int { return &c.LinkCheck.BatchSize }),
+	floatField("link_check.rate_per_second", "LINK_CHECK_RATE", func(c *Config) *float64 { return &c.LinkCheck.RatePerSecond }),
+	intField("link_check.failure_threshold", "LINK_CHECK_FAILURE_THRESHOLD", func(c *Config) *int { return &c.LinkCheck.FailureThreshold }),
+
+	durationField("songbook.interval", "SONGBOOK_INTERVAL", func(c *Config) *time.Duration { return &c.Songbook.Interval }),
+	durationField("songbook.ttl", "SONGBOOK_TTL", func(c *Config) *time.Duration { return &c.Songbook.TTL }),
+	intField("songbook.max_songs", "SONGBOOK_MAX_SONGS", func(c *Config) *int { return &c.Songbook.MaxSongs }),
+	stringField("songbook.font", "SONGBOOK_FONT", func(c *Config) *string { return &c.Songbook.Font }),
+	stringField("songbook.font_bold", "SONGBOOK_FONT_BOLD", func(c *Config) *string { return &c.Songbook.FontBold }),
+
+	stringField("audio_import.max_upload", "AUDIO_IMPORT_MAX_UPLOAD", func(c *Config) *string { return &c.AudioImport.MaxUpload }),
+	intField("audio_import.max_files", "AUDIO_IMPORT_MAX_FILES", func(c *Config) *int { return &c.AudioImport.MaxFiles }),
+	boolField("audio_import.fetch_missing", "AUDIO_IMPORT_FETCH_MISSING", func(c *Config) *bool { return &c.AudioImport.FetchMissing }),
+
+	stringField("media.storage", "MEDIA_STORAGE", func(c *Config) *string { return &c.Media.Storage }),
+	stringField("media.path", "MEDIA_PATH", func(c *Config) *string { return &c.Media.Path }),
+	stringField("media.max_upload", "MEDIA_MAX_UPLOAD", func(c *Config) *string { return &c.Media.MaxUpload }),
+	intField("media.thumbnail_size", "MEDIA_THUMBNAIL_SIZE", func(c *Config) *int { return &c.Media.ThumbnailSize }),
 
-	log.WithField("Server config.Port", config.Port).Debug("Установлено значение порта сервера") // Debug-лог
+	boolField("explicit.enabled", "EXPLICIT_ENABLED", func(c *Config) *bool { return &c.Explicit.Enabled }),
+	listField("explicit.languages", "EXPLICIT_LANGUAGES", func(c *Config) *[]string { return &c.Explicit.Languages }),
+	stringField("explicit.words_file", "EXPLICIT_WORDS_FILE", func(c *Config) *string { return &c.Explicit.WordsFile }),
 
-	config.Domain = os.Getenv("DOMAIN_NAME")
-	if len(config.Domain) == 0 {
-		fmt.Printf("error: ошибка парсинга %s использую дефолтное значение\n", os.Getenv("DOMAIN_NAME"))
-		config.Domain = "localhost"
+	stringField("log.format", "LOG_FORMAT", func(c *Config) *string { return &c.Log.Format }),
+	stringField("log.level", "LOG_LEVEL", func(c *Config) *string { return &c.Log.Level }),
+	mapField("log.levels", "LOG_LEVELS", func(c *Config) *map[string]string { return &c.Log.Levels }),
+}
+
+func (f configField) flagName() string {
+	return strings.NewReplacer(".", "-", "_", "-").Replace(f.key)
+}
+
+// RegisterConfigFlags добавляет в набор флагов -config и по флагу на каждый параметр.
+// Возвращает функцию, которая собирает итоговый конфиг после fs.Parse.
+func RegisterConfigFlags(fs *flag.FlagSet) func() (Config, error) {
+	configFile := fs.String("config", "", "Путь к файлу конфигурации (.yaml/.yml/.toml), по умолчанию env CONFIG_FILE")
+	debug := fs.Bool("debug", false, "Дебаг-режим")
+
+	for _, field := range configFields {
+		fs.String(field.flagName(), "", fmt.Sprintf("%s (env %s)", field.key, field.env))
 	}
 
-	log.WithField("Server config.Domain", config.Domain).Debug("Установлено значение домена сервера") // Debug-лог
+	return func() (Config, error) {
+		flags := make(map[string]string)
+		fs.Visit(func(f *flag.Flag) {
+			flags[f.Name] = f.Value.String()
+		})
 
-	return config
+		path := *configFile
+		if path == "" {
+			path = os.Getenv("CONFIG_FILE")
+		}
+
+		config, problems, err := loadConfig(path, flags)
+		if err != nil {
+			return config, err
+		}
+		if *debug {
+			config.Server.DebugMode = true
+		}
+		if config.Server.DebugMode {
+			config.Log.Level = "debug"
+		}
+
+		// Ошибки разбора и валидации отдаём одним списком
+		var validationErr *ValidationError
+		if errors.As(config.Validate(), &validationErr) {
+			problems = append(problems, validationErr.Problems...)
+		}
+		if len(problems) > 0 {
+			return config, &ValidationError{Problems: problems}
+		}
+
+		return config, nil
+	}
 }
 
-func FormDBConfig() DBConfig {
-	var config DBConfig
+func loadConfig(path string, flags map[string]string) (Config, []string, error) {
+	config := DefaultConfig()
+
+	log.Info("Начинаем формировать конфиг") // Info-лог
 
-	log.Info("Начинаем формировать БД конфиг") // Info-лог
+	if path != "" {
+
+		log.WithField("path", path).Info("Читаем файл конфигурации") // Info-лог
+
+		if err := readConfigFile(path, &config); err != nil {
+			return config, nil, err
+		}
+	}
+
+	var problems []string
+
+	for _, field := range configFields {
+		if value, ok := os.LookupEnv(field.env); ok && value != "" {
+			if err := field.set(&config, value); err != nil {
+				problems = append(problems, fmt.Sprintf("env %s: %s", field.env, err))
+			}
+		}
+	}
+
+	for _, field := range configFields {
+		if value, ok := flags[field.flagName()]; ok {
+			if err := field.set(&config, value); err != nil {
+				problems = append(problems, fmt.Sprintf("флаг -%s: %s", field.flagName(), err))
+			}
+		}
+	}
+
+	return config, problems, nil
+}
 
-	port, err := strconv.ParseInt(os.Getenv("DB_PORT"), 10, 64)
+func readConfigFile(path string, config *Config) error {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		fmt.Printf("error: ошибка парсинга %s использую дефолтное значение\n", os.Getenv("DB_PORT"))
-		config.Port = 5432
+		return fmt.Errorf("не удалось прочитать файл конфигурации: %w", err)
 	}
-	config.Port = int(port)
 
-	log.WithField("Server config.Port", config.Port).Debug("Установлено значение порта БД") // Debug-лог
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("не удалось разобрать YAML %s: %w", path, err)
+		}
+	case ".toml":
+		meta, err := toml.Decode(string(data), config)
+		if err != nil {
+			return fmt.Errorf("не удалось разобрать TOML %s: %w", path, err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("неизвестные ключи в %s: %v", path, undecoded)
+		}
+	default:
+		return fmt.Errorf("неподдерживаемый формат файла конфигурации: %s", path)
+	}
+
+	return nil
+}
 
-	config.User = os.Getenv("DB_USER")
-	if len(config.User) == 0 {
-		panic("error: DB_USER не указан или пуст")
+// Validate проверяет весь конфиг и возвращает список всех проблем, а не первую
+func (c Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
 	}
 
-	log.WithField("DB config.User", config.User).Debug("Установлено значение пользователя БД") // Debug-лог
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		add("server.port: порт должен быть в диапазоне 1-65535, получено %d", c.Server.Port)
+	}
+	if c.Server.Domain == "" {
+		add("server.domain: не указан")
+	}
 
-	config.Pswd = os.Getenv("DB_PSWD")
-	if config.Pswd == "" {
-		panic("error: DB_PSWD не указан или пуст")
+	switch c.Server.Tracing.Exporter {
+	case TracingExporterNone, TracingExporterStdout, TracingExporterOTLP:
+	default:
+		add("server.tracing.exporter: ожидается none/stdout/otlp, получено %q", c.Server.Tracing.Exporter)
+	}
+	if c.Server.Tracing.SampleRatio < 0 || c.Server.Tracing.SampleRatio > 1 {
+		add("server.tracing.sample_ratio: ожидается значение от 0 до 1, получено %v", c.Server.Tracing.SampleRatio)
+	}
+	if c.Server.Tracing.ServiceName == "" {
+		add("server.tracing.service_name: не указан")
 	}
 
-	log.WithField("DB config.Pswd", config.Pswd).Debug("Установлено значение пароля БД") // Debug-лог
+	for _, check := range c.Server.Health.Checks {
+		switch check {
+		case HealthCheckDB, HealthCheckMigrations, HealthCheckExternalAPI:
+		default:
+			add("server.health.checks: неизвестная проверка %q", check)
+		}
+	}
+	if c.Server.Health.Timeout <= 0 {
+		add("server.health.timeout: должен быть больше нуля")
+	}
 
-	config.Name = os.Getenv("DB_NAME")
-	if config.Name == "" {
-		fmt.Printf("error:  DB_NAME не указан или пуст %s, использую дефолтное значение 'postgres'\n", os.Getenv("DB_NAME"))
-		config.Name = "postgres"
+	for name, value := range map[string]time.Duration{
+		"server.http.read_timeout":        c.Server.HTTP.ReadTimeout,
+		"server.http.read_header_timeout": c.Server.HTTP.ReadHeaderTimeout,
+		"server.http.write_timeout":       c.Server.HTTP.WriteTimeout,
+		"server.http.idle_timeout":        c.Server.HTTP.IdleTimeout,
+		"server.http.shutdown_timeout":    c.Server.HTTP.ShutdownTimeout,
+	} {
+		if value <= 0 {
+			add("%s: должен быть больше нуля", name)
+		}
+	}
+	if c.Server.HTTP.ShutdownDelay < 0 {
+		add("server.http.shutdown_delay: не может быть отрицательным")
+	}
+	if c.Server.HTTP.MaxHeaderBytes <= 0 {
+		add("server.http.max_header_bytes: должен быть больше нуля")
+	}
+	if !bodyLimitRegexp.MatchString(c.Server.HTTP.BodyLimit) {
+		add("server.http.body_limit: ожидается размер вида 512K/2M, получено %q", c.Server.HTTP.BodyLimit)
 	}
 
-	log.WithField("DB config.Name", config.Name).Debug("Установлено значение имени БД") // Debug-лог
+	if c.DB.Host == "" {
+		add("db.host: не указан")
+	}
+	if c.DB.Port < 1 || c.DB.Port > 65535 {
+		add("db.port: порт должен быть в диапазоне 1-65535, получено %d", c.DB.Port)
+	}
+	if c.DB.User == "" {
+		add("db.user: не указан (env DB_USER)")
+	}
+	if c.DB.Pswd == "" {
+		add("db.password: не указан (env DB_PSWD)")
+	}
+	if !dbNameRegexp.MatchString(c.DB.Name) {
+		add("db.name: допустимы латинские буквы, цифры и _, получено %q", c.DB.Name)
+	}
 
-	config.Host = os.Getenv("DB_HOST")
-	if config.Host == "" {
-		fmt.Printf("error: DB_HOST не указан или пуст %s, использую дефолтное значение 'localhost'\n", os.Getenv("DB_HOST"))
-		config.Host = "localhost"
+	if c.ExternalAPI.Addr == "" {
+		add("external_api.addr: не указан (env EXTERNAL_API_ADDR)")
+	} else if u, err := url.Parse(c.ExternalAPI.Addr); err != nil || u.Scheme == "" || u.Host == "" {
+		add("external_api.addr: ожидается URL вида http://host:port, получено %q", c.ExternalAPI.Addr)
+	}
+	if c.ExternalAPI.Timeout <= 0 {
+		add("external_api.timeout: должен быть больше нуля")
+	}
+	if c.ExternalAPI.CacheTTL < 0 {
+		add("external_api.cache_ttl: не может быть отрицательным")
+	}
+	switch c.ExternalAPI.Strategy {
+	case ProviderStrategyPriority, ProviderStrategyMerge:
+	default:
+		add("external_api.strategy: ожидается priority/merge, получено %q", c.ExternalAPI.Strategy)
 	}
+	providerNames := make(map[string]bool)
+	for i, provider := range c.ExternalAPI.Providers {
+		switch {
+		case provider.Name == "":
+			add("external_api.providers[%d].name: не указан", i)
+		case providerNames[provider.Name]:
+			add("external_api.providers[%d].name: повторяется %q", i, provider.Name)
+		case slices.Contains(reservedProviderNames, provider.Name):
+			add("external_api.providers[%d].name: имя %q зарезервировано", i, provider.Name)
+		}
+		providerNames[provider.Name] = true
 
-	log.WithField("DB config.Host", config.Host).Debug("Установлено значение хоста БД") // Debug-лог
+		switch provider.Type {
+		case ProviderTypeHTTP:
+			if u, err := url.Parse(provider.Addr); provider.Addr != "" && (err != nil || u.Scheme == "" || u.Host == "") {
+				add("external_api.providers[%d].addr: ожидается URL вида http://host:port, получено %q", i, provider.Addr)
+			}
+		case ProviderTypeFile:
+			if provider.Path == "" {
+				add("external_api.providers[%d].path: не указан", i)
+			}
+		default:
+			add("external_api.providers[%d].type: ожидается http/file, получено %q", i, provider.Type)
+		}
+	}
+
+	if c.Cache.Enabled {
+		if c.Cache.Size <= 0 {
+			add("cache.size: должен быть больше нуля")
+		}
+		if c.Cache.TTL <= 0 {
+			add("cache.ttl: должен быть больше нуля")
+		}
+	}
+
+	if c.Refresh.Interval <= 0 {
+		add("refresh.interval: должен быть больше нуля")
+	}
+	if c.Refresh.MaxAge < 0 {
+		add("refresh.max_age: не может быть отрицательным")
+	}
+	if c.Refresh.BatchSize <= 0 {
+		add("refresh.batch_size: должен быть больше нуля")
+	}
+
+	if c.LinkCheck.Interval <= 0 {
+		add("link_check.interval: должен быть больше нуля")
+	}
+	if c.LinkCheck.RecheckAfter < 0 {
+		add("link_check.recheck_after: не может быть отрицательным")
+	}
+	if c.LinkCheck.BatchSize <= 0 {
+		add("link_check.batch_size: должен быть больше нуля")
+	}
+	if c.LinkCheck.RatePerSecond <= 0 {
+		add("link_check.rate_per_second: должен быть больше нуля")
+	}
+	if c.LinkCheck.FailureThreshold < 1 {
+		add("link_check.failure_threshold: должен быть не меньше 1")
+	}
+
+	if c.Songbook.Interval <= 0 {
+		add("songbook.interval: должен быть больше нуля")
+	}
+	if c.Songbook.TTL <= 0 {
+		add("songbook.ttl: должен быть больше нуля")
+	}
+	if c.Songbook.MaxSongs <= 0 {
+		add("songbook.max_songs: должен быть больше нуля")
+	}
+	if c.Songbook.FontBold != "" && c.Songbook.Font == "" {
+		add("songbook.font_bold: задаётся только вместе с songbook.font")
+	}
 
-	config.DSN = fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=disable", config.Host, config.User, config.Pswd, config.Name, config.Port)
+	if !bodyLimitRegexp.MatchString(c.AudioImport.MaxUpload) {
+		add("audio_import.max_upload: ожидается размер вида 512K/2M, получено %q", c.AudioImport.MaxUpload)
+	}
+	if c.AudioImport.MaxFiles <= 0 {
+		add("audio_import.max_files: должен быть больше нуля")
+	}
 
-	log.WithField("DB config.DSN", config.DSN).Debug("Сформирована DSN строка для подключения к БД") // Debug-лог
+	switch c.Media.Storage {
+	case MediaStorageLocal:
+		if c.Media.Path == "" {
+			add("media.path: обязателен для хранилища local")
+		}
+	default:
+		add("media.storage: ожидается local, получено %q", c.Media.Storage)
+	}
+	if !bodyLimitRegexp.MatchString(c.Media.MaxUpload) {
+		add("media.max_upload: ожидается размер вида 512K/2M, получено %q", c.Media.MaxUpload)
+	}
+	if c.Media.ThumbnailSize < 16 || c.Media.ThumbnailSize > 2000 {
+		add("media.thumbnail_size: ожидается от 16 до 2000, получено %d", c.Media.ThumbnailSize)
+	}
+
+	for _, language := range c.Explicit.Languages {
+		switch language {
+		case ExplicitLanguageRU, ExplicitLanguageEN:
+		default:
+			add("explicit.languages: неизвестный словарь %q", language)
+		}
+	}
+	if c.Explicit.Enabled && len(c.Explicit.Languages) == 0 && c.Explicit.WordsFile == "" {
+		add("explicit: нужен хотя бы один словарь в languages или words_file")
+	}
+
+	if c.DB.SlowQueryThreshold < 0 {
+		add("db.slow_query_threshold: не может быть отрицательным")
+	}
+
+	switch c.Log.Format {
+	case logging.FormatJSON, logging.FormatText:
+	default:
+		add("log.format: ожидается json/text, получено %q", c.Log.Format)
+	}
+	if _, err := logrus.ParseLevel(c.Log.Level); err != nil {
+		add("log.level: %s", err)
+	}
+	for name, level := range c.Log.Levels {
+		if _, err := logrus.ParseLevel(level); err != nil {
+			add("log.levels.%s: %s", name, err)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+
+	return nil
+}
+
+// Redacted возвращает копию конфига, безопасную для вывода и логирования
+func (c Config) Redacted() Config {
+	if c.DB.Pswd != "" {
+		c.DB.Pswd = redacted
+	}
+	return c
+}
 
-	return config
+// YAML сериализует конфиг без секретов
+func (c Config) YAML() ([]byte, error) {
+	return yaml.Marshal(c.Redacted())
 }
@@ -1,17 +1,24 @@
 package initializers
 
 import (
+	"errors"
+	"io/fs"
+
 	"github.com/joho/godotenv"
-	log "github.com/sirupsen/logrus"
 )
 
 func LoadEnv() {
 
 	log.Info("Загружаем env-файл") // Info-лог
 
+	// Переменные могут приходить из окружения оркестратора, поэтому файл не обязателен
 	if err := godotenv.Load(".env"); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			log.Info("env-файл не найден, используем переменные окружения") // Info-лог
+			return
+		}
 		panic(err)
 	}
 
-	log.Info("env-файл загруженл") // Info-лог
+	log.Info("env-файл загружен") // Info-лог
 }
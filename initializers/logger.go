@@ -0,0 +1,5 @@
+package initializers
+
+import "songLibrary/logging"
+
+var log = logging.New("initializers").Entry()
@@ -1,62 +1,148 @@
 package initializers
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"songLibrary/logging"
 	"songLibrary/models"
+	"time"
 
 	_ "github.com/lib/pq"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-
-	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm/clause"
+	"gorm.io/plugin/opentelemetry/tracing"
 )
 
+// SchemaVersion - ожидаемая версия схемы БД, увеличивается при изменении моделей
+const SchemaVersion = 19
+
 var (
 	DB *gorm.DB
 )
 
-func Migrate(config DBConfig) {
-	var err error
-
-	log.Info("Начинаем миграцию") // Info-лог
+// Модели, которые мигрируются через GORM
+var migrationModels = []any{
+	&models.SchemaMigration{},
+	&models.Genre{},
+	&models.Tag{},
+	&models.Image{},
+	&models.Group{},
+	&models.Song{},
+	&models.Album{},
+	&models.SongCredit{},
+	&models.Lyrics{},
+	&models.APIKey{},
+	&models.ExternalAPIResponse{},
+	&models.SongProposal{},
+	&models.SongLink{},
+	&models.SongRelation{},
+	&models.LyricLine{},
+	&models.LyricsTranslation{},
+	&models.Playlist{},
+	&models.PlaylistEntry{},
+	&models.Setlist{},
+	&models.SetlistEntry{},
+	&models.SongbookJob{},
+}
 
-	if err = createDatabaseIfNotExists(config); err != nil {
-		log.Fatal("Не удалось создать БД: " + err.Error())
-	}
+// ConnectDB открывает соединение с существующей БД без миграции
+func ConnectDB(config DBConfig) error {
+	var err error
 
 	log.Info("Открываем соединение") // Info-лог
 
-	log.WithField("DB config.DSN", config.DSN).Debug("DSN для подключения") // Debug-лог
+	log.WithField("DB config.Host", config.Host).WithField("DB config.Name", config.Name).Debug("Подключаемся к БД") // Debug-лог
 
-	DB, err = gorm.Open(postgres.Open(config.DSN), &gorm.Config{})
+	DB, err = gorm.Open(postgres.Open(config.DSN()), &gorm.Config{Logger: logging.NewGormLogger(config.SlowQueryThreshold)})
 	if err != nil {
-		log.Fatal("Не удалось подключиться к БД: " + err.Error())
+		return fmt.Errorf("не удалось подключиться к БД: %w", err)
 	}
 
 	log.Info("Успешное подключение к БД") // Info-лог
 
+	log.Info("Подключаем трассировку запросов GORM") // Info-лог
+
+	if err = DB.Use(tracing.NewPlugin(tracing.WithoutMetrics(), tracing.WithoutQueryVariables())); err != nil {
+		return fmt.Errorf("не удалось подключить плагин трассировки: %w", err)
+	}
+
+	return nil
+}
+
+// Migrate создаёт БД при необходимости, подключается и мигрирует модели
+func Migrate(config DBConfig) error {
+	log.Info("Начинаем миграцию") // Info-лог
+
+	if err := createDatabaseIfNotExists(config); err != nil {
+		return fmt.Errorf("не удалось создать БД: %w", err)
+	}
+
+	if err := ConnectDB(config); err != nil {
+		return err
+	}
+
+	// С версии 4 уникальный ключ сохранённых ответов включает провайдера
+	if DB.Migrator().HasIndex(&models.ExternalAPIResponse{}, "idx_external_api_responses_key") {
+		if err := DB.Migrator().DropIndex(&models.ExternalAPIResponse{}, "idx_external_api_responses_key"); err != nil {
+			return fmt.Errorf("не удалось удалить устаревший индекс: %w", err)
+		}
+	}
+
 	log.Info("Мигрирую модели через GORM") // Info-лог
 
-	err = DB.AutoMigrate(&models.Group{}, &models.Song{}, &models.Lyrics{})
+	if err := DB.AutoMigrate(migrationModels...); err != nil {
+		return fmt.Errorf("ошибка миграции: %w", err)
+	}
+
+	// С версии 12 основной исполнитель песни хранится и среди участников
+	err := DB.Exec(`INSERT INTO song_credits (created_at, updated_at, song_id, group_id, role, "order")
+		SELECT NOW(), NOW(), s.id, s.group_id, ?, 0 FROM songs s
+		WHERE s.deleted_at IS NULL AND NOT EXISTS (
+			SELECT 1 FROM song_credits c
+			WHERE c.song_id = s.id AND c.group_id = s.group_id AND c.role = ? AND c.deleted_at IS NULL
+		)`, models.CreditRoleMain, models.CreditRoleMain).Error
 	if err != nil {
-		log.Fatalf("Ошибка миграции: %s", err)
-		return
+		return fmt.Errorf("не удалось заполнить участников песен: %w", err)
+	}
+
+	log.WithField("SchemaVersion", SchemaVersion).Debug("Фиксируем версию схемы") // Debug-лог
+
+	err = DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.SchemaMigration{Version: SchemaVersion, AppliedAt: time.Now()}).Error
+	if err != nil {
+		return fmt.Errorf("не удалось записать версию схемы: %w", err)
 	}
 
 	log.Info("Миграция успешна") // Info-лог
+
+	return nil
 }
 
-func createDatabaseIfNotExists(config DBConfig) error {
-	log.Info("Подключаемся к БД к дефолтной БД postgres") // Info-лог
+// CheckSchemaVersion проверяет, что схема БД соответствует версии приложения
+func CheckSchemaVersion(ctx context.Context) error {
+	version, err := CurrentSchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if version != SchemaVersion {
+		return fmt.Errorf("версия схемы %d, ожидается %d", version, SchemaVersion)
+	}
+	return nil
+}
 
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=postgres port=%d sslmode=disable", config.Host, config.User, config.Pswd, config.Port)
+// CurrentSchemaVersion возвращает последнюю применённую версию схемы
+func CurrentSchemaVersion(ctx context.Context) (int, error) {
+	var version int
+	err := DB.WithContext(ctx).Model(&models.SchemaMigration{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+	return version, err
+}
 
-	log.WithField("DB dsn", dsn).Debug("DSN для подключения к дефолтной БД postgres") // Debug-лог
+func createDatabaseIfNotExists(config DBConfig) error {
+	log.Info("Подключаемся к БД к дефолтной БД postgres") // Info-лог
 
-	db, err := sql.Open("postgres", dsn)
+	db, err := sql.Open("postgres", config.dsn("postgres"))
 	if err != nil {
-		log.Fatalf("Не удалось подключиться к БД: %s", err)
 		return err
 	}
 	defer db.Close()
@@ -68,8 +154,7 @@ func createDatabaseIfNotExists(config DBConfig) error {
 	var exists bool
 	err = db.QueryRow("SELECT EXISTS(SELECT 1 FROM pg_database WHERE datname = $1)", config.Name).Scan(&exists)
 	if err != nil {
-		log.Fatalf("Не удалось проверить существование БД: %s", err)
-		return err
+		return fmt.Errorf("не удалось проверить существование БД: %w", err)
 	}
 
 	if !exists {
@@ -78,7 +163,6 @@ func createDatabaseIfNotExists(config DBConfig) error {
 
 		_, err = db.Exec("CREATE DATABASE " + config.Name)
 		if err != nil {
-			log.Fatalf("Не удалось создать БД %s: %s", config.Name, err)
 			return err
 		}
 
@@ -87,3 +171,19 @@ func createDatabaseIfNotExists(config DBConfig) error {
 
 	return nil
 }
+
+// CloseDB закрывает пул соединений с БД
+func CloseDB(ctx context.Context) error {
+	if DB == nil {
+		return nil
+	}
+
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+
+	log.Info("Закрываем соединения с БД") // Info-лог
+
+	return sqlDB.Close()
+}
@@ -0,0 +1,98 @@
+package initializers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+const (
+	TracingExporterNone   = "none"
+	TracingExporterStdout = "stdout"
+	TracingExporterOTLP   = "otlp"
+)
+
+var (
+	// HTTPClient используется для исходящих запросов (внешний API /info),
+	// транспорт пробрасывает W3C trace-context в заголовках запроса
+	HTTPClient = &http.Client{
+		Transport: otelhttp.NewTransport(http.DefaultTransport),
+		Timeout:   10 * time.Second,
+	}
+)
+
+type TracingConfig struct {
+	ServiceName  string  `yaml:"service_name" toml:"service_name"`
+	Exporter     string  `yaml:"exporter" toml:"exporter"`
+	OTLPEndpoint string  `yaml:"otlp_endpoint" toml:"otlp_endpoint"`
+	OTLPInsecure bool    `yaml:"otlp_insecure" toml:"otlp_insecure"`
+	SampleRatio  float64 `yaml:"sample_ratio" toml:"sample_ratio"`
+}
+
+// InitTracing настраивает глобальный TracerProvider и propagator.
+// Возвращает функцию, которая сбрасывает буферы экспортера при остановке сервера.
+func InitTracing(config TracingConfig) (func(context.Context) error, error) {
+	log.Info("Инициализируем трассировку") // Info-лог
+
+	log.WithField("Tracing config.Exporter", config.Exporter).Debug("Экспортер трассировки")         // Debug-лог
+	log.WithField("Tracing config.SampleRatio", config.SampleRatio).Debug("Доля семплируемых трасс") // Debug-лог
+
+	// Propagator нужен даже без экспортера, чтобы пробрасывать входящий контекст дальше
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+
+	switch config.Exporter {
+	case TracingExporterNone, "":
+		log.Info("Экспорт трасс отключён") // Info-лог
+		return func(context.Context) error { return nil }, nil
+	case TracingExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout), stdouttrace.WithPrettyPrint())
+	case TracingExporterOTLP:
+		opts := []otlptracehttp.Option{}
+		if config.OTLPEndpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(config.OTLPEndpoint))
+		}
+		if config.OTLPInsecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(context.Background(), opts...)
+	default:
+		return nil, fmt.Errorf("неизвестный экспортер трассировки: %s", config.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать экспортер трассировки: %w", err)
+	}
+
+	res, err := resource.New(context.Background(),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(semconv.ServiceName(config.ServiceName)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось сформировать resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+
+	log.Info("Трассировка инициализирована") // Info-лог
+
+	return tp.Shutdown, nil
+}
@@ -0,0 +1,100 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Worker - фоновая задача, которая работает до отмены контекста
+type Worker interface {
+	Name() string
+	Run(ctx context.Context) error
+}
+
+type hook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// App управляет фоновыми воркерами и освобождением ресурсов при остановке.
+// Хуки остановки вызываются в обратном порядке регистрации.
+type App struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	hooks  []hook
+}
+
+func New() *App {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &App{ctx: ctx, cancel: cancel}
+}
+
+// Go запускает воркер в отдельной горутине
+func (a *App) Go(w Worker) {
+	log := log.WithField("prefix", "lifecycle").WithField("worker", w.Name())
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+
+		log.Info("Запускаем воркер") // Info-лог
+
+		if err := w.Run(a.ctx); err != nil && !errors.Is(err, context.Canceled) {
+			log.WithError(err).Error("Воркер завершился с ошибкой")
+			return
+		}
+
+		log.Info("Воркер остановлен") // Info-лог
+	}()
+}
+
+// OnShutdown регистрирует функцию освобождения ресурса
+func (a *App) OnShutdown(name string, fn func(ctx context.Context) error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.hooks = append(a.hooks, hook{name: name, fn: fn})
+}
+
+// Shutdown останавливает воркеры, дожидается их завершения и вызывает хуки.
+// Все шаги ограничены дедлайном ctx.
+func (a *App) Shutdown(ctx context.Context) error {
+	log := log.WithField("prefix", "lifecycle")
+
+	log.Info("Останавливаем фоновые воркеры") // Info-лог
+
+	a.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		a.wg.Wait()
+		close(done)
+	}()
+
+	var errs []error
+
+	select {
+	case <-done:
+		log.Info("Все воркеры остановлены") // Info-лог
+	case <-ctx.Done():
+		errs = append(errs, fmt.Errorf("воркеры не остановились до дедлайна: %w", ctx.Err()))
+	}
+
+	a.mu.Lock()
+	hooks := a.hooks
+	a.mu.Unlock()
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+
+		log.WithField("hook", hooks[i].name).Info("Освобождаем ресурс") // Info-лог
+
+		if err := hooks[i].fn(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", hooks[i].name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
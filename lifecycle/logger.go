@@ -0,0 +1,5 @@
+package lifecycle
+
+import "songLibrary/logging"
+
+var log = logging.New("lifecycle").Entry()
@@ -0,0 +1,67 @@
+package links
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// Outcome - итог одной проверки ссылки
+type Outcome int
+
+const (
+	// Ссылка открывается
+	OutcomeOK Outcome = iota
+	// Площадка однозначно ответила, что ресурса нет (404, 410)
+	OutcomeGone
+	// Ответа нет или площадка временно недоступна, проверка засчитывается как неудачная
+	OutcomeFailed
+	// Площадка не дала проверить (403, 429), такой результат не учитывается
+	OutcomeUnknown
+)
+
+// Check запрашивает ссылку методом HEAD. Если площадка не поддерживает HEAD,
+// повторяет запрос через GET без чтения тела.
+func Check(ctx context.Context, client *http.Client, linkType, canonicalURL string) (Outcome, int, error) {
+	probe := ProbeURL(linkType, canonicalURL)
+
+	status, err := probeStatus(ctx, client, http.MethodHead, probe)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		status, err = probeStatus(ctx, client, http.MethodGet, probe)
+	}
+	if err != nil {
+		return OutcomeFailed, 0, err
+	}
+
+	switch {
+	case status < http.StatusBadRequest:
+		return OutcomeOK, status, nil
+	case status == http.StatusNotFound || status == http.StatusGone:
+		return OutcomeGone, status, nil
+	case status == http.StatusForbidden || status == http.StatusTooManyRequests:
+		return OutcomeUnknown, status, nil
+	// oEmbed YouTube отвечает 401 для приватных и заблокированных для встраивания видео
+	case status == http.StatusUnauthorized && linkType == TypeYouTube:
+		return OutcomeGone, status, nil
+	default:
+		return OutcomeFailed, status, nil
+	}
+}
+
+func probeStatus(ctx context.Context, client *http.Client, method, target string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// Тело не нужно, но небольшой остаток дочитываем, чтобы соединение вернулось в пул
+	io.CopyN(io.Discard, resp.Body, 4096)
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,69 @@
+package links
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+var ErrForbiddenAddress = errors.New("адрес во внутренней сети, проверка запрещена")
+
+// Диапазоны, которых нет в netip.Addr.IsPrivate: общий адрес провайдера (RFC 6598)
+// и адреса для документации и тестов
+var forbiddenPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+}
+
+// NewClient создаёт клиент для проверки ссылок. Ссылку может добавить кто угодно,
+// поэтому соединения с loopback, частными и link-local адресами запрещены. Адрес
+// проверяется при каждом подключении уже после DNS, в том числе после редиректов.
+// Прокси из окружения не используется: через него проверка адреса обходилась бы.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   controlPublic,
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Transport: otelhttp.NewTransport(transport),
+		Timeout:   timeout,
+	}
+}
+
+func controlPublic(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	}
+	if !PublicAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+// PublicAddr - адрес в публичном интернете
+func PublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() {
+		return false
+	}
+	for _, prefix := range forbiddenPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
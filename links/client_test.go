@@ -0,0 +1,44 @@
+package links
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestPublicAddr(t *testing.T) {
+	tests := map[string]bool{
+		"8.8.8.8":          true,
+		"2a00:1450::1":     true,
+		"127.0.0.1":        false,
+		"10.1.2.3":         false,
+		"172.16.0.1":       false,
+		"192.168.1.1":      false,
+		"169.254.169.254":  false,
+		"100.64.0.1":       false,
+		"0.0.0.0":          false,
+		"::1":              false,
+		"fe80::1":          false,
+		"fd00::1":          false,
+		"::ffff:127.0.0.1": false,
+	}
+	for raw, want := range tests {
+		if got := PublicAddr(netip.MustParseAddr(raw)); got != want {
+			t.Errorf("PublicAddr(%s) = %v; want %v", raw, got, want)
+		}
+	}
+}
+
+func TestClientRejectsLoopback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	outcome, _, err := Check(context.Background(), NewClient(time.Second), TypeOther, server.URL)
+	if outcome != OutcomeFailed || !errors.Is(err, ErrForbiddenAddress) {
+		t.Fatalf("Check() = %v, %v; want OutcomeFailed, ErrForbiddenAddress", outcome, err)
+	}
+}
@@ -0,0 +1,159 @@
+package links
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Типы ссылок
+const (
+	TypeYouTube     = "youtube"
+	TypeSpotify     = "spotify"
+	TypeAppleMusic  = "apple_music"
+	TypeYandexMusic = "yandex_music"
+	TypeOther       = "other"
+)
+
+var ErrInvalidURL = errors.New("некорректная ссылка")
+
+var (
+	youTubeIDRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+	spotifyIDRegexp = regexp.MustCompile(`^[A-Za-z0-9]{22}$`)
+	numericIDRegexp = regexp.MustCompile(`^[0-9]+$`)
+)
+
+// Link - разобранная ссылка: тип площадки, каноничный URL и ID трека на площадке
+type Link struct {
+	Type       string
+	URL        string
+	ExternalID string
+}
+
+// Parse определяет площадку, извлекает ID и приводит ссылку к каноничному виду,
+// чтобы разные записи одного трека (youtu.be, m.youtube.com, utm-метки) совпадали
+func Parse(raw string) (Link, error) {
+	raw = strings.TrimSpace(raw)
+
+	// spotify:track:<id>
+	if rest, ok := strings.CutPrefix(raw, "spotify:track:"); ok {
+		return spotifyTrack(rest)
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Link{}, fmt.Errorf("%w: %s", ErrInvalidURL, raw)
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	segments := strings.FieldsFunc(u.Path, func(r rune) bool { return r == '/' })
+
+	switch {
+	case host == "youtube.com" || host == "m.youtube.com" || host == "music.youtube.com":
+		if id := u.Query().Get("v"); id != "" {
+			return youTubeVideo(id)
+		}
+		if len(segments) == 2 && (segments[0] == "shorts" || segments[0] == "embed" || segments[0] == "live") {
+			return youTubeVideo(segments[1])
+		}
+	case host == "youtu.be":
+		if len(segments) == 1 {
+			return youTubeVideo(segments[0])
+		}
+	case host == "open.spotify.com":
+		// /track/<id> или /intl-de/track/<id>
+		if len(segments) > 0 && strings.HasPrefix(segments[0], "intl-") {
+			segments = segments[1:]
+		}
+		if len(segments) == 2 && segments[0] == "track" {
+			return spotifyTrack(segments[1])
+		}
+	case host == "music.apple.com":
+		// /<страна>/album/<slug>/<albumId>?i=<trackId> или /<страна>/song/<slug>/<trackId>
+		if len(segments) >= 3 {
+			country := strings.ToLower(segments[0])
+			if id := u.Query().Get("i"); segments[1] == "album" && numericIDRegexp.MatchString(id) {
+				return appleMusicSong(country, id), nil
+			}
+			if id := segments[len(segments)-1]; segments[1] == "song" && numericIDRegexp.MatchString(id) {
+				return appleMusicSong(country, id), nil
+			}
+		}
+	case strings.HasPrefix(host, "music.yandex."):
+		// /album/<albumId>/track/<trackId> или /track/<trackId>
+		if len(segments) == 4 && segments[0] == "album" && segments[2] == "track" &&
+			numericIDRegexp.MatchString(segments[1]) && numericIDRegexp.MatchString(segments[3]) {
+			return Link{
+				Type:       TypeYandexMusic,
+				URL:        fmt.Sprintf("https://music.yandex.ru/album/%s/track/%s", segments[1], segments[3]),
+				ExternalID: segments[3],
+			}, nil
+		}
+		if len(segments) == 2 && segments[0] == "track" && numericIDRegexp.MatchString(segments[1]) {
+			return Link{Type: TypeYandexMusic, URL: "https://music.yandex.ru/track/" + segments[1], ExternalID: segments[1]}, nil
+		}
+	}
+
+	return Link{Type: TypeOther, URL: canonicalOther(u)}, nil
+}
+
+func youTubeVideo(id string) (Link, error) {
+	if !youTubeIDRegexp.MatchString(id) {
+		return Link{}, fmt.Errorf("%w: неверный ID видео YouTube %q", ErrInvalidURL, id)
+	}
+	return Link{Type: TypeYouTube, URL: "https://www.youtube.com/watch?v=" + id, ExternalID: id}, nil
+}
+
+func spotifyTrack(id string) (Link, error) {
+	if !spotifyIDRegexp.MatchString(id) {
+		return Link{}, fmt.Errorf("%w: неверный ID трека Spotify %q", ErrInvalidURL, id)
+	}
+	return Link{Type: TypeSpotify, URL: "https://open.spotify.com/track/" + id, ExternalID: id}, nil
+}
+
+func appleMusicSong(country, id string) Link {
+	return Link{Type: TypeAppleMusic, URL: fmt.Sprintf("https://music.apple.com/%s/song/%s", country, id), ExternalID: id}
+}
+
+// canonicalOther убирает фрагмент и utm-метки, приводит схему и хост к нижнему регистру
+func canonicalOther(u *url.URL) string {
+	c := *u
+	c.Scheme = strings.ToLower(c.Scheme)
+	c.Host = strings.ToLower(c.Host)
+	c.Fragment = ""
+	c.User = nil
+
+	query := c.Query()
+	for key := range query {
+		if strings.HasPrefix(strings.ToLower(key), "utm_") {
+			query.Del(key)
+		}
+	}
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, key := range keys {
+		for _, value := range query[key] {
+			parts = append(parts, url.QueryEscape(key)+"="+url.QueryEscape(value))
+		}
+	}
+	c.RawQuery = strings.Join(parts, "&")
+
+	return c.String()
+}
+
+// ProbeURL - адрес, по которому проверяется доступность. Страница удалённого видео
+// YouTube отвечает 200, поэтому для YouTube используется oEmbed, который отвечает 404.
+func ProbeURL(linkType, canonicalURL string) string {
+	if linkType == TypeYouTube {
+		return "https://www.youtube.com/oembed?format=json&url=" + url.QueryEscape(canonicalURL)
+	}
+	return canonicalURL
+}
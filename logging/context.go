@@ -0,0 +1,84 @@
+package logging
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Поля контекста запроса, общие для всех логов
+const (
+	FieldRequestID = "request_id"
+	FieldRoute     = "route"
+	FieldSongID    = "song_id"
+	FieldActor     = "actor"
+)
+
+type ctxKey struct{}
+
+// fields изменяемый набор полей: middleware создаёт его в начале запроса,
+// обработчики дополняют (например, song_id), и все последующие логи его видят
+type fields struct {
+	mu     sync.RWMutex
+	values logrus.Fields
+}
+
+// NewContext кладёт в контекст набор полей для логов
+func NewContext(ctx context.Context, values logrus.Fields) context.Context {
+	f := &fields{values: make(logrus.Fields, len(values))}
+	for k, v := range values {
+		f.values[k] = v
+	}
+	return context.WithValue(ctx, ctxKey{}, f)
+}
+
+// AddField дополняет поля контекста. Без NewContext ничего не делает.
+func AddField(ctx context.Context, key string, value any) {
+	f, ok := ctx.Value(ctxKey{}).(*fields)
+	if !ok {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.values[key] = value
+}
+
+// Fields возвращает копию полей контекста
+func Fields(ctx context.Context) logrus.Fields {
+	result := logrus.Fields{}
+
+	if f, ok := ctx.Value(ctxKey{}).(*fields); ok {
+		f.mu.RLock()
+		for k, v := range f.values {
+			result[k] = v
+		}
+		f.mu.RUnlock()
+	}
+
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		result["trace_id"] = span.TraceID().String()
+	}
+
+	return result
+}
+
+// Field возвращает одно поле контекста
+func Field(ctx context.Context, key string) (any, bool) {
+	f, ok := ctx.Value(ctxKey{}).(*fields)
+	if !ok {
+		return nil, false
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	value, ok := f.values[key]
+	return value, ok
+}
+
+// Ctx возвращает запись лога с полями контекста запроса
+func (l *Logger) Ctx(ctx context.Context) *logrus.Entry {
+	return l.Entry().WithContext(ctx).WithFields(Fields(ctx))
+}
@@ -0,0 +1,80 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// GormLogger пишет SQL-запросы в логгер db с полями контекста запроса.
+// Уровень управляется через logging, LogMode GORM игнорируется.
+type GormLogger struct {
+	logger        *Logger
+	slowThreshold time.Duration
+}
+
+var _ gorm.ParamsFilter = (*GormLogger)(nil)
+
+func NewGormLogger(slowThreshold time.Duration) *GormLogger {
+	return &GormLogger{logger: New("db"), slowThreshold: slowThreshold}
+}
+
+func (g *GormLogger) LogMode(gormlogger.LogLevel) gormlogger.Interface {
+	return g
+}
+
+func (g *GormLogger) Info(ctx context.Context, msg string, data ...any) {
+	g.logger.Ctx(ctx).Infof(msg, data...)
+}
+
+func (g *GormLogger) Warn(ctx context.Context, msg string, data ...any) {
+	g.logger.Ctx(ctx).Warnf(msg, data...)
+}
+
+func (g *GormLogger) Error(ctx context.Context, msg string, data ...any) {
+	g.logger.Ctx(ctx).Errorf(msg, data...)
+}
+
+// ParamsFilter убирает значения параметров из SQL в логе: в них пароли, ключи и
+// тексты пользователей, а уровень логгера db меняется через административную ручку
+func (g *GormLogger) ParamsFilter(ctx context.Context, sql string, params ...any) (string, []any) {
+	return sql, nil
+}
+
+func (g *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+
+	var level logrus.Level
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		level = logrus.ErrorLevel
+	case g.slowThreshold > 0 && elapsed > g.slowThreshold:
+		level = logrus.WarnLevel
+	default:
+		level = logrus.DebugLevel
+	}
+
+	if !g.logger.logger.IsLevelEnabled(level) {
+		return
+	}
+
+	sql, rows := fc()
+	entry := g.logger.Ctx(ctx).WithFields(logrus.Fields{
+		"sql":        sql,
+		"rows":       rows,
+		"elapsed_ms": elapsed.Milliseconds(),
+	})
+
+	switch level {
+	case logrus.ErrorLevel:
+		entry.WithError(err).Error("Ошибка SQL-запроса")
+	case logrus.WarnLevel:
+		entry.Warn("Медленный SQL-запрос")
+	default:
+		entry.Debug("SQL-запрос")
+	}
+}
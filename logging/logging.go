@@ -0,0 +1,175 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+
+	// Имя логгера по умолчанию - стандартный логгер logrus
+	DefaultLogger = "app"
+)
+
+type Config struct {
+	Format string `yaml:"format" toml:"format"`
+	Level  string `yaml:"level" toml:"level"`
+	// Уровни для отдельных пакетов, например handlers: debug
+	Levels map[string]string `yaml:"levels" toml:"levels"`
+}
+
+// Logger - логгер пакета со своим уровнем, формат и вывод общие для всех
+type Logger struct {
+	name   string
+	logger *logrus.Logger
+}
+
+var (
+	mu      sync.RWMutex
+	loggers = map[string]*Logger{
+		DefaultLogger: {name: DefaultLogger, logger: logrus.StandardLogger()},
+	}
+	formatter logrus.Formatter = &logrus.TextFormatter{}
+	output    io.Writer        = os.Stderr
+	levels                     = map[string]logrus.Level{}
+)
+
+// New возвращает логгер пакета, повторный вызов с тем же именем отдаёт тот же логгер
+func New(name string) *Logger {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if l, ok := loggers[name]; ok {
+		return l
+	}
+
+	logger := logrus.New()
+	logger.SetFormatter(formatter)
+	logger.SetOutput(output)
+	logger.SetLevel(levelFor(name))
+
+	l := &Logger{name: name, logger: logger}
+	loggers[name] = l
+	return l
+}
+
+// levelFor вызывается под mu
+func levelFor(name string) logrus.Level {
+	if level, ok := levels[name]; ok {
+		return level
+	}
+	return logrus.StandardLogger().GetLevel()
+}
+
+// Setup применяет формат и уровни ко всем логгерам, включая стандартный
+func Setup(config Config) error {
+	var f logrus.Formatter
+	switch config.Format {
+	case FormatJSON:
+		f = &logrus.JSONFormatter{TimestampFormat: "2006-01-02T15:04:05.000Z07:00"}
+	case FormatText, "":
+		f = &logrus.TextFormatter{FullTimestamp: true}
+	default:
+		return fmt.Errorf("неизвестный формат логов: %s", config.Format)
+	}
+
+	defaultLevel := logrus.InfoLevel
+	if config.Level != "" {
+		parsed, err := logrus.ParseLevel(config.Level)
+		if err != nil {
+			return err
+		}
+		defaultLevel = parsed
+	}
+
+	parsedLevels := make(map[string]logrus.Level, len(config.Levels))
+	for name, level := range config.Levels {
+		parsed, err := logrus.ParseLevel(level)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		parsedLevels[name] = parsed
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	formatter = f
+	levels = parsedLevels
+	logrus.SetFormatter(f)
+	logrus.SetLevel(defaultLevel)
+
+	for name, l := range loggers {
+		l.logger.SetFormatter(f)
+		l.logger.SetLevel(levelFor(name))
+	}
+
+	return nil
+}
+
+// SetLevel меняет уровень логгера пакета во время работы
+func SetLevel(name string, level string) error {
+	parsed, err := logrus.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	l, ok := loggers[name]
+	if !ok {
+		return fmt.Errorf("логгер %s не найден", name)
+	}
+
+	if name == DefaultLogger {
+		logrus.SetLevel(parsed)
+	} else {
+		levels[name] = parsed
+	}
+	l.logger.SetLevel(parsed)
+
+	return nil
+}
+
+// Levels возвращает текущие уровни всех логгеров
+func Levels() map[string]string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	result := make(map[string]string, len(loggers))
+	for name, l := range loggers {
+		result[name] = l.logger.GetLevel().String()
+	}
+	return result
+}
+
+// Names возвращает отсортированные имена логгеров
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(loggers))
+	for name := range loggers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Entry возвращает запись без контекста запроса
+func (l *Logger) Entry() *logrus.Entry {
+	return logrus.NewEntry(l.logger).WithField("logger", l.name)
+}
+
+// Writer отдаёт io.Writer, строки которого пишутся в лог на уровне info
+func (l *Logger) Writer() *io.PipeWriter {
+	return l.Entry().Writer()
+}
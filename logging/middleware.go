@@ -0,0 +1,69 @@
+package logging
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/sirupsen/logrus"
+)
+
+// Актор по умолчанию, пока запрос не аутентифицирован
+const AnonymousActor = "anonymous"
+
+// RequestID берёт X-Request-ID из запроса или генерирует новый
+// и возвращает его в заголовке ответа
+func RequestID() echo.MiddlewareFunc {
+	return middleware.RequestIDWithConfig(middleware.RequestIDConfig{
+		TargetHeader: echo.HeaderXRequestID,
+	})
+}
+
+// Middleware создаёт контекст логов запроса. Должен идти после RequestID.
+func Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			ctx := NewContext(req.Context(), logrus.Fields{
+				FieldRequestID: c.Response().Header().Get(echo.HeaderXRequestID),
+				FieldRoute:     c.Path(),
+				FieldActor:     AnonymousActor,
+			})
+			c.SetRequest(req.WithContext(ctx))
+			return next(c)
+		}
+	}
+}
+
+// AccessLog пишет по строке на запрос в логгер http
+func AccessLog(skipper middleware.Skipper) echo.MiddlewareFunc {
+	logger := New("http")
+
+	return middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
+		Skipper:      skipper,
+		HandleError:  true,
+		LogStatus:    true,
+		LogLatency:   true,
+		LogMethod:    true,
+		LogURI:       true,
+		LogRemoteIP:  true,
+		LogUserAgent: true,
+		LogError:     true,
+		LogValuesFunc: func(c echo.Context, v middleware.RequestLoggerValues) error {
+			entry := logger.Ctx(c.Request().Context()).WithFields(logrus.Fields{
+				"method":     v.Method,
+				"uri":        v.URI,
+				"status":     v.Status,
+				"latency_ms": v.Latency.Milliseconds(),
+				"remote_ip":  v.RemoteIP,
+				"user_agent": v.UserAgent,
+			})
+
+			if v.Error != nil {
+				entry.WithError(v.Error).Error("Запрос завершился ошибкой")
+				return nil
+			}
+
+			entry.Info("Запрос обработан")
+			return nil
+		},
+	})
+}
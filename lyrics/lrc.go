@@ -0,0 +1,194 @@
+package lyrics
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidLRC = errors.New("некорректный LRC")
+
+var (
+	// [mm:ss], [mm:ss.xx], [mm:ss.xxx]
+	lrcTimeRegexp = regexp.MustCompile(`^\[(\d{1,3}):(\d{1,2})(?:[.:](\d{1,3}))?\]`)
+	// Служебные теги [ar:...], [ti:...], [offset:...]
+	lrcTagRegexp = regexp.MustCompile(`^\[([a-zA-Z#]+):(.*)\]$`)
+	// Метки слов в расширенном LRC: <mm:ss.xx>
+	lrcWordRegexp = regexp.MustCompile(`<(\d{1,3}):(\d{1,2})(?:[.:](\d{1,3}))?>`)
+)
+
+// Word - слово с временем начала из расширенного LRC
+type Word struct {
+	StartMs int64
+	Text    string
+}
+
+// TimedLine - строка текста со временем начала и, если известно, конца
+type TimedLine struct {
+	StartMs int64
+	EndMs   *int64
+	Text    string
+	Words   []Word
+}
+
+// LRC - разобранный файл: служебные теги и строки по возрастанию времени
+type LRC struct {
+	Tags  map[string]string
+	Lines []TimedLine
+}
+
+// ParseLRC разбирает LRC, в том числе расширенный с метками слов. Строка с несколькими
+// метками времени повторяется для каждой. Тег offset (мс) сдвигает все метки.
+// Конец строки - начало следующей.
+func ParseLRC(text string) (LRC, error) {
+	result := LRC{Tags: map[string]string{}}
+
+	for _, line := range strings.Split(Normalize(text), "\n") {
+		if line == "" {
+			continue
+		}
+
+		var starts []int64
+		for {
+			m := lrcTimeRegexp.FindStringSubmatch(line)
+			if m == nil {
+				break
+			}
+			starts = append(starts, lrcMillis(m[1], m[2], m[3]))
+			line = line[len(m[0]):]
+		}
+
+		if len(starts) == 0 {
+			if m := lrcTagRegexp.FindStringSubmatch(line); m != nil {
+				result.Tags[strings.ToLower(m[1])] = strings.TrimSpace(m[2])
+			}
+			continue
+		}
+
+		lineText, words := parseWords(line)
+		for _, start := range starts {
+			result.Lines = append(result.Lines, TimedLine{StartMs: start, Text: lineText, Words: words})
+		}
+	}
+
+	if len(result.Lines) == 0 {
+		return result, fmt.Errorf("%w: нет строк с временными метками", ErrInvalidLRC)
+	}
+
+	if raw, ok := result.Tags["offset"]; ok {
+		offset, err := strconv.ParseInt(strings.TrimPrefix(raw, "+"), 10, 64)
+		if err != nil {
+			return result, fmt.Errorf("%w: offset %s", ErrInvalidLRC, raw)
+		}
+		// Положительный offset показывает текст раньше
+		for i := range result.Lines {
+			result.Lines[i].StartMs = max(result.Lines[i].StartMs-offset, 0)
+			for j := range result.Lines[i].Words {
+				result.Lines[i].Words[j].StartMs = max(result.Lines[i].Words[j].StartMs-offset, 0)
+			}
+		}
+	}
+
+	sort.SliceStable(result.Lines, func(i, j int) bool {
+		return result.Lines[i].StartMs < result.Lines[j].StartMs
+	})
+	for i := 0; i+1 < len(result.Lines); i++ {
+		end := result.Lines[i+1].StartMs
+		result.Lines[i].EndMs = &end
+	}
+
+	return result, nil
+}
+
+// parseWords убирает метки слов из строки и возвращает текст и слова с временем
+func parseWords(line string) (string, []Word) {
+	matches := lrcWordRegexp.FindAllStringSubmatchIndex(line, -1)
+	if len(matches) == 0 {
+		return strings.TrimSpace(line), nil
+	}
+
+	var words []Word
+	for i, m := range matches {
+		end := len(line)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		text := strings.TrimSpace(line[m[1]:end])
+		if text == "" {
+			continue
+		}
+		words = append(words, Word{
+			StartMs: lrcMillis(line[m[2]:m[3]], line[m[4]:m[5]], optionalGroup(line, m[6], m[7])),
+			Text:    text,
+		})
+	}
+
+	return strings.Join(strings.Fields(lrcWordRegexp.ReplaceAllString(line, " ")), " "), words
+}
+
+func optionalGroup(s string, start, end int) string {
+	if start < 0 {
+		return ""
+	}
+	return s[start:end]
+}
+
+// lrcMillis переводит минуты, секунды и дробную часть (сотые или тысячные) в миллисекунды
+func lrcMillis(minutes, seconds, fraction string) int64 {
+	m, _ := strconv.ParseInt(minutes, 10, 64)
+	s, _ := strconv.ParseInt(seconds, 10, 64)
+
+	var ms int64
+	if fraction != "" {
+		f, _ := strconv.ParseInt(fraction, 10, 64)
+		switch len(fraction) {
+		case 1:
+			ms = f * 100
+		case 2:
+			ms = f * 10
+		default:
+			ms = f
+		}
+	}
+
+	return (m*60+s)*1000 + ms
+}
+
+// FormatLRC собирает LRC. Если у строк есть слова с временем, пишется расширенный формат.
+func FormatLRC(tags map[string]string, lines []TimedLine) string {
+	var b strings.Builder
+
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&b, "[%s:%s]\n", key, tags[key])
+	}
+
+	for _, line := range lines {
+		b.WriteString("[" + lrcTimestamp(line.StartMs) + "]")
+		if len(line.Words) == 0 {
+			b.WriteString(line.Text)
+		} else {
+			for i, word := range line.Words {
+				if i > 0 {
+					b.WriteByte(' ')
+				}
+				b.WriteString("<" + lrcTimestamp(word.StartMs) + ">" + word.Text)
+			}
+		}
+		b.WriteByte('\n')
+	}
+
+	return b.String()
+}
+
+// lrcTimestamp - mm:ss.xx
+func lrcTimestamp(ms int64) string {
+	return fmt.Sprintf("%02d:%02d.%02d", ms/60000, ms/1000%60, ms%1000/10)
+}
@@ -0,0 +1,153 @@
+package lyrics
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseLRC(t *testing.T) {
+	type line struct {
+		StartMs int64
+		EndMs   int64
+		Text    string
+	}
+	tests := []struct {
+		name string
+		text string
+		tags map[string]string
+		want []line
+	}{
+		{
+			name: "сотые и тысячные",
+			text: "[00:01.50]Первая\n[00:02.250]Вторая\n[00:03]Третья",
+			tags: map[string]string{},
+			want: []line{{1500, 2250, "Первая"}, {2250, 3000, "Вторая"}, {3000, 0, "Третья"}},
+		},
+		{
+			name: "несколько меток в строке",
+			text: "[00:10.00][00:30.00]Припев\n[00:20.00]Куплет",
+			tags: map[string]string{},
+			want: []line{{10000, 20000, "Припев"}, {20000, 30000, "Куплет"}, {30000, 0, "Припев"}},
+		},
+		{
+			name: "положительный offset сдвигает раньше",
+			text: "[ar:Группа]\n[offset:+500]\n[00:00.20]Раньше начала\n[00:02.00]Строка",
+			tags: map[string]string{"ar": "Группа", "offset": "+500"},
+			want: []line{{0, 1500, "Раньше начала"}, {1500, 0, "Строка"}},
+		},
+		{
+			name: "отрицательный offset сдвигает позже",
+			text: "[offset:-1000]\n[00:01.00]Строка",
+			tags: map[string]string{"offset": "-1000"},
+			want: []line{{2000, 0, "Строка"}},
+		},
+		{
+			name: "строки без меток пропускаются",
+			text: "просто текст\n[00:01.00]Строка\n[битая метка]\n[00:xx.00]Тоже текст\r\n[00:02.00]",
+			tags: map[string]string{},
+			want: []line{{1000, 2000, "Строка"}, {2000, 0, ""}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := ParseLRC(tt.text)
+			if err != nil {
+				t.Fatalf("ParseLRC() error = %v", err)
+			}
+			if !reflect.DeepEqual(parsed.Tags, tt.tags) {
+				t.Errorf("ParseLRC() tags = %v; want %v", parsed.Tags, tt.tags)
+			}
+			var got []line
+			for _, l := range parsed.Lines {
+				var end int64
+				if l.EndMs != nil {
+					end = *l.EndMs
+				}
+				got = append(got, line{l.StartMs, end, l.Text})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseLRC() = %+v; want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLRCWords(t *testing.T) {
+	parsed, err := ParseLRC("[offset:100]\n[00:01.00]<00:01.00>Раз <00:01.50>два  <00:02.125>три")
+	if err != nil {
+		t.Fatalf("ParseLRC() error = %v", err)
+	}
+	want := TimedLine{StartMs: 900, Text: "Раз два три", Words: []Word{{900, "Раз"}, {1400, "два"}, {2025, "три"}}}
+	if !reflect.DeepEqual(parsed.Lines, []TimedLine{want}) {
+		t.Errorf("ParseLRC() = %+v; want %+v", parsed.Lines, want)
+	}
+}
+
+func TestParseLRCErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"пустой текст", ""},
+		{"только теги", "[ar:Группа]\n[ti:Песня]"},
+		{"без меток", "Строка\nЕщё строка"},
+		{"некорректный offset", "[offset:abc]\n[00:01.00]Строка"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseLRC(tt.text); !errors.Is(err, ErrInvalidLRC) {
+				t.Errorf("ParseLRC(%q) error = %v; want %v", tt.text, err, ErrInvalidLRC)
+			}
+		})
+	}
+}
+
+func TestLRCRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "сотые сохраняются",
+			text: "[ar:Группа]\n[ti:Песня]\n[00:01.50]Первая\n[01:02.03]Вторая\n",
+			want: "[ar:Группа]\n[ti:Песня]\n[00:01.50]Первая\n[01:02.03]Вторая\n",
+		},
+		{
+			name: "тысячные округляются вниз до сотых",
+			text: "[00:01.509]Строка\n",
+			want: "[00:01.50]Строка\n",
+		},
+		{
+			name: "несколько меток раскладываются по строкам",
+			text: "[00:03.00][00:01.00]Припев\n[00:02.00]Куплет\n",
+			want: "[00:01.00]Припев\n[00:02.00]Куплет\n[00:03.00]Припев\n",
+		},
+		{
+			name: "метки слов",
+			text: "[00:01.00]<00:01.00>Раз <00:01.50>два\n",
+			want: "[00:01.00]<00:01.00>Раз <00:01.50>два\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := ParseLRC(tt.text)
+			if err != nil {
+				t.Fatalf("ParseLRC() error = %v", err)
+			}
+			got := FormatLRC(parsed.Tags, parsed.Lines)
+			if got != tt.want {
+				t.Errorf("FormatLRC() = %q; want %q", got, tt.want)
+			}
+
+			again, err := ParseLRC(got)
+			if err != nil {
+				t.Fatalf("ParseLRC(FormatLRC()) error = %v", err)
+			}
+			if again := FormatLRC(again.Tags, again.Lines); again != got {
+				t.Errorf("повторный FormatLRC() = %q; want %q", again, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package lyrics
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Типы частей песни
+const (
+	TypeVerse     = "verse"
+	TypeChorus    = "chorus"
+	TypePreChorus = "pre_chorus"
+	TypeBridge    = "bridge"
+	TypeIntro     = "intro"
+	TypeOutro     = "outro"
+	TypeOther     = "other"
+)
+
+var (
+	// [Chorus], [Verse 2], [Припев x2], (Bridge)
+	markerRegexp = regexp.MustCompile(`^[\[(]\s*([^\[\]()]{1,40}?)\s*[\])]\s*:?$`)
+	blankLines   = regexp.MustCompile(`\n{3,}`)
+)
+
+// Ключевые слова меток, проверяются по порядку: "pre-chorus" должен сработать раньше "chorus"
+var sectionKeywords = []struct {
+	sectionType string
+	words       []string
+}{
+	{TypePreChorus, []string{"pre-chorus", "pre chorus", "prechorus", "предприпев", "пред-припев"}},
+	{TypeChorus, []string{"chorus", "refrain", "hook", "припев"}},
+	{TypeVerse, []string{"verse", "куплет"}},
+	{TypeBridge, []string{"bridge", "бридж"}},
+	{TypeIntro, []string{"intro", "вступление"}},
+	{TypeOutro, []string{"outro", "концовка", "кода"}},
+}
+
+// labelRegexps - метка целиком: ключевое слово, номер, число повторов и после двоеточия
+// исполнитель: "Verse 2", "Припев x2", "Chorus: Drake". Строка в скобках, где ключевое
+// слово только часть фразы ("Across the universe"), меткой не считается.
+var labelRegexps = func() []*regexp.Regexp {
+	result := make([]*regexp.Regexp, len(sectionKeywords))
+	for i, keyword := range sectionKeywords {
+		words := make([]string, len(keyword.words))
+		for j, word := range keyword.words {
+			words[j] = regexp.QuoteMeta(word)
+		}
+		result[i] = regexp.MustCompile(`^(?:` + strings.Join(words, "|") + `)\s*(\d+)?\s*(?:[xх×]\s*\d+)?\s*(?::.*)?$`)
+	}
+	return result
+}()
+
+// Section - часть текста песни
+type Section struct {
+	Type string
+	// Метка из текста без скобок, пустая, если метки не было
+	Label  string
+	Number int
+	Text   string
+	// Индекс первой части с тем же текстом, -1 если часть встречается впервые
+	RepeatOf int
+}
+
+// Normalize приводит переносы строк к \n, убирает пробелы по краям строк,
+// BOM и неразрывные пробелы, схлопывает подряд идущие пустые строки
+func Normalize(text string) string {
+	text = strings.TrimPrefix(text, "\ufeff")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	text = strings.ReplaceAll(text, "\u00a0", " ")
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	text = strings.Join(lines, "\n")
+
+	return strings.TrimSpace(blankLines.ReplaceAllString(text, "\n\n"))
+}
+
+// Parse разбивает текст на части по пустым строкам и меткам вида [Chorus].
+// Метка без текста ("[Chorus]" перед повтором) повторяет последнюю часть этого типа.
+// Повторяющиеся блоки без метки считаются припевом.
+func Parse(text string) []Section {
+	var sections []Section
+
+	var current *Section
+	var lines []string
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+		current.Text = strings.Join(lines, "\n")
+		sections = append(sections, *current)
+		current, lines = nil, nil
+	}
+
+	for _, line := range strings.Split(Normalize(text), "\n") {
+		if line == "" {
+			// Метка, после которой сразу пустая строка, ещё ждёт свой текст
+			if current != nil && len(lines) == 0 {
+				continue
+			}
+			flush()
+			continue
+		}
+
+		if m := markerRegexp.FindStringSubmatch(line); m != nil {
+			if sectionType, number, ok := Classify(m[1]); ok {
+				flush()
+				current = &Section{Type: sectionType, Label: m[1], Number: number, RepeatOf: -1}
+				continue
+			}
+		}
+
+		if current == nil {
+			current = &Section{Type: TypeVerse, RepeatOf: -1}
+		}
+		lines = append(lines, line)
+	}
+	flush()
+
+	return linkRepeats(sections)
+}
+
+// Verses - только тексты частей
+func Verses(text string) []string {
+	sections := Parse(text)
+
+	verses := make([]string, 0, len(sections))
+	for _, section := range sections {
+		verses = append(verses, section.Text)
+	}
+	return verses
+}
+
+// Join собирает текст обратно, метки сохраняются, поэтому Parse(Join(sections)) даёт те же части
+func Join(sections []Section) string {
+	blocks := make([]string, 0, len(sections))
+	for _, section := range sections {
+		if section.Label != "" {
+			blocks = append(blocks, "["+section.Label+"]\n"+section.Text)
+			continue
+		}
+		blocks = append(blocks, section.Text)
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// linkRepeats раскрывает пустые метки и связывает одинаковые блоки с первым вхождением
+func linkRepeats(sections []Section) []Section {
+	result := make([]Section, 0, len(sections))
+	firstByKey := make(map[string]int)
+
+	for _, section := range sections {
+		if section.Text == "" {
+			canonical := lastOfType(result, section.Type, section.Label)
+			if canonical < 0 {
+				// Повторять нечего, метка без текста не нужна
+				continue
+			}
+			section.Text = result[canonical].Text
+			if section.Number == 0 {
+				section.Number = result[canonical].Number
+			}
+		}
+
+		key := compareKey(section.Text)
+		if first, ok := firstByKey[key]; ok {
+			section.RepeatOf = first
+			if result[first].Label == "" && section.Label == "" {
+				result[first].Type = TypeChorus
+				section.Type = TypeChorus
+			} else if section.Label == "" {
+				section.Type = result[first].Type
+			}
+		} else {
+			firstByKey[key] = len(result)
+		}
+
+		result = append(result, section)
+	}
+
+	return result
+}
+
+// lastOfType ищет последнюю часть с той же меткой, иначе с тем же типом
+func lastOfType(sections []Section, sectionType, label string) int {
+	for i := len(sections) - 1; i >= 0; i-- {
+		if strings.EqualFold(sections[i].Label, label) {
+			return sections[i].canonical(i)
+		}
+	}
+	for i := len(sections) - 1; i >= 0; i-- {
+		if sections[i].Type == sectionType {
+			return sections[i].canonical(i)
+		}
+	}
+	return -1
+}
+
+func (s Section) canonical(index int) int {
+	if s.RepeatOf >= 0 {
+		return s.RepeatOf
+	}
+	return index
+}
+
+// Classify определяет тип по метке. Метка должна целиком быть известной формой,
+// иначе (имя исполнителя, строка текста в скобках) она остаётся частью текста.
+func Classify(label string) (string, int, bool) {
+	lower := strings.ToLower(strings.TrimSpace(label))
+
+	for i, keyword := range sectionKeywords {
+		m := labelRegexps[i].FindStringSubmatch(lower)
+		if m == nil {
+			continue
+		}
+		number := 0
+		if m[1] != "" {
+			number, _ = strconv.Atoi(m[1])
+		}
+		return keyword.sectionType, number, true
+	}
+	return "", 0, false
+}
+
+// compareKey - текст без регистра, пунктуации и лишних пробелов
+func compareKey(text string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteRune(r)
+		case unicode.IsSpace(r):
+			space = true
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,99 @@
+package lyrics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		label  string
+		typ    string
+		number int
+		ok     bool
+	}{
+		{"Chorus", TypeChorus, 0, true},
+		{"Verse 2", TypeVerse, 2, true},
+		{"verse2", TypeVerse, 2, true},
+		{"Припев x2", TypeChorus, 0, true},
+		{"Припев х2", TypeChorus, 0, true},
+		{"Куплет 3 x2", TypeVerse, 3, true},
+		{"Pre-Chorus", TypePreChorus, 0, true},
+		{"Verse 1: Kendrick Lamar", TypeVerse, 1, true},
+		{"Hook", TypeChorus, 0, true},
+		{"Бридж", TypeBridge, 0, true},
+		{"Across the universe", "", 0, false},
+		{"shook me all night long", "", 0, false},
+		{"Chorus of angels", "", 0, false},
+		{"Kendrick Lamar", "", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			typ, number, ok := Classify(tt.label)
+			if typ != tt.typ || number != tt.number || ok != tt.ok {
+				t.Errorf("Classify(%q) = %q, %d, %v; want %q, %d, %v", tt.label, typ, number, ok, tt.typ, tt.number, tt.ok)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	type part struct {
+		Type  string
+		Label string
+		Text  string
+	}
+	tests := []struct {
+		name string
+		text string
+		want []part
+	}{
+		{
+			name: "строки в скобках остаются текстом",
+			text: "Line one\n(Across the universe)\nLine three\n\nChorus line\n(shook me all night long)",
+			want: []part{
+				{TypeVerse, "", "Line one\n(Across the universe)\nLine three"},
+				{TypeVerse, "", "Chorus line\n(shook me all night long)"},
+			},
+		},
+		{
+			name: "метки частей",
+			text: "[Verse 1]\nFirst\n\n[Chorus]\nRefrain\n\n[Verse 2]\nSecond\n\n[Chorus]",
+			want: []part{
+				{TypeVerse, "Verse 1", "First"},
+				{TypeChorus, "Chorus", "Refrain"},
+				{TypeVerse, "Verse 2", "Second"},
+				{TypeChorus, "Chorus", "Refrain"},
+			},
+		},
+		{
+			name: "метка в круглых скобках с двоеточием",
+			text: "(Припев):\nЛа-ла-ла\n\n(Куплет 2)\nТекст",
+			want: []part{
+				{TypeChorus, "Припев", "Ла-ла-ла"},
+				{TypeVerse, "Куплет 2", "Текст"},
+			},
+		},
+		{
+			name: "повтор без меток считается припевом",
+			text: "One\n\nRepeat\n\nTwo\n\nRepeat",
+			want: []part{
+				{TypeVerse, "", "One"},
+				{TypeChorus, "", "Repeat"},
+				{TypeVerse, "", "Two"},
+				{TypeChorus, "", "Repeat"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []part
+			for _, section := range Parse(tt.text) {
+				got = append(got, part{section.Type, section.Label, section.Text})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v; want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package lyrics
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Длительность последней строки, если её конец неизвестен
+const lastCueMs = 5000
+
+// FormatWebVTT собирает субтитры WebVTT, по одной реплике на строку
+func FormatWebVTT(lines []TimedLine) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n")
+
+	for i, line := range lines {
+		end := line.StartMs + lastCueMs
+		switch {
+		case line.EndMs != nil:
+			end = *line.EndMs
+		case i+1 < len(lines):
+			end = lines[i+1].StartMs
+		}
+
+		fmt.Fprintf(&b, "\n%d\n%s --> %s\n%s\n", i+1, vttTimestamp(line.StartMs), vttTimestamp(end), line.Text)
+	}
+
+	return b.String()
+}
+
+// vttTimestamp - hh:mm:ss.mmm
+func vttTimestamp(ms int64) string {
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
@@ -0,0 +1,47 @@
+package lyrics
+
+import "testing"
+
+func TestFormatWebVTT(t *testing.T) {
+	tests := []struct {
+		name string
+		lrc  string
+		want string
+	}{
+		{
+			name: "конец реплики - начало следующей, у последней 5 секунд",
+			lrc:  "[00:01.50]Первая\n[00:02.250]Вторая",
+			want: "WEBVTT\n\n1\n00:00:01.500 --> 00:00:02.250\nПервая\n\n2\n00:00:02.250 --> 00:00:07.250\nВторая\n",
+		},
+		{
+			name: "часы и несколько меток в строке",
+			lrc:  "[59:59.99][61:00.00]Припев",
+			want: "WEBVTT\n\n1\n00:59:59.990 --> 01:01:00.000\nПрипев\n\n2\n01:01:00.000 --> 01:01:05.000\nПрипев\n",
+		},
+		{
+			name: "offset",
+			lrc:  "[offset:250]\n[00:01.00]Строка",
+			want: "WEBVTT\n\n1\n00:00:00.750 --> 00:00:05.750\nСтрока\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := ParseLRC(tt.lrc)
+			if err != nil {
+				t.Fatalf("ParseLRC() error = %v", err)
+			}
+			if got := FormatWebVTT(parsed.Lines); got != tt.want {
+				t.Errorf("FormatWebVTT() = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatWebVTTEndMs(t *testing.T) {
+	end := int64(1800)
+	lines := []TimedLine{{StartMs: 1000, EndMs: &end, Text: "Первая"}, {StartMs: 3000, Text: "Вторая"}}
+	want := "WEBVTT\n\n1\n00:00:01.000 --> 00:00:01.800\nПервая\n\n2\n00:00:03.000 --> 00:00:08.000\nВторая\n"
+	if got := FormatWebVTT(lines); got != want {
+		t.Errorf("FormatWebVTT() = %q; want %q", got, want)
+	}
+}
@@ -1,17 +1,47 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
-	"songLibrary/handlers"
+	"os"
 	"songLibrary/initializers"
+	"strings"
+)
 
-	"github.com/labstack/echo/v4"
-	"github.com/labstack/echo/v4/middleware"
-	log "github.com/sirupsen/logrus"
+// Коды выхода команд
+const (
+	exitOK      = 0
+	exitFailure = 1 // ошибка выполнения
+	exitUsage   = 2 // неверные аргументы или конфиг
+	exitPartial = 3 // часть записей не обработана
 )
 
-var debugMode = flag.Bool("debug", false, "Дебаг-режим")
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
+
+var commands = []command{
+	{"serve", "запустить HTTP-сервер (по умолчанию)", runServe},
+	{"migrate", "создать БД и применить миграции", runMigrate},
+	{"seed", "добавить тестовые песни", runSeed},
+	{"import", "импорт песен из JSON Lines", runImport},
+	{"import-audio", "импорт песен из тегов аудиофайлов", runImportAudio},
+	{"export", "экспорт песен в JSON Lines", runExport},
+	{"enrich", "дозаполнить данные песен из внешнего API", runEnrich},
+	{"refresh", "сверить давно не проверенные песни с провайдерами", runRefresh},
+	{"reparse-lyrics", "заново разобрать тексты песен на части", runReparseLyrics},
+	{"scan-explicit", "заново проверить тексты на ненормативную лексику", runScanExplicit},
+	{"sync-links", "перенести ссылки песен в список ссылок", runSyncLinks},
+	{"check-links", "проверить давно не проверенные ссылки", runCheckLinks},
+	{"purge-trash", "окончательно удалить мягко удалённые записи", runPurgeTrash},
+	{"create-api-key", "создать API-ключ для административных ручек", runCreateAPIKey},
+	{"check-config", "проверить конфигурацию", runCheckConfig},
+	{"config", "config print - вывести конфиг без секретов", runConfig},
+}
 
 // @title           songLibraryAPI
 // @version         1.0
@@ -19,40 +49,92 @@ var debugMode = flag.Bool("debug", false, "Дебаг-режим")
 
 // @host localhost:8080
 // @BasePath /api/v1
+
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
 func main() {
-	// Дебаг режим
-	flag.Parse()
-	if *debugMode {
-		log.SetLevel(log.DebugLevel)
-		log.Debug("Debug-режим активирован")
-	} else {
-		log.SetLevel(log.InfoLevel)
+	args := os.Args[1:]
+
+	// Без команды (или сразу с флагами) запускаем сервер, как раньше
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		os.Exit(runServe(args))
 	}
 
-	// Env файл
-	initializers.LoadEnv()
+	if args[0] == "help" {
+		usage()
+		os.Exit(exitOK)
+	}
 
-	// Инициализация config + DB
-	serverConfig := initializers.FormServerConfig()
-	dbConfig := initializers.FormDBConfig()
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			os.Exit(cmd.run(args[1:]))
+		}
+	}
 
-	// Автомиграция
-	initializers.Migrate(dbConfig)
+	fmt.Fprintf(os.Stderr, "неизвестная команда: %s\n\n", args[0])
+	usage()
+	os.Exit(exitUsage)
+}
 
-	// Echo
-	e := echo.New()
+func usage() {
+	fmt.Fprintln(os.Stderr, "Использование: songLibrary <команда> [флаги]")
+	fmt.Fprintln(os.Stderr, "\nКоманды:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-16s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(os.Stderr, "\nФлаги конфигурации общие для всех команд: songLibrary <команда> -h")
+}
 
-	log.Info("Регистрируем middleware") // Info-лог
+// commandFlags - флаги команды вместе с общими флагами конфигурации
+type commandFlags struct {
+	*flag.FlagSet
+	build func() (initializers.Config, error)
+}
+
+func newCommandFlags(name string) *commandFlags {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	return &commandFlags{FlagSet: fs, build: initializers.RegisterConfigFlags(fs)}
+}
 
-	e.Use(middleware.Recover(), middleware.Logger())
+var errUsage = errors.New("неверные аргументы")
 
-	h := handlers.NewHandler()
+// parse разбирает флаги и собирает конфиг из файла, env и флагов
+func (f *commandFlags) parse(args []string) (initializers.Config, error) {
+	if err := f.Parse(args); err != nil {
+		return initializers.Config{}, errUsage
+	}
+
+	// Env файл
+	initializers.LoadEnv()
 
-	log.Info("Регистрируем handlers") // Info-лог
+	return f.build()
+}
 
-	registerHandlers(e, h)
+// reportConfigError печатает ошибку конфига в stderr и возвращает код выхода
+func reportConfigError(err error) int {
+	if !errors.Is(err, errUsage) {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	return exitUsage
+}
 
-	log.Info("Запускаем сервер") // Info-лог
+// flagPassed - задан ли флаг в командной строке явно
+func flagPassed(fs *flag.FlagSet, name string) bool {
+	passed := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			passed = true
+		}
+	})
+	return passed
+}
 
-	log.Fatal(e.Start(fmt.Sprintf("0.0.0.0:%v", serverConfig.Port)))
+// writeJSON печатает результат команды в stdout одной строкой JSON
+func writeJSON(v any) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
 }
@@ -0,0 +1,113 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+	"slices"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var ErrInvalidImage = errors.New("некорректное изображение")
+
+// Типы, которые принимаются при загрузке. Тип определяется по содержимому,
+// заголовок Content-Type клиента не учитывается.
+var ContentTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+
+// Миниатюры всегда в JPEG
+const ThumbnailContentType = "image/jpeg"
+
+// Больше пикселей не раскодируем: маленький файл может раскрыться в гигабайты памяти
+const maxPixels = 40_000_000
+
+// Image - проверенное изображение с готовой миниатюрой
+type Image struct {
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+	Thumbnail   []byte
+}
+
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// Sniff определяет тип изображения по первым байтам
+func Sniff(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	if !slices.Contains(ContentTypes, contentType) {
+		return "", fmt.Errorf("%w: тип %s не поддерживается, ожидается один из %v", ErrInvalidImage, contentType, ContentTypes)
+	}
+	return contentType, nil
+}
+
+// Process проверяет изображение и строит миниатюру, вписанную в квадрат thumbnailSize.
+// Изображение меньше квадрата не увеличивается. У GIF берётся первый кадр.
+func Process(data []byte, thumbnailSize int) (Image, error) {
+	contentType, err := Sniff(data)
+	if err != nil {
+		return Image{}, err
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, fmt.Errorf("%w: %s", ErrInvalidImage, err)
+	}
+	if config.Width < 1 || config.Height < 1 || config.Width*config.Height > maxPixels {
+		return Image{}, fmt.Errorf("%w: размер %dx%d больше допустимого", ErrInvalidImage, config.Width, config.Height)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, fmt.Errorf("%w: %s", ErrInvalidImage, err)
+	}
+
+	thumbnail, err := Thumbnail(src, thumbnailSize)
+	if err != nil {
+		return Image{}, err
+	}
+
+	return Image{
+		ContentType: contentType,
+		Extension:   extensions[contentType],
+		Width:       config.Width,
+		Height:      config.Height,
+		Thumbnail:   thumbnail,
+	}, nil
+}
+
+// Thumbnail уменьшает изображение с сохранением пропорций и кодирует в JPEG.
+// Прозрачные области заливаются белым.
+func Thumbnail(src image.Image, size int) ([]byte, error) {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > size || height > size {
+		if width >= height {
+			width, height = size, max(height*size/width, 1)
+		} else {
+			width, height = max(width*size/height, 1), size
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
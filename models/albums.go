@@ -0,0 +1,23 @@
+package models
+
+import "database/sql/driver"
+
+// AlbumInfo - альбом песни в ответе провайдера и в строке экспорта
+type AlbumInfo struct {
+	Title       string `json:"title" example:"American Beauty/American Psycho"`
+	ReleaseDate string `json:"release_date,omitempty" example:"16.01.2015"`
+	Type        string `json:"type,omitempty" example:"lp"`
+	TrackNumber int    `json:"track_number,omitempty" example:"3"`
+	DiscNumber  int    `json:"disc_number,omitempty" example:"1"`
+}
+
+func (a *AlbumInfo) Value() (driver.Value, error) {
+	return jsonValue(a, a == nil)
+}
+
+func (a *AlbumInfo) Scan(value any) error {
+	if value == nil {
+		return nil
+	}
+	return jsonScan(value, a, "альбома")
+}
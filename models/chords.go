@@ -0,0 +1,27 @@
+package models
+
+import "database/sql/driver"
+
+// VerseChord - аккорд над символом строки части текста
+type VerseChord struct {
+	// Номер строки в части, с нуля
+	Line int `json:"line" example:"0"`
+	// Номер символа в строке, с нуля
+	Pos   int    `json:"pos" example:"9"`
+	Chord string `json:"chord" example:"Am7"`
+}
+
+// VerseChords хранится в jsonb, пустой список пишется как NULL
+type VerseChords []VerseChord
+
+func (c VerseChords) Value() (driver.Value, error) {
+	return jsonValue(c, len(c) == 0)
+}
+
+func (c *VerseChords) Scan(value any) error {
+	if value == nil {
+		*c = nil
+		return nil
+	}
+	return jsonScan(value, c, "аккордов")
+}
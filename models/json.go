@@ -0,0 +1,28 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// jsonValue пишет значение в jsonb, пустое значение пишется как NULL
+func jsonValue(value any, empty bool) (driver.Value, error) {
+	if empty {
+		return nil, nil
+	}
+	data, err := json.Marshal(value)
+	return string(data), err
+}
+
+// jsonScan читает jsonb в dst, name - для текста ошибки
+func jsonScan(value any, dst any, name string) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, dst)
+	case string:
+		return json.Unmarshal([]byte(v), dst)
+	default:
+		return fmt.Errorf("неподдерживаемый тип %s: %T", name, value)
+	}
+}
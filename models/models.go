@@ -1,8 +1,10 @@
 package models
 
 import (
+	"fmt"
 	"time"
 
+	"github.com/lib/pq"
 	"gorm.io/gorm"
 )
 
@@ -17,7 +19,56 @@ type Model struct {
 
 type Group struct {
 	Model
-	Name string `gorm:"size:255;not null;uniqueIndex" json:"name"`
+	Name   string  `gorm:"size:255;not null;uniqueIndex" json:"name"`
+	Genres []Genre `gorm:"many2many:group_genres" json:"genres,omitempty"`
+	Tags   []Tag   `gorm:"many2many:group_tags" json:"tags,omitempty"`
+	// Фотография группы
+	PhotoID *int   `gorm:"index" json:"-"`
+	Photo   *Image `json:"photo,omitempty"`
+}
+
+// Путь, по которому API отдаёт изображения, см. Image.AfterFind
+const ImagesPath = "/api/v1/library/images"
+
+// Загруженное изображение: оригинал и миниатюра лежат в хранилище файлов по ключам.
+// Отдаётся через API, поэтому ссылки не зависят от хранилища.
+type Image struct {
+	Model
+	Key          string `gorm:"size:255;not null" json:"-"`
+	ThumbnailKey string `gorm:"size:255;not null" json:"-"`
+	ContentType  string `gorm:"size:50;not null" json:"content_type" example:"image/jpeg"`
+	Size         int    `gorm:"not null" json:"size" example:"183204"`
+	Width        int    `gorm:"not null" json:"width" example:"1200"`
+	Height       int    `gorm:"not null" json:"height" example:"1200"`
+	URL          string `gorm:"-" json:"url" example:"/api/v1/library/images/1"`
+	ThumbnailURL string `gorm:"-" json:"thumbnail_url" example:"/api/v1/library/images/1/thumbnail"`
+}
+
+// AfterFind заполняет ссылки на изображение и миниатюру
+func (i *Image) AfterFind(tx *gorm.DB) error {
+	i.URL = fmt.Sprintf("%s/%d", ImagesPath, i.ID)
+	i.ThumbnailURL = i.URL + "/thumbnail"
+	return nil
+}
+
+// AfterCreate - ссылки нужны и в ответе на загрузку
+func (i *Image) AfterCreate(tx *gorm.DB) error {
+	return i.AfterFind(tx)
+}
+
+// Жанр в иерархии: фильтр по жанру находит и песни его поджанров
+type Genre struct {
+	Model
+	Name     string  `gorm:"size:100;not null;uniqueIndex" json:"name" example:"Alternative Rock"`
+	Slug     string  `gorm:"size:100;not null;uniqueIndex" json:"slug" example:"alternative-rock"`
+	ParentID *int    `gorm:"index" json:"parent_id,omitempty" example:"1"`
+	Children []Genre `gorm:"foreignKey:ParentID" json:"children,omitempty"`
+}
+
+// Свободный тег, хранится в нижнем регистре
+type Tag struct {
+	Model
+	Name string `gorm:"size:50;not null;uniqueIndex" json:"name" example:"summer"`
 }
 
 type Song struct {
@@ -27,6 +78,108 @@ type Song struct {
 	ReleaseDate string   `gorm:"size:10;index" json:"release_date" example:"01.01.2019"`
 	Link        string   `gorm:"size:255;index" json:"link" example:"https://www.youtube.com/watch?v=LBr7kECsjcQ"`
 	Lyrics      []Lyrics `gorm:"foreignKey:SongID" json:"lyrics"`
+	// Источник каждого поля: release_date, link, lyrics -> имя провайдера, manual или import
+	Provenance Provenance `gorm:"type:jsonb" json:"provenance,omitempty" swaggertype:"object,string" example:"release_date:info,lyrics:manual"`
+	// Когда данные последний раз сверялись с провайдерами
+	RefreshedAt *time.Time `gorm:"index" json:"refreshed_at,omitempty"`
+	Links       []SongLink `gorm:"foreignKey:SongID" json:"links,omitempty"`
+	// Исходник ChordPro, отдаётся ручкой аккордов
+	ChordPro    string `gorm:"type:text" json:"-"`
+	AlbumID     *int   `gorm:"index" json:"album_id,omitempty" example:"1"`
+	Album       *Album `json:"album,omitempty"`
+	TrackNumber *int   `json:"track_number,omitempty" example:"3"`
+	DiscNumber  *int   `json:"disc_number,omitempty" example:"1"`
+	// Все участники песни, основной исполнитель из group_id среди них с ролью main
+	Credits []SongCredit `gorm:"foreignKey:SongID" json:"credits,omitempty"`
+	// Собственные жанры и теги песни, в фильтрах к ним добавляются жанры и теги группы
+	Genres []Genre `gorm:"many2many:song_genres" json:"genres,omitempty"`
+	Tags   []Tag   `gorm:"many2many:song_tags" json:"tags,omitempty"`
+	// Обложка песни
+	CoverID *int   `gorm:"index" json:"-"`
+	Cover   *Image `json:"cover,omitempty"`
+	// Есть ли в тексте ненормативная лексика: ручная отметка, а без неё - найденное в тексте
+	Explicit bool `gorm:"not null;default:false;index" json:"explicit" example:"false"`
+	// Номера (order) частей текста, в которых найдена ненормативная лексика
+	ExplicitVerses pq.Int64Array `gorm:"type:integer[]" json:"explicit_verses,omitempty" swaggertype:"array,integer" example:"2,4"`
+	// Ручная отметка, важнее найденного в тексте
+	ExplicitOverride *bool `json:"explicit_override,omitempty" example:"true"`
+	// Песня, чей текст отдаётся вместо своего: у версии нет текста, а связь разрешает наследование
+	LyricsSourceID *int `gorm:"-" json:"lyrics_source_id,omitempty" example:"1"`
+}
+
+// Роли участников песни
+const (
+	CreditRoleMain     = "main"
+	CreditRoleFeatured = "featured"
+	CreditRoleComposer = "composer"
+	CreditRoleLyricist = "lyricist"
+	CreditRoleProducer = "producer"
+)
+
+// Участник песни: исполнитель или автор. Один исполнитель может быть в песне в нескольких ролях.
+type SongCredit struct {
+	Model
+	SongID  int    `gorm:"not null;uniqueIndex:idx_song_credits_song_group_role,where:deleted_at IS NULL" json:"song_id" example:"1"`
+	GroupID int    `gorm:"not null;index;uniqueIndex:idx_song_credits_song_group_role" json:"group_id" example:"2"`
+	Group   *Group `json:"group,omitempty"`
+	// main, featured, composer, lyricist, producer
+	Role  string `gorm:"size:20;not null;index;uniqueIndex:idx_song_credits_song_group_role" json:"role" example:"featured"`
+	Order int    `gorm:"not null;default:0" json:"order" example:"1"`
+}
+
+// Типы альбомов
+const (
+	AlbumTypeLP          = "lp"
+	AlbumTypeEP          = "ep"
+	AlbumTypeSingle      = "single"
+	AlbumTypeCompilation = "compilation"
+)
+
+// Альбом группы. У сборника группы может не быть.
+type Album struct {
+	Model
+	GroupID     *int   `gorm:"uniqueIndex:idx_albums_group_title,where:deleted_at IS NULL" json:"group_id,omitempty" example:"1"`
+	Title       string `gorm:"size:255;not null;uniqueIndex:idx_albums_group_title" json:"title" example:"American Beauty/American Psycho"`
+	ReleaseDate string `gorm:"size:10;index" json:"release_date,omitempty" example:"16.01.2015"`
+	// lp, ep, single, compilation
+	Type string `gorm:"size:20;not null;default:lp;index" json:"type" example:"lp"`
+	// Треки по порядку дисков и номеров, заполняется только в ответе на запрос альбома
+	Songs []Song `gorm:"foreignKey:AlbumID" json:"songs,omitempty"`
+}
+
+// Типы связей между версиями песни
+const (
+	RelationCover       = "cover"
+	RelationRemix       = "remix"
+	RelationLive        = "live"
+	RelationSample      = "sample"
+	RelationTranslation = "translation"
+)
+
+// Связь версий: песня SongID - кавер, ремикс, концертная версия, перевод или сэмпл песни RelatedID
+type SongRelation struct {
+	Model
+	SongID    int `gorm:"not null;index;uniqueIndex:idx_song_relations_pair,where:deleted_at IS NULL" json:"song_id" example:"2"`
+	RelatedID int `gorm:"not null;index;uniqueIndex:idx_song_relations_pair" json:"related_id" example:"1"`
+	// cover, remix, live, sample, translation
+	Type string `gorm:"size:20;not null;index;uniqueIndex:idx_song_relations_pair" json:"type" example:"cover"`
+	// Пока у версии нет своего текста, отдаётся текст оригинала
+	InheritLyrics bool `gorm:"not null;default:false" json:"inherit_lyrics" example:"true"`
+}
+
+// Ссылка на песню на одной из площадок. URL хранится в каноничном виде.
+type SongLink struct {
+	Model
+	SongID     int    `gorm:"not null;uniqueIndex:idx_song_links_song_url" json:"song_id" example:"1"`
+	Type       string `gorm:"size:20;not null;index" json:"type" example:"youtube"`
+	URL        string `gorm:"size:500;not null;uniqueIndex:idx_song_links_song_url" json:"url" example:"https://www.youtube.com/watch?v=LBr7kECsjcQ"`
+	ExternalID string `gorm:"size:100" json:"external_id,omitempty" example:"LBr7kECsjcQ"`
+	// Ссылка не открывается: площадка ответила 404/410 или проверки подряд завершились ошибкой
+	Broken     bool       `gorm:"not null;default:false;index" json:"broken" example:"false"`
+	Failures   int        `gorm:"not null;default:0" json:"-"`
+	LastStatus int        `json:"last_status,omitempty" example:"200"`
+	LastError  string     `gorm:"size:255" json:"last_error,omitempty"`
+	CheckedAt  *time.Time `gorm:"index" json:"checked_at,omitempty"`
 }
 
 type Lyrics struct {
@@ -34,6 +187,175 @@ type Lyrics struct {
 	SongID int    `gorm:"not null;index" json:"song_id" example:"1"`
 	Verse  string `gorm:"not null;index" json:"verse" example:"Some legends are told"`
 	Order  int    `gorm:"not null;index" json:"order" example:"1"`
+	// verse, chorus, pre_chorus, bridge, intro, outro, other
+	Type string `gorm:"size:20;not null;default:verse" json:"type" example:"chorus"`
+	// Метка из текста, например "Verse 2"
+	Label string `gorm:"size:50" json:"label,omitempty" example:"Chorus"`
+	// ID первого вхождения повторяющейся части, обычно припева
+	RepeatOfID *int `gorm:"index" json:"repeat_of_id,omitempty" example:"2"`
+	// Аккорды из ChordPro, если он загружен
+	Chords VerseChords `gorm:"type:jsonb" json:"chords,omitempty"`
+}
+
+// LyricLine - строка текста с необязательными таймингами для караоке
+type LyricLine struct {
+	Model
+	SongID int `gorm:"not null;index" json:"song_id" example:"1"`
+	// Часть текста, к которой относится строка
+	LyricsID *int   `gorm:"index" json:"lyrics_id,omitempty" example:"1"`
+	Order    int    `gorm:"not null" json:"order" example:"1"`
+	Text     string `gorm:"not null" json:"text" example:"Some legends are told"`
+	StartMs  *int64 `json:"start_ms,omitempty" example:"12000"`
+	EndMs    *int64 `json:"end_ms,omitempty" example:"15400"`
+	// Тайминги слов из расширенного LRC
+	Words WordTimings `gorm:"type:jsonb" json:"words,omitempty"`
+}
+
+// Перевод части текста, на каждый язык - одна запись на часть
+type LyricsTranslation struct {
+	Model
+	SongID   int    `gorm:"not null;index" json:"song_id" example:"1"`
+	LyricsID int    `gorm:"not null;uniqueIndex:idx_lyrics_translations_verse_lang" json:"lyrics_id" example:"1"`
+	Lang     string `gorm:"size:10;not null;uniqueIndex:idx_lyrics_translations_verse_lang" json:"lang" example:"en"`
+	Verse    string `gorm:"not null" json:"verse" example:"Some legends are told"`
+}
+
+// Видимость плейлиста и поведение при повторном добавлении песни
+const (
+	PlaylistPrivate = "private"
+	PlaylistShared  = "shared"
+	PlaylistPublic  = "public"
+
+	DuplicatesAllow  = "allow"
+	DuplicatesSkip   = "skip"
+	DuplicatesReject = "reject"
+)
+
+// Плейлист владельца - имени API-ключа. private видит только владелец, shared - любой
+// со ссылкой с share_token, public ещё и попадает в общий список.
+type Playlist struct {
+	Model
+	Name        string `gorm:"size:255;not null" json:"name" example:"Road trip"`
+	Description string `gorm:"type:text" json:"description,omitempty" example:"Для дальней дороги"`
+	Owner       string `gorm:"size:100;not null;index" json:"owner" example:"cron"`
+	Visibility  string `gorm:"size:20;not null;default:private;index" json:"visibility" example:"shared"`
+	// Отдаётся только владельцу
+	ShareToken string `gorm:"size:32;not null;uniqueIndex" json:"share_token,omitempty" example:"9f86d081884c7d659a2feaa0c55ad015"`
+	// allow, skip, reject
+	DuplicatePolicy string          `gorm:"size:10;not null;default:allow" json:"duplicate_policy" example:"skip"`
+	Entries         []PlaylistEntry `gorm:"foreignKey:PlaylistID" json:"entries,omitempty"`
+}
+
+// Песня в плейлисте, одна песня может встречаться несколько раз
+type PlaylistEntry struct {
+	Model
+	PlaylistID int   `gorm:"not null;index" json:"playlist_id" example:"1"`
+	SongID     int   `gorm:"not null;index" json:"song_id" example:"1"`
+	Song       *Song `json:"song,omitempty"`
+	Position   int   `gorm:"not null" json:"position" example:"1"`
+}
+
+// Сетлист выступления владельца - имени API-ключа
+type Setlist struct {
+	Model
+	Name  string `gorm:"size:255;not null" json:"name" example:"Summer Fest"`
+	Venue string `gorm:"size:255" json:"venue,omitempty" example:"Main Stage"`
+	Date  string `gorm:"size:10;index" json:"date,omitempty" example:"21.06.2025"`
+	Owner string `gorm:"size:100;not null;index" json:"owner" example:"cron"`
+	// Сумма указанных длительностей песен
+	TotalDurationSec int `gorm:"-" json:"total_duration_sec" example:"2700"`
+	// Песни без длительности, в сумму не вошли
+	UntimedEntries int            `gorm:"-" json:"untimed_entries" example:"1"`
+	Entries        []SetlistEntry `gorm:"foreignKey:SetlistID" json:"entries,omitempty"`
+}
+
+// Песня в сетлисте со своими заметками, тональностью и ожидаемой длительностью
+type SetlistEntry struct {
+	Model
+	SetlistID int    `gorm:"not null;index" json:"setlist_id" example:"1"`
+	SongID    int    `gorm:"not null;index" json:"song_id" example:"1"`
+	Song      *Song  `json:"song,omitempty"`
+	Position  int    `gorm:"not null" json:"position" example:"1"`
+	Notes     string `gorm:"type:text" json:"notes,omitempty" example:"Длинное вступление, гитара одна"`
+	// Тональность выступления, аккорды в печатной версии транспонируются в неё
+	Key         string `gorm:"size:20" json:"key,omitempty" example:"Am"`
+	DurationSec *int   `json:"duration_sec,omitempty" example:"225"`
+}
+
+// Статусы задания сборки песенника
+const (
+	SongbookPending = "pending"
+	SongbookRunning = "running"
+	SongbookDone    = "done"
+	SongbookFailed  = "failed"
+)
+
+// Задание сборки песенника владельца - имени API-ключа. Песни выбираются при создании
+// задания, готовый файл хранится до expires_at.
+type SongbookJob struct {
+	Model
+	Owner string `gorm:"size:100;not null;index" json:"owner" example:"cron"`
+	Title string `gorm:"size:255;not null" json:"title" example:"Песни у костра"`
+	// pdf, epub
+	Format  string        `gorm:"size:10;not null" json:"format" example:"pdf"`
+	SongIDs pq.Int64Array `gorm:"type:integer[];not null" json:"song_ids" swaggertype:"array,integer" example:"1,2,3"`
+	// pending, running, done, failed
+	Status     string     `gorm:"size:10;not null;default:pending;index" json:"status" example:"done"`
+	Error      string     `gorm:"size:500" json:"error,omitempty"`
+	Size       int        `gorm:"not null;default:0" json:"size" example:"48213"`
+	Data       []byte     `gorm:"type:bytea" json:"-"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	ExpiresAt  *time.Time `gorm:"index" json:"expires_at,omitempty"`
+}
+
+// Служебные таблицы
+
+// Версия схемы, записывается после успешной миграции
+type SchemaMigration struct {
+	Version   int       `gorm:"primarykey"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// Ключ доступа к административным ручкам, хранится только хеш
+type APIKey struct {
+	Model
+	Name       string     `gorm:"size:100;not null;uniqueIndex" json:"name" example:"cron"`
+	Hash       string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+// Сохранённый ответ провайдера, ключ - провайдер и нормализованные группа и название
+type ExternalAPIResponse struct {
+	ID          int        `gorm:"primarykey" json:"id" example:"1"`
+	Provider    string     `gorm:"size:50;not null;default:info;uniqueIndex:idx_external_api_responses_provider_key" json:"provider" example:"info"`
+	GroupKey    string     `gorm:"size:255;not null;uniqueIndex:idx_external_api_responses_provider_key" json:"group_key" example:"fall out boy"`
+	SongKey     string     `gorm:"size:255;not null;uniqueIndex:idx_external_api_responses_provider_key" json:"song_key" example:"centuries"`
+	ReleaseDate string     `gorm:"size:10" json:"release_date" example:"01.01.2019"`
+	Text        string     `json:"text" example:"Some legends are told"`
+	Link        string     `gorm:"size:255" json:"link" example:"https://www.youtube.com/watch?v=LBr7kECsjcQ"`
+	Album       *AlbumInfo `gorm:"type:jsonb" json:"album,omitempty"`
+	FetchedAt   time.Time  `gorm:"not null" json:"fetched_at" example:"2024-11-23T18:55:28.896205+03:00"`
+	ExpiresAt   time.Time  `gorm:"not null;index" json:"expires_at" example:"2024-11-30T18:55:28.896205+03:00"`
+}
+
+const (
+	ProposalPending  = "pending"
+	ProposalAccepted = "accepted"
+	ProposalRejected = "rejected"
+)
+
+// Изменение от провайдера для поля, которое правили вручную, ждёт решения человека
+type SongProposal struct {
+	Model
+	SongID        int        `gorm:"not null;index" json:"song_id" example:"1"`
+	Field         string     `gorm:"size:20;not null" json:"field" example:"link"`
+	CurrentValue  string     `json:"current_value" example:"https://www.youtube.com/watch?v=LBr7kECsjcQ"`
+	ProposedValue string     `gorm:"not null" json:"proposed_value" example:"https://www.youtube.com/watch?v=Xsp3_a-PMTw"`
+	Provider      string     `gorm:"size:50;not null" json:"provider" example:"info"`
+	Status        string     `gorm:"size:10;not null;index;default:pending" json:"status" example:"pending"`
+	ResolvedBy    string     `gorm:"size:100" json:"resolved_by,omitempty" example:"cron"`
+	ResolvedAt    *time.Time `json:"resolved_at,omitempty"`
 }
 
 // Запросы
@@ -51,6 +373,126 @@ type Edit struct {
 	GroupName   string   `json:"group_name" example:"Fall Out Boys"`
 }
 
+// ExplicitInput - ручная отметка ненормативной лексики, null снимает отметку
+type ExplicitInput struct {
+	Explicit *bool `json:"explicit" example:"true"`
+}
+
+type LinkInput struct {
+	URL string `json:"url" example:"https://youtu.be/LBr7kECsjcQ"`
+}
+
+type AlbumInput struct {
+	Title string `json:"title" example:"American Beauty/American Psycho"`
+	// Группа по имени, создаётся, если её нет. Необязательна для сборника.
+	Group       string `json:"group" example:"Fall Out Boy"`
+	ReleaseDate string `json:"release_date" example:"16.01.2015"`
+	Type        string `json:"type" example:"lp"`
+}
+
+type GenreInput struct {
+	Name     string `json:"name" example:"Alternative Rock"`
+	ParentID *int   `json:"parent_id" example:"1"`
+}
+
+// Жанры по slug, заменяют текущие
+type GenresInput struct {
+	Genres []string `json:"genres" example:"alternative-rock"`
+}
+
+// Теги заменяют текущие, новые создаются
+type TagsInput struct {
+	Tags []string `json:"tags" example:"summer"`
+}
+
+type PlaylistInput struct {
+	Name        string `json:"name" example:"Road trip"`
+	Description string `json:"description" example:"Для дальней дороги"`
+	// private (по умолчанию), shared, public
+	Visibility string `json:"visibility" example:"shared"`
+	// allow (по умолчанию), skip, reject
+	DuplicatePolicy string `json:"duplicate_policy" example:"skip"`
+}
+
+// Без позиции песня добавляется в конец
+type PlaylistEntryInput struct {
+	SongID   int `json:"song_id" example:"1"`
+	Position int `json:"position" example:"2"`
+}
+
+type PositionInput struct {
+	Position int `json:"position" example:"1"`
+}
+
+type SetlistInput struct {
+	Name  string `json:"name" example:"Summer Fest"`
+	Venue string `json:"venue" example:"Main Stage"`
+	Date  string `json:"date" example:"21.06.2025"`
+}
+
+// Без позиции песня добавляется в конец
+type SetlistEntryInput struct {
+	SongID      int    `json:"song_id" example:"1"`
+	Position    int    `json:"position" example:"2"`
+	Notes       string `json:"notes" example:"Длинное вступление, гитара одна"`
+	Key         string `json:"key" example:"Am"`
+	DurationSec *int   `json:"duration_sec" example:"225"`
+}
+
+// Заменяет заметки, тональность и длительность песни сетлиста
+type SetlistEntryUpdate struct {
+	Notes       string `json:"notes" example:"Без второго куплета"`
+	Key         string `json:"key" example:"Am"`
+	DurationSec *int   `json:"duration_sec" example:"180"`
+}
+
+// Песни песенника берутся из одного источника: song_ids, playlist_id, album_id
+// или фильтров group, genre и tag, которые можно сочетать
+type SongbookInput struct {
+	Title string `json:"title" example:"Песни у костра"`
+	// pdf (по умолчанию), epub
+	Format  string `json:"format" example:"pdf"`
+	SongIDs []int  `json:"song_ids" example:"1,2,3"`
+	// shared-плейлист открывается по playlist_token
+	PlaylistID    int    `json:"playlist_id" example:"1"`
+	PlaylistToken string `json:"playlist_token" example:"9f86d081884c7d659a2feaa0c55ad015"`
+	AlbumID       int    `json:"album_id" example:"1"`
+	Group         string `json:"group" example:"Кино"`
+	// slug жанра, песни поджанров тоже попадают
+	Genre string `json:"genre" example:"rock"`
+	Tag   string `json:"tag" example:"summer"`
+}
+
+type RelationInput struct {
+	// ID оригинала
+	RelatedID     int    `json:"related_id" example:"1"`
+	Type          string `json:"type" example:"cover"`
+	InheritLyrics bool   `json:"inherit_lyrics" example:"true"`
+}
+
+// Участник по имени, создаётся, если его нет. Роль по умолчанию featured.
+type CreditInput struct {
+	Artist string `json:"artist" example:"Rihanna"`
+	Role   string `json:"role" example:"featured"`
+}
+
+// Трек альбома: без номера песня встаёт в конец диска, диск по умолчанию первый
+type TrackInput struct {
+	SongID      int `json:"song_id" example:"1"`
+	TrackNumber int `json:"track_number" example:"3"`
+	DiscNumber  int `json:"disc_number" example:"1"`
+}
+
+// Перевод целиком: части разделяются пустой строкой, как в оригинале
+type TranslationInput struct {
+	Lang string `json:"lang" example:"en"`
+	Text string `json:"text" example:"Some legends are told\nSome turn to dust or to gold"`
+}
+
+type LogLevel struct {
+	Level string `json:"level" example:"debug"`
+}
+
 // Ответы
 
 type SongsList struct {
@@ -58,4 +500,96 @@ type SongsList struct {
 	TotalCount int64  `json:"total_count" example:"100"`
 	Page       int    `json:"page" example:"1"`
 	Limit      int    `json:"limit" example:"10"`
+	// Число песен по жанрам и тегам среди всех найденных, без учёта пагинации
+	Facets *Facets `json:"facets,omitempty"`
+}
+
+type Facets struct {
+	Genres []FacetCount `json:"genres"`
+	Tags   []FacetCount `json:"tags"`
+}
+
+// Жанр считается вместе с поджанрами
+type FacetCount struct {
+	ID       int    `json:"id" example:"1"`
+	Name     string `json:"name" example:"Rock"`
+	Slug     string `json:"slug,omitempty" example:"rock"`
+	ParentID *int   `json:"parent_id,omitempty"`
+	Count    int64  `json:"count" example:"120"`
+}
+
+type ExternalAPIResponsesList struct {
+	Data       []ExternalAPIResponse `json:"data"`
+	TotalCount int64                 `json:"total_count" example:"100"`
+	Page       int                   `json:"page" example:"1"`
+	Limit      int                   `json:"limit" example:"10"`
+}
+
+// Граф версий песни: песни и связи между ними
+type SongVersions struct {
+	// Версии, которые сами ничьей версией не являются
+	Originals []int          `json:"originals" example:"1"`
+	Songs     []Song         `json:"songs"`
+	Relations []SongRelation `json:"relations"`
+}
+
+type PlaylistsList struct {
+	Data       []Playlist `json:"data"`
+	TotalCount int64      `json:"total_count" example:"100"`
+	Page       int        `json:"page" example:"1"`
+	Limit      int        `json:"limit" example:"10"`
+}
+
+type SetlistsList struct {
+	Data       []Setlist `json:"data"`
+	TotalCount int64     `json:"total_count" example:"100"`
+	Page       int       `json:"page" example:"1"`
+	Limit      int       `json:"limit" example:"10"`
+}
+
+type AlbumsList struct {
+	Data       []Album `json:"data"`
+	TotalCount int64   `json:"total_count" example:"100"`
+	Page       int     `json:"page" example:"1"`
+	Limit      int     `json:"limit" example:"10"`
+}
+
+type SongProposalsList struct {
+	Data       []SongProposal `json:"data"`
+	TotalCount int64          `json:"total_count" example:"100"`
+	Page       int            `json:"page" example:"1"`
+	Limit      int            `json:"limit" example:"10"`
+}
+
+// ActiveLine - строка, которая звучит в позиции воспроизведения, и следующая за ней
+type ActiveLine struct {
+	PositionMs int64      `json:"position_ms" example:"13000"`
+	Line       *LyricLine `json:"line"`
+	Next       *LyricLine `json:"next"`
+}
+
+// Часть текста рядом с переводом
+type PairedVerse struct {
+	ID          int    `json:"id" example:"1"`
+	Order       int    `json:"order" example:"1"`
+	Type        string `json:"type" example:"verse"`
+	Label       string `json:"label,omitempty" example:"Verse 1"`
+	Verse       string `json:"verse" example:"Некоторые легенды рассказаны"`
+	Lang        string `json:"lang" example:"en"`
+	Translation string `json:"translation" example:"Some legends are told"`
+}
+
+type PurgedCount struct {
+	Deleted int64 `json:"deleted" example:"3"`
+}
+
+type HealthCheck struct {
+	Status     string `json:"status" example:"ok"`
+	DurationMs int64  `json:"duration_ms" example:"3"`
+	Detail     string `json:"detail,omitempty" example:"schema version 1"`
+}
+
+type HealthStatus struct {
+	Status string                 `json:"status" example:"ok"`
+	Checks map[string]HealthCheck `json:"checks,omitempty"`
 }
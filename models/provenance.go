@@ -0,0 +1,49 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// Источники, которые не являются провайдерами
+const (
+	ProvenanceManual = "manual"
+	ProvenanceImport = "import"
+	ProvenanceTags   = "tags"
+)
+
+// Provenance хранит, откуда взято каждое поле песни
+type Provenance map[string]string
+
+// Set отмечает источник для списка полей
+func (p *Provenance) Set(source string, fields ...string) {
+	if *p == nil {
+		*p = Provenance{}
+	}
+	for _, field := range fields {
+		(*p)[field] = source
+	}
+}
+
+func (p Provenance) Value() (driver.Value, error) {
+	if p == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(p)
+	return string(data), err
+}
+
+func (p *Provenance) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*p = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, p)
+	case string:
+		return json.Unmarshal([]byte(v), p)
+	default:
+		return fmt.Errorf("неподдерживаемый тип provenance: %T", value)
+	}
+}
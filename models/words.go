@@ -0,0 +1,24 @@
+package models
+
+import "database/sql/driver"
+
+// WordTiming - слово строки со временем начала
+type WordTiming struct {
+	StartMs int64  `json:"start_ms" example:"12000"`
+	Text    string `json:"text" example:"Some"`
+}
+
+// WordTimings хранится в jsonb, пустой список пишется как NULL
+type WordTimings []WordTiming
+
+func (w WordTimings) Value() (driver.Value, error) {
+	return jsonValue(w, len(w) == 0)
+}
+
+func (w *WordTimings) Scan(value any) error {
+	if value == nil {
+		*w = nil
+		return nil
+	}
+	return jsonScan(value, w, "таймингов слов")
+}
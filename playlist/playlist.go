@@ -0,0 +1,97 @@
+package playlist
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// Track - песня плейлиста для экспорта
+type Track struct {
+	Title    string
+	Creator  string
+	Album    string
+	TrackNum int
+	// Ссылка на песню, без неё трек в экспорт не попадает
+	Location string
+	// 0, если длительность неизвестна
+	DurationMs int64
+}
+
+// FormatM3U8 собирает расширенный M3U в UTF-8
+func FormatM3U8(title string, tracks []Track) string {
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+	if title != "" {
+		b.WriteString("#PLAYLIST:" + oneLine(title) + "\n")
+	}
+
+	for _, track := range tracks {
+		if track.Location == "" {
+			continue
+		}
+
+		// В M3U длительность в секундах, -1 - неизвестна
+		duration := int64(-1)
+		if track.DurationMs > 0 {
+			duration = (track.DurationMs + 500) / 1000
+		}
+		name := oneLine(track.Title)
+		if track.Creator != "" {
+			name = oneLine(track.Creator) + " - " + name
+		}
+
+		fmt.Fprintf(&b, "#EXTINF:%d,%s\n%s\n", duration, name, track.Location)
+	}
+
+	return b.String()
+}
+
+func oneLine(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
+
+// https://xspf.org/spec
+type xspfPlaylist struct {
+	XMLName xml.Name `xml:"http://xspf.org/ns/0/ playlist"`
+	Version int      `xml:"version,attr"`
+	Title   string   `xml:"title,omitempty"`
+	Creator string   `xml:"creator,omitempty"`
+	// trackList обязателен даже пустой
+	TrackList struct {
+		Tracks []xspfTrack `xml:"track"`
+	} `xml:"trackList"`
+}
+
+type xspfTrack struct {
+	Location string `xml:"location"`
+	Title    string `xml:"title,omitempty"`
+	Creator  string `xml:"creator,omitempty"`
+	Album    string `xml:"album,omitempty"`
+	TrackNum int    `xml:"trackNum,omitempty"`
+	Duration int64  `xml:"duration,omitempty"`
+}
+
+// FormatXSPF собирает плейлист XSPF
+func FormatXSPF(title, creator string, tracks []Track) ([]byte, error) {
+	doc := xspfPlaylist{Version: 1, Title: title, Creator: creator}
+	for _, track := range tracks {
+		if track.Location == "" {
+			continue
+		}
+		doc.TrackList.Tracks = append(doc.TrackList.Tracks, xspfTrack{
+			Location: track.Location,
+			Title:    track.Title,
+			Creator:  track.Creator,
+			Album:    track.Album,
+			TrackNum: track.TrackNum,
+			Duration: track.DurationMs,
+		})
+	}
+
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(body, '\n')...), nil
+}
@@ -0,0 +1,110 @@
+package providers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// catalogueRecord совпадает со строкой экспорта, поэтому вывод команды export
+// можно сразу подключить как каталог
+type catalogueRecord struct {
+	Group       string `json:"group"`
+	Song        string `json:"song"`
+	ReleaseDate string `json:"release_date"`
+	Link        string `json:"link"`
+	Text        string `json:"text"`
+	Album       *struct {
+		Title       string `json:"title"`
+		ReleaseDate string `json:"release_date"`
+		Type        string `json:"type"`
+		TrackNumber int    `json:"track_number"`
+		DiscNumber  int    `json:"disc_number"`
+	} `json:"album"`
+}
+
+// File - локальный каталог в формате JSON Lines, читается целиком при запуске
+type File struct {
+	name    string
+	entries map[string]Detail
+}
+
+func NewFile(name, path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть каталог %s: %w", path, err)
+	}
+	defer f.Close()
+
+	p := &File{name: name, entries: make(map[string]Detail)}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+
+		raw := strings.TrimSpace(scanner.Text())
+		if raw == "" {
+			continue
+		}
+
+		var record catalogueRecord
+		if err := json.Unmarshal([]byte(raw), &record); err != nil {
+			return nil, fmt.Errorf("каталог %s, строка %d: %w", path, line, err)
+		}
+		detail := Detail{
+			ReleaseDate: record.ReleaseDate,
+			Text:        record.Text,
+			Link:        record.Link,
+		}
+		if record.Album != nil {
+			detail.Album = &Album{
+				Title:       record.Album.Title,
+				ReleaseDate: record.Album.ReleaseDate,
+				Type:        record.Album.Type,
+				TrackNumber: record.Album.TrackNumber,
+				DiscNumber:  record.Album.DiscNumber,
+			}
+		}
+		p.entries[catalogueKey(record.Group, record.Song)] = detail
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("не удалось прочитать каталог %s: %w", path, err)
+	}
+
+	logger.Entry().WithField("provider", name).WithField("songs", len(p.entries)).Info("Каталог загружен") // Info-лог
+
+	return p, nil
+}
+
+func (p *File) Name() string {
+	return p.name
+}
+
+func (p *File) Local() bool {
+	return true
+}
+
+func (p *File) Lookup(_ context.Context, group, song string) (Detail, error) {
+	detail, ok := p.entries[catalogueKey(group, song)]
+	if !ok {
+		return detail, &Error{Provider: p.name, StatusCode: http.StatusNotFound, Err: errors.New("песня не найдена в каталоге")}
+	}
+	return detail, nil
+}
+
+func catalogueKey(group, song string) string {
+	return NormalizeKey(group) + "\x00" + NormalizeKey(song)
+}
+
+// NormalizeKey приводит название к виду, в котором "Muse " и "muse" совпадают
+func NormalizeKey(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
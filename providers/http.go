@@ -0,0 +1,62 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// HTTP - провайдер с протоколом внешнего API: GET {addr}/info?group=...&song=...
+type HTTP struct {
+	name   string
+	addr   string
+	client *http.Client
+}
+
+func NewHTTP(name, addr string, client *http.Client) *HTTP {
+	return &HTTP{name: name, addr: addr, client: client}
+}
+
+func (p *HTTP) Name() string {
+	return p.name
+}
+
+func (p *HTTP) Lookup(ctx context.Context, group, song string) (Detail, error) {
+	log := logger.Ctx(ctx).WithField("prefix", "HTTP").WithField("provider", p.name)
+
+	var detail Detail
+
+	log.Info("Делаем запрос к внешнему API") // Info-лог
+
+	apiURL := fmt.Sprintf("%s/info?group=%s&song=%s", p.addr, url.QueryEscape(group), url.QueryEscape(song))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return detail, &Error{Provider: p.name, Err: fmt.Errorf("не удалось сформировать запрос к внешнему API: %s", err)}
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+
+		log.Info("Не удалось получить положительный ответ от внешнего API") // Info-лог
+
+		return detail, &Error{Provider: p.name, Err: fmt.Errorf("не удалось получить положительный ответ от внешнего API: %s", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+
+		log.WithField("status_code", resp.StatusCode).Error("Внешний API вернул ошибку")
+
+		return detail, &Error{Provider: p.name, StatusCode: resp.StatusCode, Err: fmt.Errorf("внешний API вернул ошибку: %d", resp.StatusCode)}
+	}
+
+	log.Info("Парсинг ответа от внешнего API") // Info-лог
+
+	if err := json.NewDecoder(resp.Body).Decode(&detail); err != nil {
+		return detail, &Error{Provider: p.name, StatusCode: resp.StatusCode, Err: fmt.Errorf("не удалось распарсить ответ от внешнего API: %s", err)}
+	}
+
+	return detail, nil
+}
@@ -0,0 +1,106 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"songLibrary/initializers"
+	"songLibrary/logging"
+)
+
+var logger = logging.New("providers")
+
+// Поля песни, которые заполняют провайдеры
+const (
+	FieldReleaseDate = "release_date"
+	FieldLink        = "link"
+	FieldLyrics      = "lyrics"
+	FieldAlbum       = "album"
+)
+
+// Detail - данные о песне от провайдера, формат совпадает с ответом /info
+type Detail struct {
+	ReleaseDate string `json:"releaseDate"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+	// Необязательное поле: провайдер может не знать альбом
+	Album *Album `json:"album,omitempty"`
+}
+
+// Album - альбом песни в ответе /info
+type Album struct {
+	Title       string `json:"title"`
+	ReleaseDate string `json:"releaseDate"`
+	Type        string `json:"type"`
+	TrackNumber int    `json:"trackNumber"`
+	DiscNumber  int    `json:"discNumber"`
+}
+
+// Fields возвращает значения по именам полей, для альбома - название
+func (d Detail) Fields() map[string]string {
+	fields := map[string]string{
+		FieldReleaseDate: d.ReleaseDate,
+		FieldLink:        d.Link,
+		FieldLyrics:      d.Text,
+		FieldAlbum:       "",
+	}
+	if d.Album != nil {
+		fields[FieldAlbum] = d.Album.Title
+	}
+	return fields
+}
+
+// Empty - провайдер ничего полезного не вернул
+func (d Detail) Empty() bool {
+	return d.ReleaseDate == "" && d.Text == "" && d.Link == "" && (d.Album == nil || d.Album.Title == "")
+}
+
+// Error - ошибка провайдера. StatusCode равен 0, если ответ не был получен,
+// иначе это HTTP-код (или его аналог, например 404 для отсутствующей в каталоге песни).
+type Error struct {
+	Provider   string
+	StatusCode int
+	Err        error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Provider ищет данные о песне по группе и названию
+type Provider interface {
+	Name() string
+	Lookup(ctx context.Context, group, song string) (Detail, error)
+}
+
+// Local - провайдер без сетевых запросов, его ответы незачем сохранять в БД
+type Local interface {
+	Local() bool
+}
+
+// New создаёт провайдеров в порядке приоритета. Без явного списка используется
+// один HTTP-провайдер с адресом external_api.addr.
+func New(config initializers.ExternalAPIConfig) ([]Provider, error) {
+	configs := config.ProviderConfigs()
+
+	list := make([]Provider, 0, len(configs))
+	for _, pc := range configs {
+		switch pc.Type {
+		case initializers.ProviderTypeHTTP:
+			list = append(list, NewHTTP(pc.Name, pc.Addr, initializers.HTTPClient))
+		case initializers.ProviderTypeFile:
+			file, err := NewFile(pc.Name, pc.Path)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, file)
+		default:
+			return nil, fmt.Errorf("неизвестный тип провайдера %s: %s", pc.Name, pc.Type)
+		}
+	}
+
+	return list, nil
+}
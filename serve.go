@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	stdlog "log"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"songLibrary/blobstore"
+	"songLibrary/cache"
+	"songLibrary/handlers"
+	"songLibrary/initializers"
+	"songLibrary/lifecycle"
+	"songLibrary/logging"
+	"songLibrary/services"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
+)
+
+func runServe(args []string) int {
+	// Инициализация config
+	config, err := newCommandFlags("serve").parse(args)
+	if err != nil {
+		return reportConfigError(err)
+	}
+	serverConfig := config.Server
+
+	// Формат и уровни логов, в debug-режиме уровень по умолчанию debug
+	if err := logging.Setup(config.Log); err != nil {
+		log.Fatal(err)
+	}
+	if serverConfig.DebugMode {
+		log.Debug("Debug-режим активирован")
+	}
+
+	if out, err := config.YAML(); err == nil {
+		log.WithField("config", string(out)).Debug("Итоговый конфиг") // Debug-лог
+	}
+
+	initializers.HTTPClient.Timeout = config.ExternalAPI.Timeout
+
+	// Жизненный цикл: воркеры и освобождение ресурсов
+	app := lifecycle.New()
+
+	// Трассировка
+	shutdownTracing, err := initializers.InitTracing(serverConfig.Tracing)
+	if err != nil {
+		log.Fatal("Не удалось инициализировать трассировку: " + err.Error())
+	}
+	// Спаны сбрасываем последними, после закрытия БД
+	app.OnShutdown("tracing", shutdownTracing)
+
+	// Автомиграция
+	if err := initializers.Migrate(config.DB); err != nil {
+		log.Fatal(err)
+	}
+	app.OnShutdown("database", initializers.CloseDB)
+
+	// Echo
+	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+	e.Logger.SetOutput(logging.New("echo").Writer())
+
+	log.Info("Регистрируем middleware") // Info-лог
+
+	skipInfra := func(c echo.Context) bool {
+		return strings.HasPrefix(c.Path(), "/api/v1/doc") || c.Path() == "/healthz" || c.Path() == "/readyz"
+	}
+
+	e.Use(middleware.Recover())
+	e.Use(logging.RequestID(), logging.Middleware(), logging.AccessLog(skipInfra))
+	// У загрузки файлов свои пределы, они задаются на маршрутах
+	e.Use(middleware.BodyLimitWithConfig(middleware.BodyLimitConfig{
+		Limit:   serverConfig.HTTP.BodyLimit,
+		Skipper: func(c echo.Context) bool { return slices.Contains(uploadPaths, c.Path()) },
+	}))
+	e.Use(otelecho.Middleware(serverConfig.Tracing.ServiceName, otelecho.WithSkipper(skipInfra)))
+
+	var responseCache cache.Cache = cache.Noop{}
+	if config.Cache.Enabled {
+		responseCache = cache.NewMemory(config.Cache.Size, config.Cache.TTL)
+	}
+
+	store, err := blobstore.New(config.Media)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	library, err := services.NewLibrary(initializers.DB, config.ExternalAPI, config.Explicit, responseCache, store)
+	if err != nil {
+		log.Fatal("Не удалось подготовить провайдеров: " + err.Error())
+	}
+
+	if config.Refresh.Enabled {
+		app.Go(services.NewRefreshWorker(library, config.Refresh))
+	}
+	if config.LinkCheck.Enabled {
+		app.Go(services.NewLinkCheckWorker(library, config.LinkCheck))
+	}
+
+	// Песенники из API собираются только здесь, поэтому воркер работает всегда
+	app.Go(services.NewSongbookWorker(library, config.Songbook))
+
+	h := handlers.NewHandler(library, config.Songbook, config.AudioImport, config.Media)
+	health := handlers.NewHealthHandler(serverConfig.Health, config.ExternalAPI)
+
+	log.Info("Регистрируем handlers") // Info-лог
+
+	registerHandlers(e, h, health, config.AudioImport, config.Media)
+
+	server := &http.Server{
+		Addr:              fmt.Sprintf("0.0.0.0:%v", serverConfig.Port),
+		ReadTimeout:       serverConfig.HTTP.ReadTimeout,
+		ReadHeaderTimeout: serverConfig.HTTP.ReadHeaderTimeout,
+		WriteTimeout:      serverConfig.HTTP.WriteTimeout,
+		IdleTimeout:       serverConfig.HTTP.IdleTimeout,
+		MaxHeaderBytes:    serverConfig.HTTP.MaxHeaderBytes,
+		ErrorLog:          stdlog.New(logging.New("http").Writer(), "", 0),
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Info("Запускаем сервер") // Info-лог
+
+		if err := e.StartServer(server); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+		close(serverErr)
+	}()
+
+	exitCode := exitOK
+
+	select {
+	case <-ctx.Done():
+		log.Info("Получен сигнал остановки") // Info-лог
+	case err := <-serverErr:
+		log.WithError(err).Error("Сервер остановился с ошибкой")
+		exitCode = exitFailure
+	}
+
+	// Повторный сигнал завершает процесс без ожидания
+	stop()
+
+	health.MarkShuttingDown()
+
+	log.WithField("ShutdownDelay", serverConfig.HTTP.ShutdownDelay).Debug("Ждём, пока балансировщик увидит отказ readiness") // Debug-лог
+
+	time.Sleep(serverConfig.HTTP.ShutdownDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serverConfig.HTTP.ShutdownTimeout)
+
+	log.Info("Дожидаемся завершения текущих запросов") // Info-лог
+
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		log.WithError(err).Error("Не удалось корректно остановить HTTP-сервер")
+		exitCode = exitFailure
+	}
+
+	if err := app.Shutdown(shutdownCtx); err != nil {
+		log.WithError(err).Error("Ошибка при освобождении ресурсов")
+		exitCode = exitFailure
+	}
+
+	cancel()
+
+	log.Info("Сервер остановлен") // Info-лог
+
+	return exitCode
+}
@@ -0,0 +1,368 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"songLibrary/cache"
+	"songLibrary/models"
+	"songLibrary/providers"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAlbumNotFound = errors.New("альбом не найден")
+	ErrAlbumExists   = errors.New("у группы уже есть альбом с таким названием")
+	ErrInvalidAlbum  = errors.New("некорректный альбом")
+	ErrTrackTaken    = errors.New("номер трека на диске уже занят")
+)
+
+var albumTypes = []string{models.AlbumTypeLP, models.AlbumTypeEP, models.AlbumTypeSingle, models.AlbumTypeCompilation}
+
+// AlbumFilter - фильтры списка альбомов, group и title ищутся по подстроке
+type AlbumFilter struct {
+	Group string
+	Title string
+	Type  string
+}
+
+// validateAlbum проверяет ввод и подставляет тип lp по умолчанию
+func validateAlbum(input *models.AlbumInput) error {
+	input.Title = strings.TrimSpace(input.Title)
+	input.Group = strings.TrimSpace(input.Group)
+	input.Type = strings.ToLower(strings.TrimSpace(input.Type))
+
+	if input.Type == "" {
+		input.Type = models.AlbumTypeLP
+	}
+	if !slices.Contains(albumTypes, input.Type) {
+		return fmt.Errorf("%w: тип должен быть одним из %s", ErrInvalidAlbum, strings.Join(albumTypes, ", "))
+	}
+	if len(input.Title) < 1 || len(input.Title) > 255 {
+		return fmt.Errorf("%w: название пустое или слишком длинное", ErrInvalidAlbum)
+	}
+	if input.Group == "" && input.Type != models.AlbumTypeCompilation {
+		return fmt.Errorf("%w: группа необязательна только для сборника", ErrInvalidAlbum)
+	}
+	if len(input.Group) > 60 {
+		return fmt.Errorf("%w: название группы слишком длинное", ErrInvalidAlbum)
+	}
+	if input.ReleaseDate != "" {
+		if _, err := time.Parse("02.01.2006", input.ReleaseDate); err != nil {
+			return fmt.Errorf("%w: release_date должен быть в формате dd.MM.yyyy", ErrInvalidAlbum)
+		}
+	}
+	return nil
+}
+
+// fillAlbum переносит ввод в альбом, группа ищется по имени и создаётся при необходимости
+func (l *Library) fillAlbum(ctx context.Context, tx *gorm.DB, album *models.Album, input models.AlbumInput) error {
+	album.Title = input.Title
+	album.ReleaseDate = input.ReleaseDate
+	album.Type = input.Type
+	album.GroupID = nil
+
+	if input.Group != "" {
+		group, err := l.FindOrCreateGroup(ctx, tx, input.Group)
+		if err != nil {
+			return err
+		}
+		album.GroupID = &group.ID
+	}
+
+	query := tx.Model(&models.Album{}).Where("LOWER(title) = LOWER(?) AND id <> ?", album.Title, album.ID)
+	if album.GroupID != nil {
+		query = query.Where("group_id = ?", *album.GroupID)
+	} else {
+		query = query.Where("group_id IS NULL")
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrAlbumExists
+	}
+	return nil
+}
+
+// CreateAlbum создаёт альбом
+func (l *Library) CreateAlbum(ctx context.Context, input models.AlbumInput) (models.Album, error) {
+	log := logger.Ctx(ctx).WithField("prefix", "CreateAlbum")
+
+	var album models.Album
+	if err := validateAlbum(&input); err != nil {
+		return album, err
+	}
+
+	log.WithField("title", input.Title).Info("Создаём альбом") // Info-лог
+
+	err := l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := l.fillAlbum(ctx, tx, &album, input); err != nil {
+			return err
+		}
+		return tx.Create(&album).Error
+	})
+	return album, err
+}
+
+// GetAlbum возвращает альбом с треками по порядку дисков и номеров
+func (l *Library) GetAlbum(ctx context.Context, albumID int) (models.Album, error) {
+	var album models.Album
+	err := l.DB(ctx).Preload("Songs", func(db *gorm.DB) *gorm.DB {
+		return db.Order("disc_number NULLS LAST, track_number NULLS LAST, id")
+	}).Preload("Songs.Cover").First(&album, albumID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return album, ErrAlbumNotFound
+	}
+	return album, err
+}
+
+// ListAlbums возвращает альбомы по фильтрам
+func (l *Library) ListAlbums(ctx context.Context, filter AlbumFilter, page, limit int) (models.AlbumsList, error) {
+	result := models.AlbumsList{Page: page, Limit: limit}
+
+	query := l.DB(ctx).Model(&models.Album{})
+	if filter.Group != "" {
+		query = query.Joins("JOIN groups ON groups.id = albums.group_id").Where("LOWER(groups.name) LIKE LOWER(?)", "%"+filter.Group+"%")
+	}
+	if filter.Title != "" {
+		query = query.Where("LOWER(albums.title) LIKE LOWER(?)", "%"+filter.Title+"%")
+	}
+	if filter.Type != "" {
+		query = query.Where("albums.type = ?", strings.ToLower(filter.Type))
+	}
+
+	if err := query.Count(&result.TotalCount).Error; err != nil {
+		return result, err
+	}
+
+	err := query.Order("albums.id").Offset((page - 1) * limit).Limit(limit).Find(&result.Data).Error
+	return result, err
+}
+
+// UpdateAlbum заменяет поля альбома
+func (l *Library) UpdateAlbum(ctx context.Context, albumID int, input models.AlbumInput) (models.Album, error) {
+	var album models.Album
+	if err := validateAlbum(&input); err != nil {
+		return album, err
+	}
+
+	err := l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&album, albumID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAlbumNotFound
+			}
+			return err
+		}
+		if err := l.fillAlbum(ctx, tx, &album, input); err != nil {
+			return err
+		}
+		return tx.Save(&album).Error
+	})
+	if err != nil {
+		return album, err
+	}
+
+	l.invalidateSongs(ctx, l.albumSongIDs(ctx, albumID))
+
+	return album, nil
+}
+
+// DeleteAlbum удаляет альбом, песни остаются без альбома
+func (l *Library) DeleteAlbum(ctx context.Context, albumID int) error {
+	log := logger.Ctx(ctx).WithField("prefix", "DeleteAlbum")
+
+	// Песни запоминаем до отвязки, чтобы потом сбросить их кеш
+	songIDs := l.albumSongIDs(ctx, albumID)
+
+	err := l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Delete(&models.Album{}, albumID)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrAlbumNotFound
+		}
+
+		detached := tx.Model(&models.Song{}).Where("album_id = ?", albumID).
+			Updates(map[string]any{"album_id": nil, "track_number": nil, "disc_number": nil})
+		if detached.Error != nil {
+			return detached.Error
+		}
+
+		log.WithField("songs", detached.RowsAffected).Info("Альбом удалён, песни отвязаны") // Info-лог
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	l.invalidateSongs(ctx, songIDs)
+
+	return nil
+}
+
+// AttachSong добавляет песню в альбом или меняет её место. Без номера трека песня
+// встаёт в конец диска. Песня другой группы допускается только в сборнике.
+func (l *Library) AttachSong(ctx context.Context, albumID int, input models.TrackInput) (models.Song, error) {
+	log := logger.Ctx(ctx).WithField("prefix", "AttachSong")
+
+	var song models.Song
+
+	if input.TrackNumber < 0 || input.DiscNumber < 0 {
+		return song, fmt.Errorf("%w: номера трека и диска не могут быть отрицательными", ErrInvalidAlbum)
+	}
+	disc := max(input.DiscNumber, 1)
+
+	err := l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		var album models.Album
+		if err := tx.First(&album, albumID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAlbumNotFound
+			}
+			return err
+		}
+		if err := tx.First(&song, input.SongID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrSongNotFound
+			}
+			return err
+		}
+
+		if album.Type != models.AlbumTypeCompilation && album.GroupID != nil && *album.GroupID != song.GroupID {
+			return fmt.Errorf("%w: песня другой группы, добавить её можно только в сборник", ErrInvalidAlbum)
+		}
+
+		track := input.TrackNumber
+		if track == 0 {
+			var last *int
+			err := tx.Model(&models.Song{}).Select("MAX(track_number)").
+				Where("album_id = ? AND disc_number = ? AND id <> ?", albumID, disc, song.ID).Scan(&last).Error
+			if err != nil {
+				return err
+			}
+			track = 1
+			if last != nil {
+				track = *last + 1
+			}
+		} else {
+			var taken int64
+			err := tx.Model(&models.Song{}).
+				Where("album_id = ? AND disc_number = ? AND track_number = ? AND id <> ?", albumID, disc, track, song.ID).Count(&taken).Error
+			if err != nil {
+				return err
+			}
+			if taken > 0 {
+				return ErrTrackTaken
+			}
+		}
+
+		log.WithField("track", track).WithField("disc", disc).Info("Добавляем песню в альбом") // Info-лог
+
+		song.AlbumID, song.TrackNumber, song.DiscNumber = &albumID, &track, &disc
+		return tx.Model(&song).Select("album_id", "track_number", "disc_number").Updates(&song).Error
+	})
+	if err != nil {
+		return song, err
+	}
+
+	l.InvalidateSong(ctx, song.ID, song.GroupID)
+
+	return song, nil
+}
+
+// DetachSong убирает песню из альбома
+func (l *Library) DetachSong(ctx context.Context, albumID, songID int) error {
+	res := l.DB(ctx).Model(&models.Song{}).Where("id = ? AND album_id = ?", songID, albumID).
+		Updates(map[string]any{"album_id": nil, "track_number": nil, "disc_number": nil})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrSongNotFound
+	}
+
+	l.InvalidateSong(ctx, songID)
+
+	return nil
+}
+
+// albumSongIDs - песни альбома, для сброса кеша
+func (l *Library) albumSongIDs(ctx context.Context, albumID int) []int {
+	var songIDs []int
+	if err := l.DB(ctx).Model(&models.Song{}).Where("album_id = ?", albumID).Pluck("id", &songIDs).Error; err != nil {
+		logger.Ctx(ctx).WithError(err).Warn("Не удалось получить песни альбома для сброса кеша") // Warn-лог
+	}
+	return songIDs
+}
+
+// invalidateSongs сбрасывает кеш списков и перечисленных песен
+func (l *Library) invalidateSongs(ctx context.Context, songIDs []int) {
+	tags := []string{cache.TagSongsList}
+	for _, id := range songIDs {
+		tags = append(tags, cache.TagSong(id))
+	}
+	l.cache.InvalidateTags(ctx, tags...)
+}
+
+// attachProviderAlbum привязывает песню без альбома к альбому из ответа провайдера.
+// Альбом ищется у группы песни по названию и создаётся, если его нет.
+// Номер трека ставится, только если он свободен. Значения провайдера приводятся к
+// ограничениям колонок: ошибка вставки альбома отменила бы добавление всей песни.
+func attachProviderAlbum(tx *gorm.DB, song *models.Song, album *providers.Album) error {
+	if album == nil || song.AlbumID != nil {
+		return nil
+	}
+	title := truncate(strings.TrimSpace(album.Title), 255)
+	if title == "" {
+		return nil
+	}
+	log := logger.Ctx(tx.Statement.Context).WithField("prefix", "attachProviderAlbum")
+
+	var found models.Album
+	err := tx.Where("group_id = ? AND LOWER(title) = LOWER(?)", song.GroupID, title).First(&found).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		albumType := strings.ToLower(album.Type)
+		if !slices.Contains(albumTypes, albumType) {
+			albumType = models.AlbumTypeLP
+		}
+		releaseDate := album.ReleaseDate
+		if _, err := time.Parse("02.01.2006", releaseDate); err != nil {
+			releaseDate = ""
+		}
+		groupID := song.GroupID
+		found = models.Album{GroupID: &groupID, Title: title, ReleaseDate: releaseDate, Type: albumType}
+
+		log.WithField("title", title).Info("Создаём альбом из ответа провайдера") // Info-лог
+
+		err = tx.Create(&found).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	disc := max(album.DiscNumber, 1)
+	song.AlbumID, song.DiscNumber = &found.ID, &disc
+
+	if album.TrackNumber > 0 {
+		var taken int64
+		err := tx.Model(&models.Song{}).
+			Where("album_id = ? AND disc_number = ? AND track_number = ? AND id <> ?", found.ID, disc, album.TrackNumber, song.ID).Count(&taken).Error
+		if err != nil {
+			return err
+		}
+		if taken == 0 {
+			track := album.TrackNumber
+			song.TrackNumber = &track
+		}
+	}
+
+	return tx.Model(song).Select("album_id", "track_number", "disc_number").Updates(song).Error
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"songLibrary/audiotags"
+	"songLibrary/lyrics"
+	"songLibrary/models"
+	"songLibrary/providers"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// AudioFile - аудиофайл для импорта: файл на диске или загруженный в запросе
+type AudioFile struct {
+	Name string
+	Open func() (io.ReadSeekCloser, error)
+}
+
+// AudioConflict - файл, песня из которого уже есть в библиотеке, но теги с ней расходятся
+type AudioConflict struct {
+	File   string               `json:"file" example:"Muse/Black Holes and Revelations/03.mp3"`
+	SongID int                  `json:"song_id" example:"1"`
+	Group  string               `json:"group" example:"Muse"`
+	Song   string               `json:"song" example:"Supermassive Black Hole"`
+	Fields []AudioFieldConflict `json:"fields"`
+}
+
+type AudioFieldConflict struct {
+	Field  string `json:"field" example:"release_date"`
+	Stored string `json:"stored" example:"16.07.2006"`
+	Tags   string `json:"tags" example:"19.06.2006"`
+}
+
+type AudioImportResult struct {
+	Created int `json:"created"`
+	// Песня уже есть, в том числе с расхождениями из conflicts
+	Skipped   int             `json:"skipped"`
+	Failed    int             `json:"failed"`
+	Errors    []string        `json:"errors,omitempty"`
+	Conflicts []AudioConflict `json:"conflicts,omitempty"`
+}
+
+// Сколько символов значения показывать в расхождении
+const conflictValueLength = 100
+
+// ImportAudio создаёт песни из тегов аудиофайлов. Песни, для которых в тегах нет текста,
+// дозапрашиваются у провайдеров, если fetchMissing; значения из тегов важнее ответа провайдера.
+func (l *Library) ImportAudio(ctx context.Context, files []AudioFile, fetchMissing bool) AudioImportResult {
+	log := logger.Ctx(ctx).WithField("prefix", "ImportAudio")
+
+	log.WithField("count", len(files)).Info("Импортируем песни из тегов") // Info-лог
+
+	var result AudioImportResult
+	for _, file := range files {
+		if ctx.Err() != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %s", file.Name, ctx.Err()))
+			continue
+		}
+
+		conflict, err := l.importAudioFile(ctx, file, fetchMissing)
+		switch {
+		case errors.Is(err, ErrSongExists):
+			result.Skipped++
+			if conflict != nil {
+				result.Conflicts = append(result.Conflicts, *conflict)
+			}
+		case err != nil:
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %s", file.Name, err))
+		default:
+			result.Created++
+		}
+	}
+
+	return result
+}
+
+// importAudioFile читает теги одного файла и создаёт песню тем же путём, что и AddSong.
+// Если песня уже есть, возвращает ErrSongExists и расхождения с тегами, если они нашлись.
+func (l *Library) importAudioFile(ctx context.Context, file AudioFile, fetchMissing bool) (*AudioConflict, error) {
+	log := logger.Ctx(ctx).WithField("prefix", "ImportAudio").WithField("file", file.Name)
+
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	track, err := audiotags.Read(f)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ValidateSongInput(track.Artist, track.Title); err != nil {
+		return nil, err
+	}
+
+	detail := SongDetail{ReleaseDate: track.ReleaseDate, Text: track.Lyrics}
+	if track.Album != "" {
+		detail.Album = &providers.Album{Title: track.Album, TrackNumber: track.TrackNumber, DiscNumber: track.DiscNumber}
+	}
+	// Синхронизированный текст тоже текст, провайдеры за ним не нужны
+	hasLyrics := track.Lyrics != "" || track.SyncedLyrics != ""
+
+	song, err := l.addSong(ctx, track.Artist, track.Title, func(ctx context.Context, groupName, title string) (SongDetail, models.Provenance, error) {
+		var provenance models.Provenance
+		provenance.Set(models.ProvenanceTags, filledFields(detail)...)
+
+		if !fetchMissing || hasLyrics {
+			return detail, provenance, nil
+		}
+
+		found, foundProvenance, err := l.LookupSongDetail(ctx, groupName, title)
+		if err != nil {
+
+			log.WithError(err).Warn("Провайдеры не вернули данные, создаём песню только из тегов") // Warn-лог
+
+			return detail, provenance, nil
+		}
+		return mergeTagDetail(detail, provenance, found, foundProvenance), provenance, nil
+	})
+	if errors.Is(err, ErrSongExists) {
+		conflict, cerr := l.audioConflict(ctx, file.Name, song, track)
+		if cerr != nil {
+			return nil, cerr
+		}
+		return conflict, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if track.SyncedLyrics != "" {
+
+		log.Info("Импортируем синхронизированный текст") // Info-лог
+
+		if _, err := l.ImportLRC(ctx, song.ID, track.SyncedLyrics); err != nil {
+			return nil, fmt.Errorf("песня %d создана, но синхронизированный текст не импортирован: %w", song.ID, err)
+		}
+	}
+
+	return nil, nil
+}
+
+// mergeTagDetail дополняет данные из тегов пустыми у них полями из ответа провайдеров
+func mergeTagDetail(detail SongDetail, provenance models.Provenance, found SongDetail, foundProvenance models.Provenance) SongDetail {
+	if detail.ReleaseDate == "" && found.ReleaseDate != "" {
+		detail.ReleaseDate = found.ReleaseDate
+		provenance[providers.FieldReleaseDate] = foundProvenance[providers.FieldReleaseDate]
+	}
+	if detail.Link == "" && found.Link != "" {
+		detail.Link = found.Link
+		provenance[providers.FieldLink] = foundProvenance[providers.FieldLink]
+	}
+	if detail.Text == "" && found.Text != "" {
+		detail.Text = found.Text
+		provenance[providers.FieldLyrics] = foundProvenance[providers.FieldLyrics]
+	}
+	if detail.Album == nil && found.Album != nil {
+		detail.Album = found.Album
+		provenance[providers.FieldAlbum] = foundProvenance[providers.FieldAlbum]
+	}
+	return detail
+}
+
+// audioConflict сравнивает теги с сохранённой песней. Поле считается расхождением,
+// только если оно заполнено и в тегах, и в библиотеке.
+func (l *Library) audioConflict(ctx context.Context, name string, song models.Song, track audiotags.Track) (*AudioConflict, error) {
+	err := l.DB(ctx).Preload("Lyrics", func(db *gorm.DB) *gorm.DB {
+		return db.Order("\"order\"")
+	}).Preload("Album").First(&song, song.ID).Error
+	if err != nil {
+		return nil, err
+	}
+	var group models.Group
+	if err := l.DB(ctx).First(&group, song.GroupID).Error; err != nil {
+		return nil, err
+	}
+
+	conflict := AudioConflict{File: name, SongID: song.ID, Group: group.Name, Song: song.Title}
+	add := func(field, stored, tags string) {
+		conflict.Fields = append(conflict.Fields, AudioFieldConflict{Field: field, Stored: truncate(stored, conflictValueLength), Tags: truncate(tags, conflictValueLength)})
+	}
+
+	switch {
+	case song.ReleaseDate == "":
+	case track.ReleaseDate != "":
+		if track.ReleaseDate != song.ReleaseDate {
+			add(providers.FieldReleaseDate, song.ReleaseDate, track.ReleaseDate)
+		}
+	case track.Year > 0:
+		// Без полной даты в тегах сравниваем только год
+		if year := strconv.Itoa(track.Year); !strings.HasSuffix(song.ReleaseDate, "."+year) {
+			add(providers.FieldReleaseDate, song.ReleaseDate, year)
+		}
+	}
+
+	if track.Album != "" && song.Album != nil && !strings.EqualFold(track.Album, song.Album.Title) {
+		add(providers.FieldAlbum, song.Album.Title, track.Album)
+	}
+
+	if track.Lyrics != "" && len(song.Lyrics) > 0 {
+		stored := make([]string, 0, len(song.Lyrics))
+		for _, verse := range song.Lyrics {
+			stored = append(stored, verse.Verse)
+		}
+		storedText, tagText := compactText(stored), compactText(lyrics.Verses(track.Lyrics))
+		if !strings.EqualFold(storedText, tagText) {
+			add(providers.FieldLyrics, storedText, tagText)
+		}
+	}
+
+	if len(conflict.Fields) == 0 {
+		return nil, nil
+	}
+	return &conflict, nil
+}
+
+// compactText склеивает части текста в одну строку без лишних пробелов и переводов строк
+func compactText(verses []string) string {
+	return strings.Join(strings.Fields(strings.Join(verses, "\n")), " ")
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"songLibrary/chords"
+	"songLibrary/lyrics"
+	"songLibrary/models"
+	"songLibrary/providers"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var ErrNoChords = errors.New("у песни нет аккордов")
+
+// SetChordPro сохраняет исходник ChordPro и заменяет текст песни частями из него,
+// у каждой части - аккорды по строкам. Текст после этого считается правленым вручную.
+func (l *Library) SetChordPro(ctx context.Context, songID int, src string) ([]models.Lyrics, error) {
+	log := logger.Ctx(ctx).WithField("prefix", "SetChordPro")
+
+	sheet, err := chords.Parse(src)
+	if err != nil {
+		return nil, err
+	}
+
+	var song models.Song
+	if err := l.DB(ctx).First(&song, songID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSongNotFound
+		}
+		return nil, err
+	}
+
+	log.WithField("blocks", len(sheet.Blocks)).Info("Сохраняем ChordPro") // Info-лог
+
+	var verses []models.Lyrics
+	err = l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		song.ChordPro = src
+		song.Provenance.Set(models.ProvenanceManual, providers.FieldLyrics)
+		if err := tx.Save(&song).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("song_id = ?", songID).Delete(&models.Lyrics{}).Error; err != nil {
+			return err
+		}
+
+		verses, err = applyChordPro(tx, songID, sheet)
+		if err != nil {
+			return err
+		}
+		return l.FlagExplicit(tx, &song)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	l.InvalidateSong(ctx, song.ID, song.GroupID)
+
+	return verses, nil
+}
+
+// applyChordPro создаёт части текста из ChordPro и раскладывает по ним аккорды
+func applyChordPro(tx *gorm.DB, songID int, sheet chords.Sheet) ([]models.Lyrics, error) {
+	log := logger.Ctx(tx.Statement.Context).WithField("prefix", "applyChordPro")
+
+	sections := lyrics.Parse(sheet.LyricsText())
+
+	verses, err := createVerses(tx, songID, sections)
+	if err != nil {
+		return nil, err
+	}
+
+	sectionChords := sheet.SectionChords(sections)
+	if sectionChords == nil {
+
+		log.WithField("song.id", songID).Warn("Части текста не совпали с блоками ChordPro, аккорды в частях не сохранены") // Warn-лог
+
+		return verses, nil
+	}
+
+	for i := range verses {
+		if len(sectionChords[i]) == 0 {
+			continue
+		}
+
+		verseChords := make(models.VerseChords, 0, len(sectionChords[i]))
+		for _, chord := range sectionChords[i] {
+			verseChords = append(verseChords, models.VerseChord{Line: chord.Line, Pos: chord.Pos, Chord: chord.Chord})
+		}
+		if err := tx.Model(&verses[i]).Update("chords", verseChords).Error; err != nil {
+			return nil, err
+		}
+		verses[i].Chords = verseChords
+	}
+
+	return verses, nil
+}
+
+// ClearChordPro удаляет исходник ChordPro и аккорды частей, текст песни остаётся
+func (l *Library) ClearChordPro(ctx context.Context, songID int) error {
+	var song models.Song
+	if err := l.DB(ctx).First(&song, songID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSongNotFound
+		}
+		return err
+	}
+	if song.ChordPro == "" {
+		return ErrNoChords
+	}
+
+	err := l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&song).Update("chord_pro", "").Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Lyrics{}).Where("song_id = ?", songID).Update("chords", nil).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	l.InvalidateSong(ctx, song.ID, song.GroupID)
+
+	return nil
+}
+
+// ChordSheet возвращает разобранный ChordPro песни. Название и исполнитель,
+// если их нет в исходнике, берутся из библиотеки.
+func (l *Library) ChordSheet(ctx context.Context, songID int) (chords.Sheet, error) {
+	var song models.Song
+	if err := l.DB(ctx).First(&song, songID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return chords.Sheet{}, ErrSongNotFound
+		}
+		return chords.Sheet{}, err
+	}
+	if song.ChordPro == "" {
+		return chords.Sheet{}, ErrNoChords
+	}
+
+	sheet, err := chords.Parse(song.ChordPro)
+	if err != nil {
+		return sheet, err
+	}
+
+	if sheet.Title == "" {
+		sheet.Title = song.Title
+	}
+	if sheet.Artist == "" {
+		var group models.Group
+		if err := l.DB(ctx).Unscoped().First(&group, song.GroupID).Error; err != nil {
+			return sheet, err
+		}
+		sheet.Artist = group.Name
+	}
+
+	return sheet, nil
+}
+
+// songSheet - аккорды песни или, если ChordPro нет, её текст (свой или унаследованный
+// от оригинала) для печати. Аккорды транспонируются в key, если тональности песни и key
+// разобрались и отличаются. Пустой key оставляет тональность песни.
+func (l *Library) songSheet(ctx context.Context, song models.Song, key string) (chords.Sheet, error) {
+	sheet, err := l.ChordSheet(ctx, song.ID)
+	if err == nil {
+		if shift, ok := chords.KeyInterval(sheet.Key, key); ok && shift != 0 {
+			sheet = sheet.Transposed(chords.Options{Transpose: shift})
+		}
+		if sheet.Key == "" {
+			sheet.Key = key
+		}
+		return sheet, nil
+	}
+	if !errors.Is(err, ErrNoChords) {
+		return sheet, err
+	}
+
+	sourceID, err := l.LyricsSource(ctx, song.ID)
+	if err != nil {
+		return chords.Sheet{}, err
+	}
+
+	var verses []models.Lyrics
+	if err := l.DB(ctx).Where("song_id = ?", sourceID).Order("\"order\"").Find(&verses).Error; err != nil {
+		return chords.Sheet{}, err
+	}
+
+	var group models.Group
+	if err := l.DB(ctx).Unscoped().First(&group, song.GroupID).Error; err != nil {
+		return chords.Sheet{}, err
+	}
+
+	return lyricsSheet(song.Title, group.Name, key, verses), nil
+}
+
+// lyricsSheet оформляет текст без аккордов как ChordPro, чтобы печатать его так же
+func lyricsSheet(title, artist, key string, verses []models.Lyrics) chords.Sheet {
+	sheet := chords.Sheet{Title: title, Artist: artist, Key: key}
+
+	for _, verse := range verses {
+		block := chords.Block{Label: verse.Label}
+		switch verse.Type {
+		case lyrics.TypeChorus:
+			block.Env = chords.EnvChorus
+		case lyrics.TypeBridge:
+			block.Env = chords.EnvBridge
+		}
+		for _, line := range strings.Split(verse.Verse, "\n") {
+			block.Lines = append(block.Lines, chords.Line{Text: line})
+		}
+		sheet.Blocks = append(sheet.Blocks, block)
+	}
+
+	return sheet
+}
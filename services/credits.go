@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"songLibrary/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCreditNotFound = errors.New("участник песни не найден")
+	ErrCreditExists   = errors.New("участник уже указан в этой роли")
+	ErrInvalidCredit  = errors.New("некорректный участник песни")
+)
+
+var creditRoles = []string{models.CreditRoleMain, models.CreditRoleFeatured, models.CreditRoleComposer, models.CreditRoleLyricist, models.CreditRoleProducer}
+
+// "Song (feat. Artist)" или "Song [ft. A & B]" в любом месте строки. "(with …)" не
+// считается: так пишут и состав исполнения, "Starlight (with Strings)".
+var featBracketRegexp = regexp.MustCompile(`(?i)\s*[(\[]\s*(?:feat\.?|ft\.?|featuring)\s+([^)\]]+)[)\]]`)
+
+// "Artist feat. Artist" без скобок, до конца строки
+var featTailRegexp = regexp.MustCompile(`(?i)\s+(?:feat\.?|ft\.?|featuring)\s+(.+)$`)
+
+// Разделители приглашённых исполнителей: "A, B & C". По "and" не делим, он бывает
+// частью названия: "Florence and the Machine".
+var featSeparatorRegexp = regexp.MustCompile(`\s*[,&]\s*`)
+
+// SplitFeaturing выделяет приглашённых исполнителей из названия группы и песни.
+// Основной исполнитель не делится: "Simon & Garfunkel" остаётся одной группой.
+func SplitFeaturing(groupName, title string) (string, string, []string) {
+	var featured []string
+
+	cut := func(value string) string {
+		for _, m := range featBracketRegexp.FindAllStringSubmatch(value, -1) {
+			featured = append(featured, featSeparatorRegexp.Split(m[1], -1)...)
+		}
+		rest := strings.TrimSpace(featBracketRegexp.ReplaceAllString(value, ""))
+
+		if m := featTailRegexp.FindStringSubmatchIndex(rest); m != nil {
+			featured = append(featured, featSeparatorRegexp.Split(rest[m[2]:m[3]], -1)...)
+			rest = strings.TrimSpace(rest[:m[0]])
+		}
+
+		// От названия целиком в скобках ничего не остаётся, оставляем как было
+		if rest == "" {
+			return value
+		}
+		return rest
+	}
+
+	groupName = cut(groupName)
+	title = cut(title)
+
+	seen := map[string]bool{strings.ToLower(groupName): true}
+	names := make([]string, 0, len(featured))
+	for _, name := range featured {
+		name = strings.TrimSpace(name)
+		if name == "" || len(name) > 60 || seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		names = append(names, name)
+	}
+
+	return groupName, title, names
+}
+
+// syncMainCredit держит основного исполнителя из group_id среди участников с ролью main.
+// При смене группы прежний основной исполнитель из участников убирается.
+func syncMainCredit(tx *gorm.DB, song *models.Song, previousGroupID int) error {
+	if previousGroupID != 0 && previousGroupID != song.GroupID {
+		err := tx.Unscoped().Where("song_id = ? AND group_id = ? AND role = ?", song.ID, previousGroupID, models.CreditRoleMain).Delete(&models.SongCredit{}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	var count int64
+	err := tx.Model(&models.SongCredit{}).Where("song_id = ? AND group_id = ? AND role = ?", song.ID, song.GroupID, models.CreditRoleMain).Count(&count).Error
+	if err != nil || count > 0 {
+		return err
+	}
+
+	return tx.Create(&models.SongCredit{SongID: song.ID, GroupID: song.GroupID, Role: models.CreditRoleMain}).Error
+}
+
+// SyncMainCredit - syncMainCredit для ручек, которые сами ведут транзакцию
+func SyncMainCredit(tx *gorm.DB, song *models.Song, previousGroupID int) error {
+	return syncMainCredit(tx, song, previousGroupID)
+}
+
+// addCredit добавляет участника в конец списка
+func (l *Library) addCredit(ctx context.Context, tx *gorm.DB, songID int, name, role string) (models.SongCredit, error) {
+	group, err := l.FindOrCreateGroup(ctx, tx, name)
+	if err != nil {
+		return models.SongCredit{}, err
+	}
+
+	var count int64
+	if err := tx.Model(&models.SongCredit{}).Where("song_id = ? AND group_id = ? AND role = ?", songID, group.ID, role).Count(&count).Error; err != nil {
+		return models.SongCredit{}, err
+	}
+	if count > 0 {
+		return models.SongCredit{}, ErrCreditExists
+	}
+
+	var order int
+	if err := tx.Model(&models.SongCredit{}).Where("song_id = ?", songID).Select("COALESCE(MAX(\"order\"), 0)").Scan(&order).Error; err != nil {
+		return models.SongCredit{}, err
+	}
+
+	credit := models.SongCredit{SongID: songID, GroupID: group.ID, Group: &group, Role: role, Order: order + 1}
+	return credit, tx.Omit("Group").Create(&credit).Error
+}
+
+// ListCredits возвращает участников песни по порядку
+func (l *Library) ListCredits(ctx context.Context, songID int) ([]models.SongCredit, error) {
+	if err := l.DB(ctx).Select("id").First(&models.Song{}, songID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSongNotFound
+		}
+		return nil, err
+	}
+
+	result := []models.SongCredit{}
+	err := l.DB(ctx).Preload("Group.Photo").Where("song_id = ?", songID).Order("\"order\", id").Find(&result).Error
+	return result, err
+}
+
+// AddCredit добавляет участника песни. Исполнитель ищется по имени и создаётся, если его нет.
+func (l *Library) AddCredit(ctx context.Context, songID int, input models.CreditInput) (models.SongCredit, error) {
+	log := logger.Ctx(ctx).WithField("prefix", "AddCredit")
+
+	name := strings.TrimSpace(input.Artist)
+	role := strings.ToLower(strings.TrimSpace(input.Role))
+	if role == "" {
+		role = models.CreditRoleFeatured
+	}
+	if !slices.Contains(creditRoles, role) {
+		return models.SongCredit{}, fmt.Errorf("%w: роль должна быть одной из %s", ErrInvalidCredit, strings.Join(creditRoles, ", "))
+	}
+	if len(name) < 1 || len(name) > 60 {
+		return models.SongCredit{}, fmt.Errorf("%w: имя исполнителя пустое или слишком длинное", ErrInvalidCredit)
+	}
+
+	var song models.Song
+	if err := l.DB(ctx).First(&song, songID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.SongCredit{}, ErrSongNotFound
+		}
+		return models.SongCredit{}, err
+	}
+
+	log.WithField("artist", name).WithField("role", role).Info("Добавляем участника песни") // Info-лог
+
+	var credit models.SongCredit
+	err := l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		credit, err = l.addCredit(ctx, tx, songID, name, role)
+		return err
+	})
+	if err != nil {
+		return credit, err
+	}
+
+	l.InvalidateSong(ctx, song.ID, song.GroupID, credit.GroupID)
+
+	return credit, nil
+}
+
+// DeleteCredit удаляет участника песни. Основного исполнителя меняют через редактирование песни.
+func (l *Library) DeleteCredit(ctx context.Context, songID, creditID int) error {
+	var song models.Song
+	if err := l.DB(ctx).First(&song, songID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSongNotFound
+		}
+		return err
+	}
+
+	var credit models.SongCredit
+	if err := l.DB(ctx).Where("id = ? AND song_id = ?", creditID, songID).First(&credit).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCreditNotFound
+		}
+		return err
+	}
+	if credit.GroupID == song.GroupID && credit.Role == models.CreditRoleMain {
+		return fmt.Errorf("%w: основной исполнитель меняется через редактирование песни", ErrInvalidCredit)
+	}
+
+	if err := l.DB(ctx).Unscoped().Delete(&credit).Error; err != nil {
+		return err
+	}
+
+	l.InvalidateSong(ctx, song.ID, song.GroupID, credit.GroupID)
+
+	return nil
+}
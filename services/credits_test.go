@@ -0,0 +1,30 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitFeaturing(t *testing.T) {
+	tests := []struct {
+		group, title         string
+		wantGroup, wantTitle string
+		wantFeatured         []string
+	}{
+		{"Calvin Harris", "Sweet Nothing (feat. Florence and the Machine)", "Calvin Harris", "Sweet Nothing", []string{"Florence and the Machine"}},
+		{"Muse", "Starlight (with Strings)", "Muse", "Starlight (with Strings)", []string{}},
+		{"Eminem", "Love the Way You Lie [ft. Rihanna]", "Eminem", "Love the Way You Lie", []string{"Rihanna"}},
+		{"Major Lazer", "Lean On (feat. MØ, DJ Snake & Someone)", "Major Lazer", "Lean On", []string{"MØ", "DJ Snake", "Someone"}},
+		{"Jay-Z feat. Alicia Keys", "Empire State of Mind", "Jay-Z", "Empire State of Mind", []string{"Alicia Keys"}},
+		{"Simon & Garfunkel", "The Boxer", "Simon & Garfunkel", "The Boxer", []string{}},
+		{"Daft Punk", "Get Lucky (feat. Daft Punk)", "Daft Punk", "Get Lucky", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.group+" - "+tt.title, func(t *testing.T) {
+			group, title, featured := SplitFeaturing(tt.group, tt.title)
+			if group != tt.wantGroup || title != tt.wantTitle || !reflect.DeepEqual(featured, tt.wantFeatured) {
+				t.Errorf("SplitFeaturing() = %q, %q, %q; want %q, %q, %q", group, title, featured, tt.wantGroup, tt.wantTitle, tt.wantFeatured)
+			}
+		})
+	}
+}
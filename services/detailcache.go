@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"songLibrary/models"
+	"songLibrary/providers"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// isUpstreamUnavailable - ответа нет совсем или провайдер упал
+func isUpstreamUnavailable(err error) bool {
+	var apiErr *ExternalAPIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode == 0 || apiErr.StatusCode >= http.StatusInternalServerError
+}
+
+// lookupCached берёт неустаревший сохранённый ответ провайдера (кроме режима fresh), иначе запрашивает
+// его и сохраняет ответ. Если провайдер недоступен и включён offline-режим, отдаёт любой сохранённый ответ.
+func (l *Library) lookupCached(ctx context.Context, provider providers.Provider, groupName, title string, fresh bool) (SongDetail, error) {
+	log := logger.Ctx(ctx).WithField("prefix", "lookupCached").WithField("provider", provider.Name())
+
+	if local, ok := provider.(providers.Local); ok && local.Local() {
+		return provider.Lookup(ctx, groupName, title)
+	}
+
+	groupKey, songKey := providers.NormalizeKey(groupName), providers.NormalizeKey(title)
+
+	var cached models.ExternalAPIResponse
+	err := l.DB(ctx).Where("provider = ? AND group_key = ? AND song_key = ?", provider.Name(), groupKey, songKey).First(&cached).Error
+	found := err == nil
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return SongDetail{}, err
+	}
+
+	if found && !fresh && time.Now().Before(cached.ExpiresAt) {
+
+		log.Info("Используем сохранённый ответ провайдера") // Info-лог
+
+		return detailFromResponse(cached), nil
+	}
+
+	detail, err := provider.Lookup(ctx, groupName, title)
+	if err != nil {
+		if found && l.offlineFallback && isUpstreamUnavailable(err) {
+
+			log.WithError(err).WithField("fetched_at", cached.FetchedAt).Warn("Провайдер недоступен, используем устаревший ответ") // Warn-лог
+
+			return detailFromResponse(cached), nil
+		}
+		return detail, err
+	}
+
+	if l.detailCacheTTL > 0 {
+		now := time.Now()
+		response := models.ExternalAPIResponse{
+			Provider:    provider.Name(),
+			GroupKey:    groupKey,
+			SongKey:     songKey,
+			ReleaseDate: detail.ReleaseDate,
+			Text:        detail.Text,
+			Link:        detail.Link,
+			Album:       albumInfo(detail.Album),
+			FetchedAt:   now,
+			ExpiresAt:   now.Add(l.detailCacheTTL),
+		}
+
+		err := l.DB(ctx).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "provider"}, {Name: "group_key"}, {Name: "song_key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"release_date", "text", "link", "album", "fetched_at", "expires_at"}),
+		}).Create(&response).Error
+		if err != nil {
+			// Ответ уже получен, без сохранения просто сходим к провайдеру в следующий раз
+			log.WithError(err).Warn("Не удалось сохранить ответ провайдера") // Warn-лог
+		}
+	}
+
+	return detail, nil
+}
+
+func detailFromResponse(response models.ExternalAPIResponse) SongDetail {
+	return SongDetail{ReleaseDate: response.ReleaseDate, Text: response.Text, Link: response.Link, Album: providerAlbum(response.Album)}
+}
+
+// albumInfo и providerAlbum переводят альбом между форматом /info и форматом хранения
+func albumInfo(album *providers.Album) *models.AlbumInfo {
+	if album == nil || album.Title == "" {
+		return nil
+	}
+	return &models.AlbumInfo{
+		Title:       album.Title,
+		ReleaseDate: album.ReleaseDate,
+		Type:        album.Type,
+		TrackNumber: album.TrackNumber,
+		DiscNumber:  album.DiscNumber,
+	}
+}
+
+func providerAlbum(album *models.AlbumInfo) *providers.Album {
+	if album == nil || album.Title == "" {
+		return nil
+	}
+	return &providers.Album{
+		Title:       album.Title,
+		ReleaseDate: album.ReleaseDate,
+		Type:        album.Type,
+		TrackNumber: album.TrackNumber,
+		DiscNumber:  album.DiscNumber,
+	}
+}
+
+// ListSongDetails возвращает сохранённые ответы провайдеров, group и title ищутся по подстроке
+func (l *Library) ListSongDetails(ctx context.Context, provider, groupName, title string, page, limit int) (models.ExternalAPIResponsesList, error) {
+	result := models.ExternalAPIResponsesList{Page: page, Limit: limit}
+
+	query := l.DB(ctx).Model(&models.ExternalAPIResponse{})
+	if provider != "" {
+		query = query.Where("provider = ?", provider)
+	}
+	if groupKey := providers.NormalizeKey(groupName); groupKey != "" {
+		query = query.Where("group_key LIKE ?", "%"+groupKey+"%")
+	}
+	if songKey := providers.NormalizeKey(title); songKey != "" {
+		query = query.Where("song_key LIKE ?", "%"+songKey+"%")
+	}
+
+	if err := query.Count(&result.TotalCount).Error; err != nil {
+		return result, err
+	}
+
+	err := query.Order("group_key, song_key, provider").Offset((page - 1) * limit).Limit(limit).Find(&result.Data).Error
+	return result, err
+}
+
+// PurgeSongDetails удаляет сохранённые ответы: конкретной песни, если заданы group и title,
+// одного провайдера, если задан provider, только устаревшие с expiredOnly, иначе все
+func (l *Library) PurgeSongDetails(ctx context.Context, provider, groupName, title string, expiredOnly bool) (int64, error) {
+	log := logger.Ctx(ctx).WithField("prefix", "PurgeSongDetails")
+
+	query := l.DB(ctx).Where("1 = 1")
+	if provider != "" {
+		query = query.Where("provider = ?", provider)
+	}
+	if groupName != "" || title != "" {
+		query = query.Where("group_key = ? AND song_key = ?", providers.NormalizeKey(groupName), providers.NormalizeKey(title))
+	}
+	if expiredOnly {
+		query = query.Where("expires_at < ?", time.Now())
+	}
+
+	res := query.Delete(&models.ExternalAPIResponse{})
+	if res.Error != nil {
+		return 0, res.Error
+	}
+
+	log.WithField("deleted", res.RowsAffected).Info("Сохранённые ответы провайдеров удалены") // Info-лог
+
+	return res.RowsAffected, nil
+}
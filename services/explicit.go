@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"songLibrary/models"
+	"time"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+var ErrExplicitDisabled = errors.New("поиск ненормативной лексики выключен")
+
+type ExplicitScanResult struct {
+	Checked  int `json:"checked"`
+	Changed  int `json:"changed"`
+	Explicit int `json:"explicit"`
+}
+
+// FlagExplicit ищет ненормативную лексику в сохранённом тексте песни и обновляет отметки.
+// Вызывается в транзакции, заменившей текст. Ручная отметка остаётся в силе.
+func (l *Library) FlagExplicit(tx *gorm.DB, song *models.Song) error {
+	if l.explicit == nil {
+		return nil
+	}
+
+	var verses []models.Lyrics
+	if err := tx.Where("song_id = ?", song.ID).Order("\"order\"").Find(&verses).Error; err != nil {
+		return err
+	}
+
+	var found pq.Int64Array
+	for _, verse := range verses {
+		if len(l.explicit.Find(verse.Verse)) > 0 {
+			found = append(found, int64(verse.Order))
+		}
+	}
+
+	log := logger.Ctx(tx.Statement.Context).WithField("prefix", "FlagExplicit")
+
+	log.WithField("song.id", song.ID).WithField("verses", found).Debug("Части с ненормативной лексикой") // Debug-лог
+
+	song.ExplicitVerses = found
+	song.Explicit = explicitFlag(*song)
+
+	return tx.Model(&models.Song{}).Where("id = ?", song.ID).
+		UpdateColumns(map[string]any{"explicit": song.Explicit, "explicit_verses": found}).Error
+}
+
+// explicitFlag - ручная отметка, а без неё найдена ли лексика хотя бы в одной части
+func explicitFlag(song models.Song) bool {
+	if song.ExplicitOverride != nil {
+		return *song.ExplicitOverride
+	}
+	return len(song.ExplicitVerses) > 0
+}
+
+// SetExplicitOverride ставит или, если override nil, снимает ручную отметку
+func (l *Library) SetExplicitOverride(ctx context.Context, songID int, override *bool) (models.Song, error) {
+	log := logger.Ctx(ctx).WithField("prefix", "SetExplicitOverride")
+
+	var song models.Song
+	if err := l.DB(ctx).First(&song, songID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return song, ErrSongNotFound
+		}
+		return song, err
+	}
+
+	song.ExplicitOverride = override
+	song.Explicit = explicitFlag(song)
+
+	log.WithField("override", override).WithField("explicit", song.Explicit).Info("Меняем ручную отметку") // Info-лог
+
+	err := l.DB(ctx).Model(&models.Song{}).Where("id = ?", songID).
+		Updates(map[string]any{"explicit": song.Explicit, "explicit_override": override, "updated_at": time.Now()}).Error
+	if err != nil {
+		return song, err
+	}
+
+	l.InvalidateSong(ctx, song.ID, song.GroupID)
+
+	return song, nil
+}
+
+// ScanExplicit заново проверяет тексты всех песен, например после изменения словаря
+func (l *Library) ScanExplicit(ctx context.Context) (ExplicitScanResult, error) {
+	var result ExplicitScanResult
+	if l.explicit == nil {
+		return result, ErrExplicitDisabled
+	}
+
+	var songs []models.Song
+	err := l.DB(ctx).Order("id").FindInBatches(&songs, 100, func(tx *gorm.DB, batch int) error {
+		for i := range songs {
+			wasExplicit, wasVerses := songs[i].Explicit, slices.Clone(songs[i].ExplicitVerses)
+
+			if err := l.FlagExplicit(l.DB(ctx), &songs[i]); err != nil {
+				return err
+			}
+
+			result.Checked++
+			if songs[i].Explicit {
+				result.Explicit++
+			}
+			if songs[i].Explicit != wasExplicit || !slices.Equal(songs[i].ExplicitVerses, wasVerses) {
+				result.Changed++
+				l.InvalidateSong(ctx, songs[i].ID)
+			}
+		}
+		return nil
+	}).Error
+
+	return result, err
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"songLibrary/cache"
+	"songLibrary/media"
+	"songLibrary/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrImageNotFound = errors.New("изображение не найдено")
+
+// imageOwner - таблица и колонка, в которой запись хранит ссылку на своё изображение
+type imageOwner struct {
+	table    string
+	column   string
+	notFound error
+}
+
+var (
+	songCover  = imageOwner{table: "songs", column: "cover_id", notFound: ErrSongNotFound}
+	groupPhoto = imageOwner{table: "groups", column: "photo_id", notFound: ErrGroupNotFound}
+)
+
+// SetSongCover заменяет обложку песни
+func (l *Library) SetSongCover(ctx context.Context, songID int, data []byte, thumbnailSize int) (models.Image, error) {
+	image, err := l.replaceImage(ctx, songCover, songID, fmt.Sprintf("songs/%d/cover", songID), data, thumbnailSize)
+	if err != nil {
+		return image, err
+	}
+
+	l.InvalidateSong(ctx, songID)
+
+	return image, nil
+}
+
+// DeleteSongCover удаляет обложку песни
+func (l *Library) DeleteSongCover(ctx context.Context, songID int) error {
+	if err := l.removeImage(ctx, songCover, songID); err != nil {
+		return err
+	}
+
+	l.InvalidateSong(ctx, songID)
+
+	return nil
+}
+
+// SetGroupPhoto заменяет фотографию группы
+func (l *Library) SetGroupPhoto(ctx context.Context, groupID int, data []byte, thumbnailSize int) (models.Image, error) {
+	image, err := l.replaceImage(ctx, groupPhoto, groupID, fmt.Sprintf("groups/%d/photo", groupID), data, thumbnailSize)
+	if err != nil {
+		return image, err
+	}
+
+	l.cache.InvalidateTags(ctx, cache.TagSongsList, cache.TagGroup(groupID))
+
+	return image, nil
+}
+
+// DeleteGroupPhoto удаляет фотографию группы
+func (l *Library) DeleteGroupPhoto(ctx context.Context, groupID int) error {
+	if err := l.removeImage(ctx, groupPhoto, groupID); err != nil {
+		return err
+	}
+
+	l.cache.InvalidateTags(ctx, cache.TagSongsList, cache.TagGroup(groupID))
+
+	return nil
+}
+
+// OpenImage открывает изображение или его миниатюру и возвращает тип содержимого
+func (l *Library) OpenImage(ctx context.Context, id int, thumbnail bool) (io.ReadCloser, string, error) {
+	var image models.Image
+	if err := l.DB(ctx).First(&image, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", ErrImageNotFound
+		}
+		return nil, "", err
+	}
+
+	key, contentType := image.Key, image.ContentType
+	if thumbnail {
+		key, contentType = image.ThumbnailKey, media.ThumbnailContentType
+	}
+
+	r, err := l.store.Open(ctx, key)
+	if err != nil {
+		return nil, "", err
+	}
+	return r, contentType, nil
+}
+
+// replaceImage проверяет изображение, кладёт оригинал и миниатюру в хранилище и
+// привязывает их к записи. Прежнее изображение удаляется вместе с файлами.
+func (l *Library) replaceImage(ctx context.Context, owner imageOwner, ownerID int, keyPrefix string, data []byte, thumbnailSize int) (models.Image, error) {
+	log := logger.Ctx(ctx).WithField("prefix", "replaceImage").WithField("table", owner.table)
+
+	processed, err := media.Process(data, thumbnailSize)
+	if err != nil {
+		return models.Image{}, err
+	}
+
+	// Случайный суффикс: новый файл не совпадает по ключу со старым, закешированным клиентами
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return models.Image{}, err
+	}
+	keyPrefix += "-" + hex.EncodeToString(suffix)
+
+	image := models.Image{
+		Key:          keyPrefix + processed.Extension,
+		ThumbnailKey: keyPrefix + "-thumb.jpg",
+		ContentType:  processed.ContentType,
+		Size:         len(data),
+		Width:        processed.Width,
+		Height:       processed.Height,
+	}
+
+	log.WithField("key", image.Key).WithField("type", image.ContentType).Info("Сохраняем изображение") // Info-лог
+
+	if err := l.store.Put(ctx, image.Key, data); err != nil {
+		return image, err
+	}
+	if err := l.store.Put(ctx, image.ThumbnailKey, processed.Thumbnail); err != nil {
+		l.deleteImageFiles(ctx, image)
+		return image, err
+	}
+
+	var previous *models.Image
+	err = l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		current, err := lockImageOwner(tx, owner, ownerID)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Create(&image).Error; err != nil {
+			return err
+		}
+		if err := setImageOwner(tx, owner, ownerID, &image.ID); err != nil {
+			return err
+		}
+
+		if current != nil {
+			previous = &models.Image{}
+			if err := tx.First(previous, *current).Error; err != nil {
+				return err
+			}
+			return tx.Unscoped().Delete(previous).Error
+		}
+		return nil
+	})
+	if err != nil {
+		l.deleteImageFiles(ctx, image)
+		return image, err
+	}
+
+	if previous != nil {
+		l.deleteImageFiles(ctx, *previous)
+	}
+
+	return image, nil
+}
+
+// removeImage отвязывает изображение от записи и удаляет его вместе с файлами
+func (l *Library) removeImage(ctx context.Context, owner imageOwner, ownerID int) error {
+	var image models.Image
+	err := l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		current, err := lockImageOwner(tx, owner, ownerID)
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			return ErrImageNotFound
+		}
+
+		if err := tx.First(&image, *current).Error; err != nil {
+			return err
+		}
+		if err := setImageOwner(tx, owner, ownerID, nil); err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&image).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	l.deleteImageFiles(ctx, image)
+
+	return nil
+}
+
+// lockImageOwner блокирует запись до конца транзакции и возвращает ID её изображения
+func lockImageOwner(tx *gorm.DB, owner imageOwner, ownerID int) (*int, error) {
+	var rows []struct{ ImageID *int }
+	err := tx.Table(owner.table).Select(owner.column+" AS image_id").
+		Where("id = ? AND deleted_at IS NULL", ownerID).
+		Clauses(clause.Locking{Strength: "UPDATE"}).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, owner.notFound
+	}
+	return rows[0].ImageID, nil
+}
+
+func setImageOwner(tx *gorm.DB, owner imageOwner, ownerID int, imageID *int) error {
+	return tx.Table(owner.table).Where("id = ?", ownerID).
+		Updates(map[string]any{owner.column: imageID, "updated_at": time.Now()}).Error
+}
+
+// deleteImageFiles удаляет оригинал и миниатюру. Ошибка только пишется в лог: запись
+// уже удалена, а оставшийся файл ни на что не влияет.
+func (l *Library) deleteImageFiles(ctx context.Context, image models.Image) {
+	for _, key := range []string{image.Key, image.ThumbnailKey} {
+		if err := l.store.Delete(ctx, key); err != nil {
+
+			logger.Ctx(ctx).WithError(err).WithField("key", key).Warn("Не удалось удалить файл изображения") // Warn-лог
+		}
+	}
+}
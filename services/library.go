@@ -0,0 +1,275 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"songLibrary/blobstore"
+	"songLibrary/cache"
+	"songLibrary/explicit"
+	"songLibrary/initializers"
+	"songLibrary/links"
+	"songLibrary/logging"
+	"songLibrary/lyrics"
+	"songLibrary/models"
+	"songLibrary/providers"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+var (
+	tracer = otel.Tracer("songLibrary/services")
+	logger = logging.New("services")
+)
+
+var (
+	ErrSongExists   = errors.New("песня уже существует")
+	ErrSongNotFound = errors.New("песня не найдена")
+)
+
+// ExternalAPIError - ошибка провайдера данных о песне.
+// StatusCode равен 0, если ответ не был получен.
+type ExternalAPIError = providers.Error
+
+// SongDetail - данные о песне от провайдера
+type SongDetail = providers.Detail
+
+// Library содержит операции над библиотекой, общие для HTTP-ручек и CLI
+type Library struct {
+	db              *gorm.DB
+	providers       []providers.Provider
+	strategy        string
+	detailCacheTTL  time.Duration
+	offlineFallback bool
+	cache           cache.Cache
+	store           blobstore.Store
+	explicit        *explicit.Detector
+	// Клиент проверки ссылок, без доступа во внутреннюю сеть
+	linkClient *http.Client
+}
+
+func NewLibrary(db *gorm.DB, externalAPI initializers.ExternalAPIConfig, explicitConfig initializers.ExplicitConfig, responseCache cache.Cache, store blobstore.Store) (*Library, error) {
+	list, err := providers.New(externalAPI)
+	if err != nil {
+		return nil, err
+	}
+	detector, err := explicit.New(explicitConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Library{
+		db:              db,
+		providers:       list,
+		strategy:        externalAPI.Strategy,
+		detailCacheTTL:  externalAPI.CacheTTL,
+		offlineFallback: externalAPI.OfflineFallback,
+		cache:           responseCache,
+		store:           store,
+		explicit:        detector,
+		linkClient:      links.NewClient(externalAPI.Timeout),
+	}, nil
+}
+
+// Cache - кеш ответов ручек чтения
+func (l *Library) Cache() cache.Cache {
+	return l.cache
+}
+
+// InvalidateSong сбрасывает кеш песни, затронутых групп и всех списков песен
+func (l *Library) InvalidateSong(ctx context.Context, songID int, groupIDs ...int) {
+	tags := []string{cache.TagSongsList, cache.TagSong(songID)}
+	for _, groupID := range groupIDs {
+		tags = append(tags, cache.TagGroup(groupID))
+	}
+
+	logger.Ctx(ctx).WithField("tags", tags).Debug("Инвалидируем кеш") // Debug-лог
+
+	l.cache.InvalidateTags(ctx, tags...)
+}
+
+// DB возвращает соединение с контекстом запроса
+func (l *Library) DB(ctx context.Context) *gorm.DB {
+	return l.db.WithContext(ctx)
+}
+
+// createVerses сохраняет части текста по порядку, повторы ссылаются на первое вхождение.
+// Переводы переносятся на новые части, строки текста пересобираются следом.
+func createVerses(tx *gorm.DB, songID int, sections []lyrics.Section) ([]models.Lyrics, error) {
+	log := logger.Ctx(tx.Statement.Context).WithField("prefix", "createVerses")
+
+	verses := make([]models.Lyrics, 0, len(sections))
+	for i, section := range sections {
+		verse := models.Lyrics{
+			SongID: songID,
+			Verse:  section.Text,
+			Order:  i + 1,
+			Type:   section.Type,
+			Label:  section.Label,
+		}
+		if section.RepeatOf >= 0 {
+			canonicalID := verses[section.RepeatOf].ID
+			verse.RepeatOfID = &canonicalID
+		}
+
+		log.WithField("Order", verse.Order).WithField("Type", verse.Type).Debug("Часть текста") // Debug-лог
+
+		if err := tx.Create(&verse).Error; err != nil {
+			return nil, err
+		}
+		verses = append(verses, verse)
+	}
+
+	if err := remapTranslations(tx, songID, verses); err != nil {
+		return nil, err
+	}
+
+	return verses, rebuildLines(tx, songID)
+}
+
+// FindOrCreateGroup ищет группу по имени и создаёт её, если не нашлась
+func (l *Library) FindOrCreateGroup(ctx context.Context, tx *gorm.DB, name string) (models.Group, error) {
+	log := logger.Ctx(ctx).WithField("prefix", "FindOrCreateGroup")
+
+	log.Info("Проверка существования группы") // Info-лог
+
+	var group models.Group
+	if err := tx.Where("name = ?", name).First(&group).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return group, fmt.Errorf("не удалось найти группу %s: %w", name, err)
+		}
+
+		log.Info("Группа не найдена, создаём новую") // Info-лог
+
+		group = models.Group{Name: name}
+		if err := tx.Create(&group).Error; err != nil {
+			return group, fmt.Errorf("не удалось создать группу: %w", err)
+		}
+	}
+
+	return group, nil
+}
+
+// AddSong добавляет песню, данные о ней берутся из сохранённых ответов или у внешнего API
+func (l *Library) AddSong(ctx context.Context, groupName, title string) (models.Song, error) {
+	return l.addSong(ctx, groupName, title, func(ctx context.Context, groupName, title string) (SongDetail, models.Provenance, error) {
+		return l.LookupSongDetail(ctx, groupName, title)
+	})
+}
+
+// AddSongWithDetail добавляет песню с уже известными данными, без обращения к провайдерам.
+// Если provenance не задан, заполненные поля отмечаются как импортированные.
+func (l *Library) AddSongWithDetail(ctx context.Context, groupName, title string, detail SongDetail, provenance models.Provenance) (models.Song, error) {
+	return l.addSong(ctx, groupName, title, func(context.Context, string, string) (SongDetail, models.Provenance, error) {
+		if provenance == nil {
+			provenance.Set(models.ProvenanceImport, filledFields(detail)...)
+		}
+		return detail, provenance, nil
+	})
+}
+
+// addSong создаёт песню. Приглашённые исполнители ("feat.", "ft.") выделяются из названий
+// группы и песни в участников, провайдеров спрашиваем уже по очищенным названиям.
+func (l *Library) addSong(ctx context.Context, groupName, title string, detail func(context.Context, string, string) (SongDetail, models.Provenance, error)) (models.Song, error) {
+	log := logger.Ctx(ctx).WithField("prefix", "AddSong")
+
+	var song models.Song
+
+	groupName, title, featured := SplitFeaturing(groupName, title)
+	if len(featured) > 0 {
+
+		log.WithField("featured", featured).Debug("Приглашённые исполнители") // Debug-лог
+	}
+
+	group, err := l.FindOrCreateGroup(ctx, l.DB(ctx), groupName)
+	if err != nil {
+		return song, err
+	}
+
+	log.Info("Проверка существования песни") // Info-лог
+
+	if err := l.DB(ctx).Where("title = ? AND group_id = ?", title, group.ID).First(&song).Error; err == nil {
+		return song, ErrSongExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return song, err
+	}
+
+	songDetail, provenance, err := detail(ctx, groupName, title)
+	if err != nil {
+		return song, err
+	}
+
+	log.Info("Создание новой записи песни") // Info-лог
+
+	song = models.Song{
+		GroupID:     group.ID,
+		Title:       title,
+		ReleaseDate: songDetail.ReleaseDate,
+		Link:        songDetail.Link,
+		Provenance:  provenance,
+	}
+
+	log.WithField("song.GroupID", song.GroupID).Debug("ID группы")                        // Debug-лог
+	log.WithField("song.Title", song.Title).Debug("Имя песни")                            // Debug-лог
+	log.WithField("song.ReleaseDate", songDetail.ReleaseDate).Debug("Дата выпуска песни") // Debug-лог
+	log.WithField("song.Link", songDetail.Link).Debug("Ссылка на песню")                  // Debug-лог
+	log.WithField("songDetail.Text", songDetail.Text).Debug("Текст песни")                // Debug-лог
+
+	log.Info("Разбиение текста песни на куплеты") // Info-лог
+
+	sections := lyrics.Parse(songDetail.Text)
+
+	log.Info("Начало транзакции") // Info-лог
+
+	txCtx, span := tracer.Start(ctx, "AddSong.saveSong", trace.WithAttributes(attribute.Int("song.verses", len(sections))))
+	defer span.End()
+
+	err = l.DB(txCtx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&song).Error; err != nil {
+			return err
+		}
+		if _, err := syncPrimaryLink(tx, &song); err != nil {
+			return err
+		}
+		if err := attachProviderAlbum(tx, &song, songDetail.Album); err != nil {
+			return err
+		}
+		if err := syncMainCredit(tx, &song, 0); err != nil {
+			return err
+		}
+		for _, name := range featured {
+			if _, err := l.addCredit(ctx, tx, song.ID, name, models.CreditRoleFeatured); err != nil {
+				return err
+			}
+		}
+
+		log.Info("Сохранение куплетов") // Info-лог
+
+		verses, err := createVerses(tx, song.ID, sections)
+		if err != nil {
+			return err
+		}
+		song.Lyrics = verses
+
+		return l.FlagExplicit(tx, &song)
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return song, err
+	}
+
+	logging.AddField(ctx, logging.FieldSongID, song.ID)
+
+	l.InvalidateSong(ctx, song.ID, group.ID)
+
+	log.Info("Завершение транзакции") // Info-лог
+
+	return song, nil
+}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"songLibrary/lyrics"
+	"songLibrary/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var ErrNotSynced = errors.New("у песни нет синхронизированного текста")
+
+// rebuildLines пересобирает строки песни из частей текста. Если текст строк не изменился,
+// тайминги сохраняются и обновляется только привязка к частям.
+func rebuildLines(tx *gorm.DB, songID int) error {
+	log := logger.Ctx(tx.Statement.Context).WithField("prefix", "rebuildLines")
+
+	var verses []models.Lyrics
+	if err := tx.Where("song_id = ?", songID).Order("\"order\"").Find(&verses).Error; err != nil {
+		return err
+	}
+
+	var lines []models.LyricLine
+	for _, verse := range verses {
+		verseID := verse.ID
+		for _, text := range strings.Split(verse.Verse, "\n") {
+			if text = strings.TrimSpace(text); text != "" {
+				lines = append(lines, models.LyricLine{SongID: songID, LyricsID: &verseID, Order: len(lines) + 1, Text: text})
+			}
+		}
+	}
+
+	var existing []models.LyricLine
+	if err := tx.Where("song_id = ?", songID).Order("\"order\"").Find(&existing).Error; err != nil {
+		return err
+	}
+
+	sameText := slices.EqualFunc(existing, lines, func(a, b models.LyricLine) bool {
+		return lineKey(a.Text) == lineKey(b.Text)
+	})
+	if sameText && len(existing) > 0 {
+
+		log.WithField("song.id", songID).Debug("Текст строк не изменился, тайминги сохраняем") // Debug-лог
+
+		for i := range existing {
+			if err := tx.Model(&existing[i]).Update("lyrics_id", lines[i].LyricsID).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	log.WithField("song.id", songID).WithField("count", len(lines)).Debug("Пересобираем строки текста") // Debug-лог
+
+	if err := tx.Unscoped().Where("song_id = ?", songID).Delete(&models.LyricLine{}).Error; err != nil {
+		return err
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return tx.Create(&lines).Error
+}
+
+// RebuildLines - rebuildLines для ручек, которые сами ведут транзакцию
+func RebuildLines(tx *gorm.DB, songID int) error {
+	return rebuildLines(tx, songID)
+}
+
+// lineKey сравнивает строки без учёта регистра и лишних пробелов
+func lineKey(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+}
+
+// ListLines возвращает строки песни по порядку
+func (l *Library) ListLines(ctx context.Context, songID int) ([]models.LyricLine, error) {
+	if err := l.DB(ctx).Select("id").First(&models.Song{}, songID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSongNotFound
+		}
+		return nil, err
+	}
+
+	result := []models.LyricLine{}
+	err := l.DB(ctx).Where("song_id = ?", songID).Order("\"order\"").Find(&result).Error
+	return result, err
+}
+
+// ImportLRC заменяет строки песни строками из LRC с таймингами. Строки привязываются
+// к частям текста по совпадению. Если текста у песни ещё нет, он создаётся из LRC.
+func (l *Library) ImportLRC(ctx context.Context, songID int, text string) ([]models.LyricLine, error) {
+	log := logger.Ctx(ctx).WithField("prefix", "ImportLRC")
+
+	parsed, err := lyrics.ParseLRC(text)
+	if err != nil {
+		return nil, err
+	}
+
+	var song models.Song
+	if err := l.DB(ctx).First(&song, songID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSongNotFound
+		}
+		return nil, err
+	}
+
+	log.WithField("count", len(parsed.Lines)).Info("Импортируем строки LRC") // Info-лог
+
+	var result []models.LyricLine
+	err = l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		var verseCount int64
+		if err := tx.Model(&models.Lyrics{}).Where("song_id = ?", songID).Count(&verseCount).Error; err != nil {
+			return err
+		}
+		if verseCount == 0 {
+
+			log.Info("У песни нет текста, создаём его из LRC") // Info-лог
+
+			if _, err := createVerses(tx, songID, lyrics.Parse(plainText(parsed.Lines))); err != nil {
+				return err
+			}
+			if err := l.FlagExplicit(tx, &song); err != nil {
+				return err
+			}
+		}
+
+		var existing []models.LyricLine
+		if err := tx.Where("song_id = ?", songID).Order("\"order\"").Find(&existing).Error; err != nil {
+			return err
+		}
+
+		// Строки идут в том же порядке, что и текст, поэтому ищем совпадение только впереди
+		next := 0
+		for _, line := range parsed.Lines {
+			if line.Text == "" {
+				continue
+			}
+
+			start := line.StartMs
+			lyricLine := models.LyricLine{SongID: songID, Order: len(result) + 1, Text: line.Text, StartMs: &start, EndMs: line.EndMs}
+			for _, word := range line.Words {
+				lyricLine.Words = append(lyricLine.Words, models.WordTiming{StartMs: word.StartMs, Text: word.Text})
+			}
+			for i := next; i < len(existing); i++ {
+				if lineKey(existing[i].Text) == lineKey(line.Text) {
+					lyricLine.LyricsID = existing[i].LyricsID
+					next = i + 1
+					break
+				}
+			}
+			result = append(result, lyricLine)
+		}
+
+		if err := tx.Unscoped().Where("song_id = ?", songID).Delete(&models.LyricLine{}).Error; err != nil {
+			return err
+		}
+		if len(result) == 0 {
+			return nil
+		}
+		return tx.Create(&result).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	l.InvalidateSong(ctx, song.ID, song.GroupID)
+
+	return result, nil
+}
+
+// plainText собирает текст из строк LRC, пустые строки разделяют части
+func plainText(lines []lyrics.TimedLine) string {
+	var b strings.Builder
+	for _, line := range lines {
+		if line.Text == "" {
+			b.WriteString("\n")
+			continue
+		}
+		b.WriteString(line.Text + "\n")
+	}
+	return b.String()
+}
+
+// SyncedLyrics возвращает строки с таймингами и теги для экспорта в LRC и WebVTT
+func (l *Library) SyncedLyrics(ctx context.Context, songID int) (map[string]string, []lyrics.TimedLine, error) {
+	var song models.Song
+	if err := l.DB(ctx).First(&song, songID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrSongNotFound
+		}
+		return nil, nil, err
+	}
+
+	var group models.Group
+	if err := l.DB(ctx).Unscoped().First(&group, song.GroupID).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var lines []models.LyricLine
+	if err := l.DB(ctx).Where("song_id = ? AND start_ms IS NOT NULL", songID).Order("\"order\"").Find(&lines).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(lines) == 0 {
+		return nil, nil, ErrNotSynced
+	}
+
+	timed := make([]lyrics.TimedLine, 0, len(lines))
+	for _, line := range lines {
+		timedLine := lyrics.TimedLine{StartMs: *line.StartMs, EndMs: line.EndMs, Text: line.Text}
+		for _, word := range line.Words {
+			timedLine.Words = append(timedLine.Words, lyrics.Word{StartMs: word.StartMs, Text: word.Text})
+		}
+		timed = append(timed, timedLine)
+	}
+
+	return map[string]string{"ar": group.Name, "ti": song.Title}, timed, nil
+}
+
+// ActiveLine находит строку, которая звучит в позиции positionMs, и следующую за ней
+func (l *Library) ActiveLine(ctx context.Context, songID int, positionMs int64) (models.ActiveLine, error) {
+	result := models.ActiveLine{PositionMs: positionMs}
+
+	lines, err := l.ListLines(ctx, songID)
+	if err != nil {
+		return result, err
+	}
+
+	lines = slices.DeleteFunc(lines, func(line models.LyricLine) bool { return line.StartMs == nil })
+	if len(lines) == 0 {
+		return result, ErrNotSynced
+	}
+
+	// Первая строка, которая начинается позже позиции
+	i, _ := slices.BinarySearchFunc(lines, positionMs, func(line models.LyricLine, position int64) int {
+		if *line.StartMs <= position {
+			return -1
+		}
+		return 1
+	})
+
+	if i > 0 {
+		current := lines[i-1]
+		if current.EndMs == nil || positionMs < *current.EndMs {
+			result.Line = &current
+		}
+	}
+	if i < len(lines) {
+		result.Next = &lines[i]
+	}
+
+	return result, nil
+}
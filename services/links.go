@@ -0,0 +1,293 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"songLibrary/initializers"
+	"songLibrary/links"
+	"songLibrary/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrLinkExists   = errors.New("ссылка уже добавлена")
+	ErrLinkNotFound = errors.New("ссылка не найдена")
+)
+
+type LinkCheckResult struct {
+	Checked int `json:"checked"`
+	Broken  int `json:"broken"`
+	Fixed   int `json:"fixed"`
+}
+
+// syncPrimaryLink добавляет song.Link в список ссылок песни, некорректные ссылки пропускаются.
+// Возвращает false, если ссылки нет, она не распознана или уже была в списке.
+func syncPrimaryLink(tx *gorm.DB, song *models.Song) (bool, error) {
+	if song.Link == "" {
+		return false, nil
+	}
+
+	link, err := links.Parse(song.Link)
+	if err != nil {
+		logger.Ctx(tx.Statement.Context).WithError(err).WithField("song.id", song.ID).Warn("Ссылка песни не распознана") // Warn-лог
+		return false, nil
+	}
+
+	res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.SongLink{
+		SongID:     song.ID,
+		Type:       link.Type,
+		URL:        link.URL,
+		ExternalID: link.ExternalID,
+	})
+	return res.RowsAffected > 0, res.Error
+}
+
+// SyncPrimaryLink - syncPrimaryLink для ручек, которые сами ведут транзакцию
+func SyncPrimaryLink(tx *gorm.DB, song *models.Song) error {
+	_, err := syncPrimaryLink(tx, song)
+	return err
+}
+
+// SyncLinks переносит song.Link всех песен в список ссылок, повторный запуск ничего не дублирует.
+// Возвращает число добавленных ссылок.
+func (l *Library) SyncLinks(ctx context.Context) (int, error) {
+	var synced int
+	var songs []models.Song
+
+	err := l.DB(ctx).Where("link <> ''").Order("id").FindInBatches(&songs, 100, func(tx *gorm.DB, batch int) error {
+		for i := range songs {
+			added, err := syncPrimaryLink(l.DB(ctx), &songs[i])
+			if err != nil {
+				return err
+			}
+			if added {
+				synced++
+			}
+		}
+		return nil
+	}).Error
+
+	return synced, err
+}
+
+// ListLinks возвращает ссылки песни
+func (l *Library) ListLinks(ctx context.Context, songID int) ([]models.SongLink, error) {
+	if err := l.DB(ctx).Select("id").First(&models.Song{}, songID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSongNotFound
+		}
+		return nil, err
+	}
+
+	result := []models.SongLink{}
+	err := l.DB(ctx).Where("song_id = ?", songID).Order("id").Find(&result).Error
+	return result, err
+}
+
+// AddLink распознаёт площадку и добавляет ссылку в каноничном виде
+func (l *Library) AddLink(ctx context.Context, songID int, raw string) (models.SongLink, error) {
+	var songLink models.SongLink
+
+	parsed, err := links.Parse(raw)
+	if err != nil {
+		return songLink, err
+	}
+
+	var song models.Song
+	if err := l.DB(ctx).First(&song, songID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return songLink, ErrSongNotFound
+		}
+		return songLink, err
+	}
+
+	songLink = models.SongLink{SongID: songID, Type: parsed.Type, URL: parsed.URL, ExternalID: parsed.ExternalID}
+
+	res := l.DB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&songLink)
+	if res.Error != nil {
+		return songLink, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return songLink, ErrLinkExists
+	}
+
+	l.InvalidateSong(ctx, song.ID, song.GroupID)
+
+	return songLink, nil
+}
+
+// DeleteLink удаляет ссылку окончательно, чтобы её можно было добавить снова
+func (l *Library) DeleteLink(ctx context.Context, songID, linkID int) error {
+	res := l.DB(ctx).Unscoped().Where("id = ? AND song_id = ?", linkID, songID).Delete(&models.SongLink{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrLinkNotFound
+	}
+
+	l.InvalidateSong(ctx, songID)
+
+	return nil
+}
+
+// CheckLinks проверяет до config.BatchSize ссылок, которые давно не проверялись,
+// не чаще config.RatePerSecond запросов в секунду
+func (l *Library) CheckLinks(ctx context.Context, config initializers.LinkCheckConfig) (LinkCheckResult, error) {
+	log := logger.Ctx(ctx).WithField("prefix", "CheckLinks")
+
+	var result LinkCheckResult
+
+	songLinks, err := l.claimLinks(ctx, config.RecheckAfter, config.BatchSize)
+	if err != nil {
+		return result, err
+	}
+
+	log.WithField("count", len(songLinks)).Info("Найдены ссылки для проверки") // Info-лог
+
+	throttle := time.NewTicker(time.Duration(float64(time.Second) / config.RatePerSecond))
+	defer throttle.Stop()
+
+	for i := range songLinks {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return result, ctx.Err()
+			case <-throttle.C:
+			}
+		}
+
+		songLink := &songLinks[i]
+		wasBroken := songLink.Broken
+
+		outcome, status, err := links.Check(ctx, l.linkClient, songLink.Type, songLink.URL)
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+
+		result.Checked++
+
+		songLink.LastStatus = status
+		songLink.LastError = ""
+		if err != nil {
+			songLink.LastError = truncate(err.Error(), 255)
+		}
+
+		switch outcome {
+		case links.OutcomeOK:
+			songLink.Broken = false
+			songLink.Failures = 0
+		case links.OutcomeGone:
+			songLink.Broken = true
+			songLink.Failures++
+		case links.OutcomeFailed:
+			songLink.Failures++
+			songLink.Broken = songLink.Failures >= config.FailureThreshold
+		}
+
+		err = l.DB(ctx).Model(songLink).UpdateColumns(map[string]any{
+			"broken":      songLink.Broken,
+			"failures":    songLink.Failures,
+			"last_status": songLink.LastStatus,
+			"last_error":  songLink.LastError,
+		}).Error
+		if err != nil {
+			return result, fmt.Errorf("ссылка %d: %w", songLink.ID, err)
+		}
+
+		if songLink.Broken != wasBroken {
+
+			log.WithField("link.id", songLink.ID).WithField("broken", songLink.Broken).Info("Изменилось состояние ссылки") // Info-лог
+
+			if songLink.Broken {
+				result.Broken++
+			} else {
+				result.Fixed++
+			}
+			l.InvalidateSong(ctx, songLink.SongID)
+		}
+	}
+
+	return result, nil
+}
+
+// claimLinks выбирает ссылки для проверки и сразу отмечает их, пропуская заблокированные другим экземпляром
+func (l *Library) claimLinks(ctx context.Context, recheckAfter time.Duration, limit int) ([]models.SongLink, error) {
+	var songLinks []models.SongLink
+
+	err := l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("checked_at IS NULL OR checked_at < ?", time.Now().Add(-recheckAfter)).
+			Order("checked_at NULLS FIRST").
+			Limit(limit).
+			Find(&songLinks).Error
+		if err != nil || len(songLinks) == 0 {
+			return err
+		}
+
+		now := time.Now()
+		ids := make([]int, len(songLinks))
+		for i := range songLinks {
+			ids[i] = songLinks[i].ID
+			songLinks[i].CheckedAt = &now
+		}
+		return tx.Model(&models.SongLink{}).Where("id IN ?", ids).UpdateColumn("checked_at", now).Error
+	})
+
+	return songLinks, err
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
+// LinkCheckWorker периодически запускает CheckLinks
+type LinkCheckWorker struct {
+	library *Library
+	config  initializers.LinkCheckConfig
+}
+
+func NewLinkCheckWorker(library *Library, config initializers.LinkCheckConfig) *LinkCheckWorker {
+	return &LinkCheckWorker{library: library, config: config}
+}
+
+func (w *LinkCheckWorker) Name() string {
+	return "link-check"
+}
+
+func (w *LinkCheckWorker) Run(ctx context.Context) error {
+	log := logger.Ctx(ctx).WithField("prefix", "LinkCheckWorker")
+
+	ticker := time.NewTicker(w.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		result, err := w.library.CheckLinks(ctx, w.config)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return err
+			}
+			log.WithError(err).Error("Проверка ссылок завершилась с ошибкой")
+			continue
+		}
+
+		log.WithField("checked", result.Checked).
+			WithField("broken", result.Broken).
+			WithField("fixed", result.Fixed).
+			Info("Проверка ссылок завершена") // Info-лог
+	}
+}
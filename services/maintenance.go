@@ -0,0 +1,416 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"songLibrary/lyrics"
+	"songLibrary/models"
+	"songLibrary/providers"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrAPIKeyInvalid = errors.New("неверный API-ключ")
+
+// Префикс выдаваемых ключей, по нему ключ легко узнать в конфигах и логах
+const apiKeyPrefix = "sl_"
+
+type EnrichResult struct {
+	Checked int              `json:"checked"`
+	Updated int              `json:"updated"`
+	Failed  int              `json:"failed"`
+	Errors  []string         `json:"errors,omitempty"`
+	Changes map[int][]string `json:"changes,omitempty"`
+}
+
+type PurgeResult struct {
+	DryRun         bool  `json:"dry_run"`
+	Songs          int64 `json:"songs"`
+	Lyrics         int64 `json:"lyrics"`
+	Links          int64 `json:"links"`
+	Lines          int64 `json:"lines"`
+	Translations   int64 `json:"translations"`
+	Credits        int64 `json:"credits"`
+	Relations      int64 `json:"relations"`
+	Proposals      int64 `json:"proposals"`
+	Entries        int64 `json:"entries"`
+	Playlists      int64 `json:"playlists"`
+	SetlistEntries int64 `json:"setlist_entries"`
+	Setlists       int64 `json:"setlists"`
+	Albums         int64 `json:"albums"`
+	Groups         int64 `json:"groups"`
+	// Обложки удаляемых песен и фотографии групп, файлы удаляются вместе с записями
+	Images int64 `json:"images"`
+}
+
+// EnrichSong дозаполняет пустые поля песни данными провайдеров, песню без альбома
+// привязывает к альбому из ответа. С overwrite перезаписывает дату, ссылку и текст целиком.
+func (l *Library) EnrichSong(ctx context.Context, song *models.Song, overwrite bool) ([]string, error) {
+	log := logger.Ctx(ctx).WithField("prefix", "EnrichSong").WithField("song.id", song.ID)
+
+	var group models.Group
+	if err := l.DB(ctx).First(&group, song.GroupID).Error; err != nil {
+		return nil, err
+	}
+
+	detail, sources, err := l.LookupSongDetail(ctx, group.Name, song.Title)
+	if err != nil {
+		return nil, err
+	}
+
+	var lyricsCount int64
+	if err := l.DB(ctx).Model(&models.Lyrics{}).Where("song_id = ?", song.ID).Count(&lyricsCount).Error; err != nil {
+		return nil, err
+	}
+
+	var changed []string
+
+	if detail.ReleaseDate != "" && (song.ReleaseDate == "" || overwrite) && detail.ReleaseDate != song.ReleaseDate {
+		song.ReleaseDate = detail.ReleaseDate
+		changed = append(changed, providers.FieldReleaseDate)
+	}
+	if detail.Link != "" && (song.Link == "" || overwrite) && detail.Link != song.Link {
+		song.Link = detail.Link
+		changed = append(changed, providers.FieldLink)
+	}
+	replaceLyrics := detail.Text != "" && (lyricsCount == 0 || overwrite)
+	if replaceLyrics {
+		changed = append(changed, providers.FieldLyrics)
+	}
+	// Альбом только заполняется: переносить песню между альбомами - ручная работа
+	if detail.Album != nil && song.AlbumID == nil {
+		changed = append(changed, providers.FieldAlbum)
+	}
+
+	if len(changed) == 0 {
+		return nil, nil
+	}
+
+	for _, field := range changed {
+		song.Provenance.Set(sources[field], field)
+	}
+
+	log.WithField("changed", changed).Info("Обновляем данные песни") // Info-лог
+
+	var text *string
+	if replaceLyrics {
+		text = &detail.Text
+	}
+
+	err = l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := l.saveSong(tx, song, text); err != nil {
+			return err
+		}
+		return attachProviderAlbum(tx, song, detail.Album)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	l.InvalidateSong(ctx, song.ID, song.GroupID)
+
+	return changed, nil
+}
+
+// saveSong сохраняет песню и её ссылку и, если text не nil, заменяет куплеты
+func (l *Library) saveSong(tx *gorm.DB, song *models.Song, text *string) error {
+	if err := tx.Save(song).Error; err != nil {
+		return err
+	}
+	if _, err := syncPrimaryLink(tx, song); err != nil {
+		return err
+	}
+	if text == nil {
+		return nil
+	}
+
+	if err := tx.Where("song_id = ?", song.ID).Delete(&models.Lyrics{}).Error; err != nil {
+		return err
+	}
+	if _, err := createVerses(tx, song.ID, lyrics.Parse(*text)); err != nil {
+		return err
+	}
+	return l.FlagExplicit(tx, song)
+}
+
+// Enrich проходит по песням без даты, ссылки или текста (или по всем с all)
+func (l *Library) Enrich(ctx context.Context, all bool, overwrite bool) (EnrichResult, error) {
+	log := logger.Ctx(ctx).WithField("prefix", "Enrich")
+
+	result := EnrichResult{Changes: map[int][]string{}}
+
+	query := l.DB(ctx).Model(&models.Song{})
+	if !all {
+		query = query.Where("release_date = '' OR link = '' OR NOT EXISTS (SELECT 1 FROM lyrics WHERE lyrics.song_id = songs.id AND lyrics.deleted_at IS NULL)")
+	}
+
+	var songs []models.Song
+	if err := query.Order("id").Find(&songs).Error; err != nil {
+		return result, err
+	}
+
+	log.WithField("count", len(songs)).Info("Найдены песни для обогащения") // Info-лог
+
+	for i := range songs {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+
+		result.Checked++
+
+		changed, err := l.EnrichSong(ctx, &songs[i], overwrite)
+		if err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("song %d: %s", songs[i].ID, err))
+			continue
+		}
+		if len(changed) > 0 {
+			result.Updated++
+			result.Changes[songs[i].ID] = changed
+		}
+	}
+
+	return result, nil
+}
+
+// PurgeTrash окончательно удаляет мягко удалённые записи старше before
+func (l *Library) PurgeTrash(ctx context.Context, before time.Time, dryRun bool) (PurgeResult, error) {
+	log := logger.Ctx(ctx).WithField("prefix", "PurgeTrash")
+
+	result := PurgeResult{DryRun: dryRun}
+
+	log.WithField("before", before).Info("Очищаем корзину") // Info-лог
+
+	var images []models.Image
+	err := l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		trashedSongs := tx.Unscoped().Model(&models.Song{}).Select("id").Where("deleted_at IS NOT NULL AND deleted_at < ?", before)
+
+		lyrics := tx.Unscoped().Where("(deleted_at IS NOT NULL AND deleted_at < ?) OR song_id IN (?)", before, trashedSongs)
+		songLinks := tx.Unscoped().Where("(deleted_at IS NOT NULL AND deleted_at < ?) OR song_id IN (?)", before, trashedSongs)
+		lyricLines := tx.Unscoped().Where("(deleted_at IS NOT NULL AND deleted_at < ?) OR song_id IN (?)", before, trashedSongs)
+		translations := tx.Unscoped().Where("(deleted_at IS NOT NULL AND deleted_at < ?) OR song_id IN (?)", before, trashedSongs)
+		credits := tx.Unscoped().Where("(deleted_at IS NOT NULL AND deleted_at < ?) OR song_id IN (?)", before, trashedSongs)
+		relations := tx.Unscoped().Where("(deleted_at IS NOT NULL AND deleted_at < ?) OR song_id IN (?) OR related_id IN (?)", before, trashedSongs, trashedSongs)
+		proposals := tx.Unscoped().Where("(deleted_at IS NOT NULL AND deleted_at < ?) OR song_id IN (?)", before, trashedSongs)
+		trashedPlaylists := tx.Unscoped().Model(&models.Playlist{}).Select("id").Where("deleted_at IS NOT NULL AND deleted_at < ?", before)
+		entries := tx.Unscoped().Where("(deleted_at IS NOT NULL AND deleted_at < ?) OR song_id IN (?) OR playlist_id IN (?)", before, trashedSongs, trashedPlaylists)
+		playlists := tx.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", before)
+		trashedSetlists := tx.Unscoped().Model(&models.Setlist{}).Select("id").Where("deleted_at IS NOT NULL AND deleted_at < ?", before)
+		setEntries := tx.Unscoped().Where("(deleted_at IS NOT NULL AND deleted_at < ?) OR song_id IN (?) OR setlist_id IN (?)", before, trashedSongs, trashedSetlists)
+		setlists := tx.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", before)
+		trashedAlbums := tx.Unscoped().Model(&models.Album{}).Select("id").Where("deleted_at IS NOT NULL AND deleted_at < ?", before)
+		albums := tx.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", before)
+		songs := tx.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", before)
+		groups := tx.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", before)
+
+		covers := tx.Unscoped().Model(&models.Song{}).Select("cover_id").Where("deleted_at IS NOT NULL AND deleted_at < ? AND cover_id IS NOT NULL", before)
+		photos := tx.Unscoped().Model(&models.Group{}).Select("photo_id").Where("deleted_at IS NOT NULL AND deleted_at < ? AND photo_id IS NOT NULL", before)
+		// Изображения запоминаем до удаления песен и групп, потом по ним удаляются файлы
+		if err := tx.Where("id IN (?) OR id IN (?)", covers, photos).Find(&images).Error; err != nil {
+			return err
+		}
+		result.Images = int64(len(images))
+
+		if dryRun {
+			if err := lyrics.Model(&models.Lyrics{}).Count(&result.Lyrics).Error; err != nil {
+				return err
+			}
+			if err := songLinks.Model(&models.SongLink{}).Count(&result.Links).Error; err != nil {
+				return err
+			}
+			if err := lyricLines.Model(&models.LyricLine{}).Count(&result.Lines).Error; err != nil {
+				return err
+			}
+			if err := translations.Model(&models.LyricsTranslation{}).Count(&result.Translations).Error; err != nil {
+				return err
+			}
+			if err := credits.Model(&models.SongCredit{}).Count(&result.Credits).Error; err != nil {
+				return err
+			}
+			if err := relations.Model(&models.SongRelation{}).Count(&result.Relations).Error; err != nil {
+				return err
+			}
+			if err := proposals.Model(&models.SongProposal{}).Count(&result.Proposals).Error; err != nil {
+				return err
+			}
+			if err := entries.Model(&models.PlaylistEntry{}).Count(&result.Entries).Error; err != nil {
+				return err
+			}
+			if err := playlists.Model(&models.Playlist{}).Count(&result.Playlists).Error; err != nil {
+				return err
+			}
+			if err := setEntries.Model(&models.SetlistEntry{}).Count(&result.SetlistEntries).Error; err != nil {
+				return err
+			}
+			if err := setlists.Model(&models.Setlist{}).Count(&result.Setlists).Error; err != nil {
+				return err
+			}
+			if err := albums.Model(&models.Album{}).Count(&result.Albums).Error; err != nil {
+				return err
+			}
+			if err := songs.Model(&models.Song{}).Count(&result.Songs).Error; err != nil {
+				return err
+			}
+			return groups.Model(&models.Group{}).Count(&result.Groups).Error
+		}
+
+		res := lyrics.Delete(&models.Lyrics{})
+		if res.Error != nil {
+			return res.Error
+		}
+		result.Lyrics = res.RowsAffected
+
+		res = songLinks.Delete(&models.SongLink{})
+		if res.Error != nil {
+			return res.Error
+		}
+		result.Links = res.RowsAffected
+
+		res = lyricLines.Delete(&models.LyricLine{})
+		if res.Error != nil {
+			return res.Error
+		}
+		result.Lines = res.RowsAffected
+
+		res = translations.Delete(&models.LyricsTranslation{})
+		if res.Error != nil {
+			return res.Error
+		}
+		result.Translations = res.RowsAffected
+
+		res = credits.Delete(&models.SongCredit{})
+		if res.Error != nil {
+			return res.Error
+		}
+		result.Credits = res.RowsAffected
+
+		res = relations.Delete(&models.SongRelation{})
+		if res.Error != nil {
+			return res.Error
+		}
+		result.Relations = res.RowsAffected
+
+		res = proposals.Delete(&models.SongProposal{})
+		if res.Error != nil {
+			return res.Error
+		}
+		result.Proposals = res.RowsAffected
+
+		res = entries.Delete(&models.PlaylistEntry{})
+		if res.Error != nil {
+			return res.Error
+		}
+		result.Entries = res.RowsAffected
+
+		res = playlists.Delete(&models.Playlist{})
+		if res.Error != nil {
+			return res.Error
+		}
+		result.Playlists = res.RowsAffected
+
+		res = setEntries.Delete(&models.SetlistEntry{})
+		if res.Error != nil {
+			return res.Error
+		}
+		result.SetlistEntries = res.RowsAffected
+
+		res = setlists.Delete(&models.Setlist{})
+		if res.Error != nil {
+			return res.Error
+		}
+		result.Setlists = res.RowsAffected
+
+		// Песни удаляемых альбомов, в том числе лежащие в корзине, остаются без альбома
+		err := tx.Unscoped().Model(&models.Song{}).Where("album_id IN (?)", trashedAlbums).
+			UpdateColumns(map[string]any{"album_id": nil, "track_number": nil, "disc_number": nil}).Error
+		if err != nil {
+			return err
+		}
+
+		res = albums.Delete(&models.Album{})
+		if res.Error != nil {
+			return res.Error
+		}
+		result.Albums = res.RowsAffected
+
+		// Жанры и теги удаляемых песен и групп хранятся в связующих таблицах без мягкого удаления
+		trashedGroups := tx.Unscoped().Model(&models.Group{}).Select("id").Where("deleted_at IS NOT NULL AND deleted_at < ?", before)
+		for _, table := range []string{"song_genres", "song_tags"} {
+			if err := tx.Exec("DELETE FROM "+table+" WHERE song_id IN (?)", trashedSongs).Error; err != nil {
+				return err
+			}
+		}
+		for _, table := range []string{"group_genres", "group_tags"} {
+			if err := tx.Exec("DELETE FROM "+table+" WHERE group_id IN (?)", trashedGroups).Error; err != nil {
+				return err
+			}
+		}
+
+		res = songs.Delete(&models.Song{})
+		if res.Error != nil {
+			return res.Error
+		}
+		result.Songs = res.RowsAffected
+
+		res = groups.Delete(&models.Group{})
+		if res.Error != nil {
+			return res.Error
+		}
+		result.Groups = res.RowsAffected
+
+		if len(images) == 0 {
+			return nil
+		}
+		return tx.Unscoped().Delete(&images).Error
+	})
+	if err != nil || dryRun {
+		return result, err
+	}
+
+	for _, image := range images {
+		l.deleteImageFiles(ctx, image)
+	}
+
+	return result, nil
+}
+
+// CreateAPIKey создаёт ключ и возвращает его открытое значение, в БД хранится только хеш
+func (l *Library) CreateAPIKey(ctx context.Context, name string) (string, error) {
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	key := apiKeyPrefix + hex.EncodeToString(raw)
+
+	if err := l.DB(ctx).Create(&models.APIKey{Name: name, Hash: hashAPIKey(key)}).Error; err != nil {
+		return "", fmt.Errorf("не удалось сохранить ключ: %w", err)
+	}
+
+	return key, nil
+}
+
+// AuthenticateAPIKey ищет ключ по хешу и отмечает время использования
+func (l *Library) AuthenticateAPIKey(ctx context.Context, key string) (models.APIKey, error) {
+	var apiKey models.APIKey
+	if err := l.DB(ctx).Where("hash = ?", hashAPIKey(key)).First(&apiKey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apiKey, ErrAPIKeyInvalid
+		}
+		return apiKey, err
+	}
+
+	now := time.Now()
+	l.DB(ctx).Model(&apiKey).UpdateColumn("last_used_at", now)
+
+	return apiKey, nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"songLibrary/initializers"
+	"songLibrary/models"
+	"songLibrary/providers"
+)
+
+// LookupSongDetail опрашивает провайдеров в порядке приоритета. В режиме priority
+// все поля отдаёт первый провайдер с непустым ответом, в режиме merge каждое поле
+// берётся у первого провайдера, который его заполнил. Provenance содержит источник каждого поля.
+func (l *Library) LookupSongDetail(ctx context.Context, groupName, title string) (SongDetail, models.Provenance, error) {
+	return l.lookupSongDetail(ctx, groupName, title, false)
+}
+
+// lookupSongDetail с fresh не использует неустаревшие сохранённые ответы
+func (l *Library) lookupSongDetail(ctx context.Context, groupName, title string, fresh bool) (SongDetail, models.Provenance, error) {
+	log := logger.Ctx(ctx).WithField("prefix", "LookupSongDetail")
+
+	var detail SongDetail
+	var provenance models.Provenance
+	var errs []error
+
+	for _, provider := range l.providers {
+		found, err := l.lookupCached(ctx, provider, groupName, title, fresh)
+		if err != nil {
+
+			log.WithError(err).WithField("provider", provider.Name()).Info("Провайдер не вернул данные") // Info-лог
+
+			errs = append(errs, err)
+			continue
+		}
+		if found.Empty() {
+			continue
+		}
+
+		if l.strategy == initializers.ProviderStrategyPriority {
+			provenance.Set(provider.Name(), filledFields(found)...)
+			return found, provenance, nil
+		}
+
+		if detail.ReleaseDate == "" && found.ReleaseDate != "" {
+			detail.ReleaseDate = found.ReleaseDate
+			provenance.Set(provider.Name(), providers.FieldReleaseDate)
+		}
+		if detail.Link == "" && found.Link != "" {
+			detail.Link = found.Link
+			provenance.Set(provider.Name(), providers.FieldLink)
+		}
+		if detail.Text == "" && found.Text != "" {
+			detail.Text = found.Text
+			provenance.Set(provider.Name(), providers.FieldLyrics)
+		}
+
+		if detail.Album == nil && found.Album != nil && found.Album.Title != "" {
+			detail.Album = found.Album
+			provenance.Set(provider.Name(), providers.FieldAlbum)
+		}
+
+		if detail.ReleaseDate != "" && detail.Link != "" && detail.Text != "" && detail.Album != nil {
+			break
+		}
+	}
+
+	if detail.Empty() && len(errs) > 0 {
+		return detail, nil, firstProviderError(errs)
+	}
+
+	return detail, provenance, nil
+}
+
+// firstProviderError предпочитает ошибки недоступности: "не найдено" у одного провайдера
+// не должно скрывать, что другой упал
+func firstProviderError(errs []error) error {
+	for _, err := range errs {
+		if isUpstreamUnavailable(err) {
+			return err
+		}
+	}
+	for _, err := range errs {
+		var apiErr *ExternalAPIError
+		if errors.As(err, &apiErr) {
+			return err
+		}
+	}
+	return errs[0]
+}
+
+// filledFields - непустые поля ответа
+func filledFields(detail SongDetail) []string {
+	values := detail.Fields()
+
+	var fields []string
+	for _, field := range []string{providers.FieldReleaseDate, providers.FieldLink, providers.FieldLyrics, providers.FieldAlbum} {
+		if values[field] != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
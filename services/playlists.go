@@ -0,0 +1,401 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"songLibrary/models"
+	"songLibrary/playlist"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPlaylistNotFound  = errors.New("плейлист не найден")
+	ErrPlaylistForbidden = errors.New("плейлист может менять только владелец")
+	ErrInvalidPlaylist   = errors.New("некорректный плейлист")
+	ErrPlaylistDuplicate = errors.New("песня уже есть в плейлисте")
+	ErrPlaylistFull      = errors.New("в плейлисте слишком много песен")
+	ErrEntryNotFound     = errors.New("песни нет в плейлисте")
+)
+
+var (
+	playlistVisibilities = []string{models.PlaylistPrivate, models.PlaylistShared, models.PlaylistPublic}
+	duplicatePolicies    = []string{models.DuplicatesAllow, models.DuplicatesSkip, models.DuplicatesReject}
+)
+
+const maxPlaylistEntries = 1000
+
+// canRead - владелец видит всё, остальные - публичные и shared по токену
+func canRead(p models.Playlist, actor, token string) bool {
+	switch {
+	case actor != "" && p.Owner == actor:
+		return true
+	case p.Visibility == models.PlaylistPublic:
+		return true
+	case p.Visibility == models.PlaylistShared:
+		return token != "" && token == p.ShareToken
+	}
+	return false
+}
+
+// hideToken убирает токен ссылки из ответа не владельцу
+func hideToken(p *models.Playlist, actor string) {
+	if actor == "" || p.Owner != actor {
+		p.ShareToken = ""
+	}
+}
+
+// validatePlaylist проверяет ввод и подставляет значения по умолчанию
+func validatePlaylist(input *models.PlaylistInput) error {
+	input.Name = strings.TrimSpace(input.Name)
+	input.Visibility = strings.ToLower(strings.TrimSpace(input.Visibility))
+	input.DuplicatePolicy = strings.ToLower(strings.TrimSpace(input.DuplicatePolicy))
+
+	if input.Visibility == "" {
+		input.Visibility = models.PlaylistPrivate
+	}
+	if input.DuplicatePolicy == "" {
+		input.DuplicatePolicy = models.DuplicatesAllow
+	}
+
+	if len(input.Name) < 1 || len(input.Name) > 255 {
+		return fmt.Errorf("%w: название пустое или слишком длинное", ErrInvalidPlaylist)
+	}
+	if !slices.Contains(playlistVisibilities, input.Visibility) {
+		return fmt.Errorf("%w: visibility должен быть одним из %s", ErrInvalidPlaylist, strings.Join(playlistVisibilities, ", "))
+	}
+	if !slices.Contains(duplicatePolicies, input.DuplicatePolicy) {
+		return fmt.Errorf("%w: duplicate_policy должен быть одним из %s", ErrInvalidPlaylist, strings.Join(duplicatePolicies, ", "))
+	}
+	return nil
+}
+
+// ownPlaylist загружает плейлист для изменения. Чужой плейлист, который актор видит, - ErrPlaylistForbidden,
+// невидимый - ErrPlaylistNotFound, чтобы не раскрывать его существование.
+func ownPlaylist(tx *gorm.DB, playlistID int, actor string) (models.Playlist, error) {
+	var p models.Playlist
+	if err := tx.First(&p, playlistID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return p, ErrPlaylistNotFound
+		}
+		return p, err
+	}
+	if actor == "" || p.Owner != actor {
+		if canRead(p, actor, "") {
+			return p, ErrPlaylistForbidden
+		}
+		return p, ErrPlaylistNotFound
+	}
+	return p, nil
+}
+
+// playlistEntries возвращает песни плейлиста по порядку
+func playlistEntries(tx *gorm.DB, playlistID int) ([]models.PlaylistEntry, error) {
+	var entries []models.PlaylistEntry
+	err := tx.Where("playlist_id = ?", playlistID).Order("position, id").Find(&entries).Error
+	return entries, err
+}
+
+// reorderEntries записывает позиции 1..n в порядке entries, обновляет только изменившиеся
+func reorderEntries(tx *gorm.DB, entries []models.PlaylistEntry) error {
+	for i := range entries {
+		if entries[i].Position == i+1 {
+			continue
+		}
+		entries[i].Position = i + 1
+		if err := tx.Model(&entries[i]).Update("position", entries[i].Position).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListPlaylists возвращает публичные плейлисты и плейлисты актора
+func (l *Library) ListPlaylists(ctx context.Context, actor string, page, limit int) (models.PlaylistsList, error) {
+	result := models.PlaylistsList{Page: page, Limit: limit}
+
+	query := l.DB(ctx).Model(&models.Playlist{})
+	if actor != "" {
+		query = query.Where("visibility = ? OR owner = ?", models.PlaylistPublic, actor)
+	} else {
+		query = query.Where("visibility = ?", models.PlaylistPublic)
+	}
+
+	if err := query.Count(&result.TotalCount).Error; err != nil {
+		return result, err
+	}
+	if err := query.Order("id").Offset((page - 1) * limit).Limit(limit).Find(&result.Data).Error; err != nil {
+		return result, err
+	}
+
+	for i := range result.Data {
+		hideToken(&result.Data[i], actor)
+	}
+	return result, nil
+}
+
+// CreatePlaylist создаёт плейлист актора
+func (l *Library) CreatePlaylist(ctx context.Context, actor string, input models.PlaylistInput) (models.Playlist, error) {
+	log := logger.Ctx(ctx).WithField("prefix", "CreatePlaylist")
+
+	if actor == "" {
+		return models.Playlist{}, ErrPlaylistForbidden
+	}
+	if err := validatePlaylist(&input); err != nil {
+		return models.Playlist{}, err
+	}
+
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return models.Playlist{}, err
+	}
+
+	p := models.Playlist{
+		Name:            input.Name,
+		Description:     input.Description,
+		Owner:           actor,
+		Visibility:      input.Visibility,
+		ShareToken:      hex.EncodeToString(raw),
+		DuplicatePolicy: input.DuplicatePolicy,
+	}
+
+	log.WithField("owner", actor).Info("Создаём плейлист") // Info-лог
+
+	return p, l.DB(ctx).Create(&p).Error
+}
+
+// GetPlaylist возвращает плейлист с песнями по порядку. Песни из корзины не показываются.
+func (l *Library) GetPlaylist(ctx context.Context, playlistID int, actor, token string) (models.Playlist, error) {
+	var p models.Playlist
+	err := l.DB(ctx).Preload("Entries", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position, id")
+	}).Preload("Entries.Song").First(&p, playlistID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return p, ErrPlaylistNotFound
+		}
+		return p, err
+	}
+	if !canRead(p, actor, token) {
+		return models.Playlist{}, ErrPlaylistNotFound
+	}
+
+	p.Entries = slices.DeleteFunc(p.Entries, func(entry models.PlaylistEntry) bool { return entry.Song == nil })
+	hideToken(&p, actor)
+
+	return p, nil
+}
+
+// UpdatePlaylist заменяет название, описание, видимость и политику повторов
+func (l *Library) UpdatePlaylist(ctx context.Context, playlistID int, actor string, input models.PlaylistInput) (models.Playlist, error) {
+	if err := validatePlaylist(&input); err != nil {
+		return models.Playlist{}, err
+	}
+
+	p, err := ownPlaylist(l.DB(ctx), playlistID, actor)
+	if err != nil {
+		return p, err
+	}
+
+	p.Name = input.Name
+	p.Description = input.Description
+	p.Visibility = input.Visibility
+	p.DuplicatePolicy = input.DuplicatePolicy
+
+	return p, l.DB(ctx).Save(&p).Error
+}
+
+// DeletePlaylist удаляет плейлист вместе с его песнями
+func (l *Library) DeletePlaylist(ctx context.Context, playlistID int, actor string) error {
+	return l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		p, err := ownPlaylist(tx, playlistID, actor)
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("playlist_id = ?", p.ID).Delete(&models.PlaylistEntry{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&p).Error
+	})
+}
+
+// AddPlaylistEntry добавляет песню на позицию или в конец. Повтор песни обрабатывается
+// по политике плейлиста: allow добавляет, skip ничего не меняет, reject - ErrPlaylistDuplicate.
+func (l *Library) AddPlaylistEntry(ctx context.Context, playlistID int, actor string, input models.PlaylistEntryInput) (models.Playlist, error) {
+	log := logger.Ctx(ctx).WithField("prefix", "AddPlaylistEntry")
+
+	err := l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		p, err := ownPlaylist(tx, playlistID, actor)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Select("id").First(&models.Song{}, input.SongID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrSongNotFound
+			}
+			return err
+		}
+
+		entries, err := playlistEntries(tx, p.ID)
+		if err != nil {
+			return err
+		}
+
+		if slices.ContainsFunc(entries, func(entry models.PlaylistEntry) bool { return entry.SongID == input.SongID }) {
+			switch p.DuplicatePolicy {
+			case models.DuplicatesSkip:
+
+				log.WithField("song.id", input.SongID).Debug("Песня уже в плейлисте, пропускаем") // Debug-лог
+
+				return nil
+			case models.DuplicatesReject:
+				return ErrPlaylistDuplicate
+			}
+		}
+		if len(entries) >= maxPlaylistEntries {
+			return fmt.Errorf("%w: не больше %d", ErrPlaylistFull, maxPlaylistEntries)
+		}
+
+		position := input.Position
+		if position < 1 || position > len(entries)+1 {
+			position = len(entries) + 1
+		}
+
+		entry := models.PlaylistEntry{PlaylistID: p.ID, SongID: input.SongID, Position: position}
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+
+		entries = slices.Insert(entries, position-1, entry)
+		return reorderEntries(tx, entries)
+	})
+	if err != nil {
+		return models.Playlist{}, err
+	}
+
+	return l.GetPlaylist(ctx, playlistID, actor, "")
+}
+
+// RemovePlaylistEntry убирает песню из плейлиста, позиции остальных сдвигаются
+func (l *Library) RemovePlaylistEntry(ctx context.Context, playlistID int, actor string, entryID int) (models.Playlist, error) {
+	err := l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		p, err := ownPlaylist(tx, playlistID, actor)
+		if err != nil {
+			return err
+		}
+
+		entries, err := playlistEntries(tx, p.ID)
+		if err != nil {
+			return err
+		}
+		i := slices.IndexFunc(entries, func(entry models.PlaylistEntry) bool { return entry.ID == entryID })
+		if i < 0 {
+			return ErrEntryNotFound
+		}
+
+		if err := tx.Unscoped().Delete(&entries[i]).Error; err != nil {
+			return err
+		}
+		return reorderEntries(tx, slices.Delete(entries, i, i+1))
+	})
+	if err != nil {
+		return models.Playlist{}, err
+	}
+
+	return l.GetPlaylist(ctx, playlistID, actor, "")
+}
+
+// MovePlaylistEntry переносит песню на позицию, позиция за концом - в конец
+func (l *Library) MovePlaylistEntry(ctx context.Context, playlistID int, actor string, entryID, position int) (models.Playlist, error) {
+	if position < 1 {
+		return models.Playlist{}, fmt.Errorf("%w: позиция начинается с 1", ErrInvalidPlaylist)
+	}
+
+	err := l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		p, err := ownPlaylist(tx, playlistID, actor)
+		if err != nil {
+			return err
+		}
+
+		entries, err := playlistEntries(tx, p.ID)
+		if err != nil {
+			return err
+		}
+		i := slices.IndexFunc(entries, func(entry models.PlaylistEntry) bool { return entry.ID == entryID })
+		if i < 0 {
+			return ErrEntryNotFound
+		}
+
+		entry := entries[i]
+		entries = slices.Delete(entries, i, i+1)
+		entries = slices.Insert(entries, min(position, len(entries)+1)-1, entry)
+		return reorderEntries(tx, entries)
+	})
+	if err != nil {
+		return models.Playlist{}, err
+	}
+
+	return l.GetPlaylist(ctx, playlistID, actor, "")
+}
+
+// PlaylistTracks собирает песни плейлиста для экспорта. Ссылка берётся основная,
+// без неё - первая рабочая из ссылок песни.
+func (l *Library) PlaylistTracks(ctx context.Context, playlistID int, actor, token string) (models.Playlist, []playlist.Track, error) {
+	p, err := l.GetPlaylist(ctx, playlistID, actor, token)
+	if err != nil {
+		return p, nil, err
+	}
+
+	songIDs := make([]int, 0, len(p.Entries))
+	for _, entry := range p.Entries {
+		songIDs = append(songIDs, entry.SongID)
+	}
+
+	var songs []models.Song
+	err = l.DB(ctx).Preload("Album").Preload("Links", func(db *gorm.DB) *gorm.DB {
+		return db.Where("NOT broken").Order("id")
+	}).Where("id IN ?", songIDs).Find(&songs).Error
+	if err != nil {
+		return p, nil, err
+	}
+
+	groupIDs := make([]int, 0, len(songs))
+	byID := make(map[int]models.Song, len(songs))
+	for _, song := range songs {
+		byID[song.ID] = song
+		groupIDs = append(groupIDs, song.GroupID)
+	}
+
+	var groups []models.Group
+	if err := l.DB(ctx).Unscoped().Where("id IN ?", groupIDs).Find(&groups).Error; err != nil {
+		return p, nil, err
+	}
+	groupNames := make(map[int]string, len(groups))
+	for _, group := range groups {
+		groupNames[group.ID] = group.Name
+	}
+
+	tracks := make([]playlist.Track, 0, len(p.Entries))
+	for _, entry := range p.Entries {
+		song := byID[entry.SongID]
+		track := playlist.Track{Title: song.Title, Creator: groupNames[song.GroupID], Location: song.Link}
+		if track.Location == "" && len(song.Links) > 0 {
+			track.Location = song.Links[0].URL
+		}
+		if song.Album != nil {
+			track.Album = song.Album.Title
+		}
+		if song.TrackNumber != nil {
+			track.TrackNum = *song.TrackNumber
+		}
+		tracks = append(tracks, track)
+	}
+
+	return p, tracks, nil
+}
@@ -0,0 +1,342 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"songLibrary/initializers"
+	"songLibrary/lyrics"
+	"songLibrary/models"
+	"songLibrary/providers"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrProposalNotFound = errors.New("предложение не найдено")
+	ErrProposalResolved = errors.New("предложение уже рассмотрено")
+)
+
+type RefreshResult struct {
+	Checked  int              `json:"checked"`
+	Updated  int              `json:"updated"`
+	Proposed int              `json:"proposed"`
+	Failed   int              `json:"failed"`
+	Errors   []string         `json:"errors,omitempty"`
+	Changes  map[int][]string `json:"changes,omitempty"`
+}
+
+// Refresh сверяет с провайдерами до limit песен, которые не сверялись дольше maxAge.
+// Несколько экземпляров сервиса не берут одни и те же песни.
+func (l *Library) Refresh(ctx context.Context, maxAge time.Duration, limit int) (RefreshResult, error) {
+	log := logger.Ctx(ctx).WithField("prefix", "Refresh")
+
+	result := RefreshResult{Changes: map[int][]string{}}
+
+	songs, err := l.claimStaleSongs(ctx, maxAge, limit)
+	if err != nil {
+		return result, err
+	}
+
+	log.WithField("count", len(songs)).Info("Найдены песни для сверки") // Info-лог
+
+	for i := range songs {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+
+		result.Checked++
+
+		applied, proposed, err := l.RefreshSong(ctx, &songs[i])
+		if err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("song %d: %s", songs[i].ID, err))
+			continue
+		}
+		if len(applied) > 0 {
+			result.Updated++
+			result.Changes[songs[i].ID] = applied
+		}
+		result.Proposed += proposed
+	}
+
+	return result, nil
+}
+
+// claimStaleSongs выбирает песни для сверки и сразу отмечает их, пропуская заблокированные другим экземпляром
+func (l *Library) claimStaleSongs(ctx context.Context, maxAge time.Duration, limit int) ([]models.Song, error) {
+	var songs []models.Song
+
+	err := l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("COALESCE(refreshed_at, created_at) < ?", time.Now().Add(-maxAge)).
+			Order("COALESCE(refreshed_at, created_at)").
+			Limit(limit).
+			Find(&songs).Error
+		if err != nil || len(songs) == 0 {
+			return err
+		}
+
+		now := time.Now()
+		ids := make([]int, len(songs))
+		for i := range songs {
+			ids[i] = songs[i].ID
+			songs[i].RefreshedAt = &now
+		}
+		return tx.Model(&models.Song{}).Where("id IN ?", ids).UpdateColumn("refreshed_at", now).Error
+	})
+
+	return songs, err
+}
+
+// RefreshSong запрашивает свежие данные у провайдеров и сравнивает с сохранёнными.
+// Изменения полей, которые не правили вручную, применяются сразу, для остальных
+// создаются предложения на ревью. Возвращает применённые поля и число новых предложений.
+func (l *Library) RefreshSong(ctx context.Context, song *models.Song) ([]string, int, error) {
+	log := logger.Ctx(ctx).WithField("prefix", "RefreshSong").WithField("song.id", song.ID)
+
+	var group models.Group
+	if err := l.DB(ctx).First(&group, song.GroupID).Error; err != nil {
+		return nil, 0, err
+	}
+
+	detail, sources, err := l.lookupSongDetail(ctx, group.Name, song.Title, true)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var stored []models.Lyrics
+	if err := l.DB(ctx).Where("song_id = ?", song.ID).Order("\"order\"").Find(&stored).Error; err != nil {
+		return nil, 0, err
+	}
+	verses := make([]string, 0, len(stored))
+	for _, verse := range stored {
+		verses = append(verses, verse.Verse)
+	}
+
+	current := map[string]string{
+		providers.FieldReleaseDate: song.ReleaseDate,
+		providers.FieldLink:        song.Link,
+		providers.FieldLyrics:      strings.Join(verses, "\n\n"),
+	}
+	// Текст сравниваем после разбиения на куплеты, чтобы не реагировать на переносы строк
+	upstream := map[string]string{
+		providers.FieldReleaseDate: detail.ReleaseDate,
+		providers.FieldLink:        detail.Link,
+		providers.FieldLyrics:      strings.Join(lyrics.Verses(detail.Text), "\n\n"),
+	}
+
+	var applied []string
+	var proposed int
+	var text *string
+
+	for _, field := range []string{providers.FieldReleaseDate, providers.FieldLink, providers.FieldLyrics} {
+		value := upstream[field]
+		if value == "" || value == current[field] {
+			continue
+		}
+
+		if song.Provenance[field] == models.ProvenanceManual {
+			created, err := l.proposeChange(ctx, song.ID, field, current[field], value, sources[field])
+			if err != nil {
+				return applied, proposed, err
+			}
+			if created {
+				proposed++
+			}
+			continue
+		}
+
+		switch field {
+		case providers.FieldReleaseDate:
+			song.ReleaseDate = value
+		case providers.FieldLink:
+			song.Link = value
+		case providers.FieldLyrics:
+			text = &detail.Text
+		}
+		song.Provenance.Set(sources[field], field)
+		applied = append(applied, field)
+	}
+
+	if len(applied) == 0 {
+		return nil, proposed, nil
+	}
+
+	log.WithField("changed", applied).Info("Применяем изменения от провайдеров") // Info-лог
+
+	err = l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		return l.saveSong(tx, song, text)
+	})
+	if err != nil {
+		return nil, proposed, err
+	}
+
+	l.InvalidateSong(ctx, song.ID, song.GroupID)
+
+	return applied, proposed, nil
+}
+
+// proposeChange создаёт или обновляет ожидающее предложение для поля.
+// Значение, которое уже отклоняли, повторно не предлагается.
+func (l *Library) proposeChange(ctx context.Context, songID int, field, currentValue, proposedValue, provider string) (bool, error) {
+	var rejected int64
+	err := l.DB(ctx).Model(&models.SongProposal{}).
+		Where("song_id = ? AND field = ? AND status = ? AND proposed_value = ?", songID, field, models.ProposalRejected, proposedValue).
+		Count(&rejected).Error
+	if err != nil || rejected > 0 {
+		return false, err
+	}
+
+	var proposal models.SongProposal
+	err = l.DB(ctx).Where("song_id = ? AND field = ? AND status = ?", songID, field, models.ProposalPending).First(&proposal).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+	if err == nil && proposal.ProposedValue == proposedValue && proposal.CurrentValue == currentValue {
+		return false, nil
+	}
+
+	logger.Ctx(ctx).WithField("song.id", songID).WithField("field", field).Info("Изменение поля отправлено на ревью") // Info-лог
+
+	proposal.SongID = songID
+	proposal.Field = field
+	proposal.CurrentValue = currentValue
+	proposal.ProposedValue = proposedValue
+	proposal.Provider = provider
+	proposal.Status = models.ProposalPending
+
+	return true, l.DB(ctx).Save(&proposal).Error
+}
+
+// ListProposals возвращает предложения с фильтром по статусу и песне
+func (l *Library) ListProposals(ctx context.Context, status string, songID int, page, limit int) (models.SongProposalsList, error) {
+	result := models.SongProposalsList{Page: page, Limit: limit}
+
+	query := l.DB(ctx).Model(&models.SongProposal{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if songID != 0 {
+		query = query.Where("song_id = ?", songID)
+	}
+
+	if err := query.Count(&result.TotalCount).Error; err != nil {
+		return result, err
+	}
+
+	err := query.Order("id").Offset((page - 1) * limit).Limit(limit).Find(&result.Data).Error
+	return result, err
+}
+
+// ResolveProposal принимает или отклоняет предложение. Принятое значение
+// записывается в песню, источником поля становится провайдер.
+func (l *Library) ResolveProposal(ctx context.Context, id int, accept bool, actor string) (models.SongProposal, error) {
+	log := logger.Ctx(ctx).WithField("prefix", "ResolveProposal").WithField("proposal.id", id)
+
+	var proposal models.SongProposal
+	var song models.Song
+
+	err := l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&proposal, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrProposalNotFound
+			}
+			return err
+		}
+		if proposal.Status != models.ProposalPending {
+			return ErrProposalResolved
+		}
+
+		now := time.Now()
+		proposal.Status = models.ProposalRejected
+		proposal.ResolvedBy = actor
+		proposal.ResolvedAt = &now
+
+		if accept {
+			proposal.Status = models.ProposalAccepted
+
+			if err := tx.First(&song, proposal.SongID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrSongNotFound
+				}
+				return err
+			}
+
+			var text *string
+			switch proposal.Field {
+			case providers.FieldReleaseDate:
+				song.ReleaseDate = proposal.ProposedValue
+			case providers.FieldLink:
+				song.Link = proposal.ProposedValue
+			case providers.FieldLyrics:
+				text = &proposal.ProposedValue
+			}
+			song.Provenance.Set(proposal.Provider, proposal.Field)
+
+			if err := l.saveSong(tx, &song, text); err != nil {
+				return err
+			}
+		}
+
+		log.WithField("status", proposal.Status).Info("Предложение рассмотрено") // Info-лог
+
+		return tx.Save(&proposal).Error
+	})
+	if err != nil {
+		return proposal, err
+	}
+
+	if accept {
+		l.InvalidateSong(ctx, song.ID, song.GroupID)
+	}
+
+	return proposal, nil
+}
+
+// RefreshWorker периодически запускает Refresh
+type RefreshWorker struct {
+	library *Library
+	config  initializers.RefreshConfig
+}
+
+func NewRefreshWorker(library *Library, config initializers.RefreshConfig) *RefreshWorker {
+	return &RefreshWorker{library: library, config: config}
+}
+
+func (w *RefreshWorker) Name() string {
+	return "refresh"
+}
+
+func (w *RefreshWorker) Run(ctx context.Context) error {
+	log := logger.Ctx(ctx).WithField("prefix", "RefreshWorker")
+
+	ticker := time.NewTicker(w.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		result, err := w.library.Refresh(ctx, w.config.MaxAge, w.config.BatchSize)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return err
+			}
+			log.WithError(err).Error("Проход сверки завершился с ошибкой")
+			continue
+		}
+
+		log.WithField("checked", result.Checked).
+			WithField("updated", result.Updated).
+			WithField("proposed", result.Proposed).
+			WithField("failed", result.Failed).
+			Info("Проход сверки завершён") // Info-лог
+	}
+}
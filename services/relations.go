@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"songLibrary/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRelationNotFound = errors.New("связь не найдена")
+	ErrRelationExists   = errors.New("связь уже добавлена")
+	ErrInvalidRelation  = errors.New("некорректная связь")
+)
+
+var relationTypes = []string{models.RelationCover, models.RelationRemix, models.RelationLive, models.RelationSample, models.RelationTranslation}
+
+const (
+	// Сколько шагов к оригиналу проходим в поисках текста
+	maxLyricsInheritance = 5
+	// Больше версий в ответе не отдаём
+	maxVersions = 200
+)
+
+// AddRelation связывает песню songID с оригиналом input.RelatedID
+func (l *Library) AddRelation(ctx context.Context, songID int, input models.RelationInput) (models.SongRelation, error) {
+	log := logger.Ctx(ctx).WithField("prefix", "AddRelation")
+
+	relation := models.SongRelation{SongID: songID, RelatedID: input.RelatedID, Type: strings.ToLower(strings.TrimSpace(input.Type)), InheritLyrics: input.InheritLyrics}
+
+	if !slices.Contains(relationTypes, relation.Type) {
+		return relation, fmt.Errorf("%w: тип должен быть одним из %s", ErrInvalidRelation, strings.Join(relationTypes, ", "))
+	}
+	if relation.RelatedID == songID {
+		return relation, fmt.Errorf("%w: песня не может быть версией самой себя", ErrInvalidRelation)
+	}
+
+	var songs []models.Song
+	if err := l.DB(ctx).Where("id IN ?", []int{songID, relation.RelatedID}).Find(&songs).Error; err != nil {
+		return relation, err
+	}
+	if len(songs) != 2 {
+		return relation, ErrSongNotFound
+	}
+
+	log.WithField("related.id", relation.RelatedID).WithField("type", relation.Type).Info("Связываем версии песни") // Info-лог
+
+	err := l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		err := tx.Model(&models.SongRelation{}).Where("song_id = ? AND related_id = ? AND type = ?", songID, relation.RelatedID, relation.Type).Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrRelationExists
+		}
+
+		// Оригинал не может оказаться версией собственной версии
+		ancestors, err := originalsOf(tx, relation.RelatedID)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(ancestors, songID) {
+			return fmt.Errorf("%w: связь замыкает цикл версий", ErrInvalidRelation)
+		}
+
+		return tx.Create(&relation).Error
+	})
+	if err != nil {
+		return relation, err
+	}
+
+	l.InvalidateSong(ctx, songID)
+
+	return relation, nil
+}
+
+// originalsOf возвращает все песни, версией которых songID является прямо или через другие версии
+func originalsOf(tx *gorm.DB, songID int) ([]int, error) {
+	var result []int
+	seen := map[int]bool{songID: true}
+	frontier := []int{songID}
+
+	for len(frontier) > 0 {
+		var related []int
+		if err := tx.Model(&models.SongRelation{}).Where("song_id IN ?", frontier).Pluck("related_id", &related).Error; err != nil {
+			return nil, err
+		}
+
+		frontier = frontier[:0]
+		for _, id := range related {
+			if !seen[id] {
+				seen[id] = true
+				result = append(result, id)
+				frontier = append(frontier, id)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// DeleteRelation удаляет связь песни, в которой она версия или оригинал
+func (l *Library) DeleteRelation(ctx context.Context, songID, relationID int) error {
+	var relation models.SongRelation
+	if err := l.DB(ctx).Where("id = ? AND (song_id = ? OR related_id = ?)", relationID, songID, songID).First(&relation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRelationNotFound
+		}
+		return err
+	}
+
+	if err := l.DB(ctx).Unscoped().Delete(&relation).Error; err != nil {
+		return err
+	}
+
+	l.InvalidateSong(ctx, relation.SongID)
+
+	return nil
+}
+
+// Versions обходит связи песни в обе стороны и возвращает все версии, до которых можно дойти
+func (l *Library) Versions(ctx context.Context, songID int) (models.SongVersions, error) {
+	log := logger.Ctx(ctx).WithField("prefix", "Versions")
+
+	result := models.SongVersions{Originals: []int{}, Songs: []models.Song{}, Relations: []models.SongRelation{}}
+
+	if err := l.DB(ctx).Select("id").First(&models.Song{}, songID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return result, ErrSongNotFound
+		}
+		return result, err
+	}
+
+	order := []int{songID}
+	seen := map[int]bool{songID: true}
+	seenRelations := map[int]bool{}
+	frontier := []int{songID}
+
+	for len(frontier) > 0 && len(order) < maxVersions {
+		var relations []models.SongRelation
+		if err := l.DB(ctx).Where("song_id IN ? OR related_id IN ?", frontier, frontier).Order("id").Find(&relations).Error; err != nil {
+			return result, err
+		}
+
+		frontier = frontier[:0]
+		for _, relation := range relations {
+			if seenRelations[relation.ID] {
+				continue
+			}
+			seenRelations[relation.ID] = true
+			result.Relations = append(result.Relations, relation)
+
+			for _, id := range []int{relation.SongID, relation.RelatedID} {
+				if !seen[id] && len(order) < maxVersions {
+					seen[id] = true
+					order = append(order, id)
+					frontier = append(frontier, id)
+				}
+			}
+		}
+	}
+
+	log.WithField("count", len(order)).Debug("Версии песни") // Debug-лог
+
+	var songs []models.Song
+	if err := l.DB(ctx).Preload("Credits.Group").Where("id IN ?", order).Find(&songs).Error; err != nil {
+		return result, err
+	}
+
+	// Песни в порядке обхода: сначала запрошенная, потом ближайшие к ней версии
+	byID := make(map[int]models.Song, len(songs))
+	for _, song := range songs {
+		byID[song.ID] = song
+	}
+	derived := map[int]bool{}
+	for _, relation := range result.Relations {
+		derived[relation.SongID] = true
+	}
+	for _, id := range order {
+		song, ok := byID[id]
+		if !ok {
+			continue
+		}
+		result.Songs = append(result.Songs, song)
+		if !derived[id] {
+			result.Originals = append(result.Originals, id)
+		}
+	}
+
+	// Связи с удалёнными песнями не показываем
+	result.Relations = slices.DeleteFunc(result.Relations, func(relation models.SongRelation) bool {
+		_, song := byID[relation.SongID]
+		_, related := byID[relation.RelatedID]
+		return !song || !related
+	})
+
+	return result, nil
+}
+
+// LyricsSource возвращает песню, чей текст нужно отдавать вместо текста songID. Если у песни
+// есть свой текст или она не наследует текст, возвращается сама песня. Иначе поднимаемся
+// по связям с inherit_lyrics к ближайшему оригиналу с текстом.
+func (l *Library) LyricsSource(ctx context.Context, songID int) (int, error) {
+	current := songID
+	seen := map[int]bool{}
+
+	for step := 0; step <= maxLyricsInheritance; step++ {
+		seen[current] = true
+
+		var verses int64
+		if err := l.DB(ctx).Model(&models.Lyrics{}).Where("song_id = ?", current).Count(&verses).Error; err != nil {
+			return songID, err
+		}
+		if verses > 0 {
+			return current, nil
+		}
+
+		var relation models.SongRelation
+		err := l.DB(ctx).Joins("JOIN songs ON songs.id = song_relations.related_id AND songs.deleted_at IS NULL").
+			Where("song_relations.song_id = ? AND song_relations.inherit_lyrics", current).
+			Order("song_relations.id").First(&relation).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) || seen[relation.RelatedID] {
+			return songID, nil
+		}
+		if err != nil {
+			return songID, err
+		}
+		current = relation.RelatedID
+	}
+
+	return songID, nil
+}
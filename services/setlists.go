@@ -0,0 +1,373 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"songLibrary/chords"
+	"songLibrary/models"
+	"songLibrary/setlist"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSetlistNotFound      = errors.New("сетлист не найден")
+	ErrInvalidSetlist       = errors.New("некорректный сетлист")
+	ErrSetlistFull          = errors.New("в сетлисте слишком много песен")
+	ErrSetlistEntryNotFound = errors.New("песни нет в сетлисте")
+)
+
+const (
+	maxSetlistEntries = 200
+	// Больше трёх часов на одну песню не бывает
+	maxEntryDurationSec = 3 * 60 * 60
+)
+
+// validateSetlist проверяет название, площадку и дату
+func validateSetlist(input *models.SetlistInput) error {
+	input.Name = strings.TrimSpace(input.Name)
+	input.Venue = strings.TrimSpace(input.Venue)
+	input.Date = strings.TrimSpace(input.Date)
+
+	if len(input.Name) < 1 || len(input.Name) > 255 {
+		return fmt.Errorf("%w: название пустое или слишком длинное", ErrInvalidSetlist)
+	}
+	if len(input.Venue) > 255 {
+		return fmt.Errorf("%w: название площадки слишком длинное", ErrInvalidSetlist)
+	}
+	if input.Date != "" {
+		if _, err := time.Parse("02.01.2006", input.Date); err != nil {
+			return fmt.Errorf("%w: date должен быть в формате dd.MM.yyyy", ErrInvalidSetlist)
+		}
+	}
+	return nil
+}
+
+// validateSetlistEntry проверяет заметки, тональность и длительность песни сетлиста
+func validateSetlistEntry(update *models.SetlistEntryUpdate) error {
+	update.Notes = strings.TrimSpace(update.Notes)
+	update.Key = strings.TrimSpace(update.Key)
+
+	if len(update.Notes) > 2000 {
+		return fmt.Errorf("%w: заметки слишком длинные", ErrInvalidSetlist)
+	}
+	if update.Key != "" && (len(update.Key) > 20 || !chords.ValidKey(update.Key)) {
+		return fmt.Errorf("%w: тональность не разобралась: %s", ErrInvalidSetlist, update.Key)
+	}
+	if update.DurationSec != nil && (*update.DurationSec < 1 || *update.DurationSec > maxEntryDurationSec) {
+		return fmt.Errorf("%w: duration_sec должна быть от 1 до %d", ErrInvalidSetlist, maxEntryDurationSec)
+	}
+	return nil
+}
+
+// ownSetlist загружает сетлист актора. Чужие сетлисты не видны, поэтому для них ErrSetlistNotFound.
+func ownSetlist(tx *gorm.DB, setlistID int, actor string) (models.Setlist, error) {
+	var s models.Setlist
+	if err := tx.Where("owner = ?", actor).First(&s, setlistID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return s, ErrSetlistNotFound
+		}
+		return s, err
+	}
+	return s, nil
+}
+
+// setlistEntries возвращает песни сетлиста по порядку
+func setlistEntries(tx *gorm.DB, setlistID int) ([]models.SetlistEntry, error) {
+	var entries []models.SetlistEntry
+	err := tx.Where("setlist_id = ?", setlistID).Order("position, id").Find(&entries).Error
+	return entries, err
+}
+
+// reorderSetlistEntries записывает позиции 1..n в порядке entries, обновляет только изменившиеся
+func reorderSetlistEntries(tx *gorm.DB, entries []models.SetlistEntry) error {
+	for i := range entries {
+		if entries[i].Position == i+1 {
+			continue
+		}
+		entries[i].Position = i + 1
+		if err := tx.Model(&entries[i]).Update("position", entries[i].Position).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setlistTotals считает общую длительность по песням с указанной длительностью
+func setlistTotals(s *models.Setlist) {
+	s.TotalDurationSec, s.UntimedEntries = 0, 0
+	for _, entry := range s.Entries {
+		if entry.DurationSec == nil {
+			s.UntimedEntries++
+			continue
+		}
+		s.TotalDurationSec += *entry.DurationSec
+	}
+}
+
+// ListSetlists возвращает сетлисты актора без песен
+func (l *Library) ListSetlists(ctx context.Context, actor string, page, limit int) (models.SetlistsList, error) {
+	result := models.SetlistsList{Page: page, Limit: limit}
+
+	query := l.DB(ctx).Model(&models.Setlist{}).Where("owner = ?", actor)
+	if err := query.Count(&result.TotalCount).Error; err != nil {
+		return result, err
+	}
+	err := query.Order("id").Offset((page - 1) * limit).Limit(limit).Find(&result.Data).Error
+	return result, err
+}
+
+// CreateSetlist создаёт пустой сетлист актора
+func (l *Library) CreateSetlist(ctx context.Context, actor string, input models.SetlistInput) (models.Setlist, error) {
+	log := logger.Ctx(ctx).WithField("prefix", "CreateSetlist")
+
+	if err := validateSetlist(&input); err != nil {
+		return models.Setlist{}, err
+	}
+
+	s := models.Setlist{Name: input.Name, Venue: input.Venue, Date: input.Date, Owner: actor}
+
+	log.WithField("owner", actor).Info("Создаём сетлист") // Info-лог
+
+	return s, l.DB(ctx).Create(&s).Error
+}
+
+// GetSetlist возвращает сетлист с песнями по порядку и общей длительностью.
+// Песни из корзины не показываются и в длительность не входят.
+func (l *Library) GetSetlist(ctx context.Context, setlistID int, actor string) (models.Setlist, error) {
+	var s models.Setlist
+	err := l.DB(ctx).Preload("Entries", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position, id")
+	}).Preload("Entries.Song").Where("owner = ?", actor).First(&s, setlistID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return s, ErrSetlistNotFound
+		}
+		return s, err
+	}
+
+	s.Entries = slices.DeleteFunc(s.Entries, func(entry models.SetlistEntry) bool { return entry.Song == nil })
+	setlistTotals(&s)
+
+	return s, nil
+}
+
+// UpdateSetlist заменяет название, площадку и дату
+func (l *Library) UpdateSetlist(ctx context.Context, setlistID int, actor string, input models.SetlistInput) (models.Setlist, error) {
+	if err := validateSetlist(&input); err != nil {
+		return models.Setlist{}, err
+	}
+
+	s, err := ownSetlist(l.DB(ctx), setlistID, actor)
+	if err != nil {
+		return s, err
+	}
+
+	s.Name = input.Name
+	s.Venue = input.Venue
+	s.Date = input.Date
+	if err := l.DB(ctx).Save(&s).Error; err != nil {
+		return s, err
+	}
+
+	return l.GetSetlist(ctx, setlistID, actor)
+}
+
+// DeleteSetlist удаляет сетлист вместе с его песнями
+func (l *Library) DeleteSetlist(ctx context.Context, setlistID int, actor string) error {
+	return l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		s, err := ownSetlist(tx, setlistID, actor)
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("setlist_id = ?", s.ID).Delete(&models.SetlistEntry{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&s).Error
+	})
+}
+
+// AddSetlistEntry добавляет песню на позицию или в конец. Одна песня может звучать в сетлисте дважды.
+func (l *Library) AddSetlistEntry(ctx context.Context, setlistID int, actor string, input models.SetlistEntryInput) (models.Setlist, error) {
+	update := models.SetlistEntryUpdate{Notes: input.Notes, Key: input.Key, DurationSec: input.DurationSec}
+	if err := validateSetlistEntry(&update); err != nil {
+		return models.Setlist{}, err
+	}
+
+	err := l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		s, err := ownSetlist(tx, setlistID, actor)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Select("id").First(&models.Song{}, input.SongID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrSongNotFound
+			}
+			return err
+		}
+
+		entries, err := setlistEntries(tx, s.ID)
+		if err != nil {
+			return err
+		}
+		if len(entries) >= maxSetlistEntries {
+			return fmt.Errorf("%w: не больше %d", ErrSetlistFull, maxSetlistEntries)
+		}
+
+		position := input.Position
+		if position < 1 || position > len(entries)+1 {
+			position = len(entries) + 1
+		}
+
+		entry := models.SetlistEntry{
+			SetlistID:   s.ID,
+			SongID:      input.SongID,
+			Position:    position,
+			Notes:       update.Notes,
+			Key:         update.Key,
+			DurationSec: update.DurationSec,
+		}
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+
+		entries = slices.Insert(entries, position-1, entry)
+		return reorderSetlistEntries(tx, entries)
+	})
+	if err != nil {
+		return models.Setlist{}, err
+	}
+
+	return l.GetSetlist(ctx, setlistID, actor)
+}
+
+// UpdateSetlistEntry заменяет заметки, тональность и длительность песни сетлиста
+func (l *Library) UpdateSetlistEntry(ctx context.Context, setlistID int, actor string, entryID int, update models.SetlistEntryUpdate) (models.Setlist, error) {
+	if err := validateSetlistEntry(&update); err != nil {
+		return models.Setlist{}, err
+	}
+
+	err := l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		s, err := ownSetlist(tx, setlistID, actor)
+		if err != nil {
+			return err
+		}
+
+		var entry models.SetlistEntry
+		if err := tx.Where("setlist_id = ?", s.ID).First(&entry, entryID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrSetlistEntryNotFound
+			}
+			return err
+		}
+
+		return tx.Model(&entry).Select("notes", "key", "duration_sec").Updates(models.SetlistEntry{
+			Notes:       update.Notes,
+			Key:         update.Key,
+			DurationSec: update.DurationSec,
+		}).Error
+	})
+	if err != nil {
+		return models.Setlist{}, err
+	}
+
+	return l.GetSetlist(ctx, setlistID, actor)
+}
+
+// RemoveSetlistEntry убирает песню из сетлиста, позиции остальных сдвигаются
+func (l *Library) RemoveSetlistEntry(ctx context.Context, setlistID int, actor string, entryID int) (models.Setlist, error) {
+	err := l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		s, err := ownSetlist(tx, setlistID, actor)
+		if err != nil {
+			return err
+		}
+
+		entries, err := setlistEntries(tx, s.ID)
+		if err != nil {
+			return err
+		}
+		i := slices.IndexFunc(entries, func(entry models.SetlistEntry) bool { return entry.ID == entryID })
+		if i < 0 {
+			return ErrSetlistEntryNotFound
+		}
+
+		if err := tx.Unscoped().Delete(&entries[i]).Error; err != nil {
+			return err
+		}
+		return reorderSetlistEntries(tx, slices.Delete(entries, i, i+1))
+	})
+	if err != nil {
+		return models.Setlist{}, err
+	}
+
+	return l.GetSetlist(ctx, setlistID, actor)
+}
+
+// MoveSetlistEntry переносит песню на позицию, позиция за концом - в конец
+func (l *Library) MoveSetlistEntry(ctx context.Context, setlistID int, actor string, entryID, position int) (models.Setlist, error) {
+	if position < 1 {
+		return models.Setlist{}, fmt.Errorf("%w: позиция начинается с 1", ErrInvalidSetlist)
+	}
+
+	err := l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		s, err := ownSetlist(tx, setlistID, actor)
+		if err != nil {
+			return err
+		}
+
+		entries, err := setlistEntries(tx, s.ID)
+		if err != nil {
+			return err
+		}
+		i := slices.IndexFunc(entries, func(entry models.SetlistEntry) bool { return entry.ID == entryID })
+		if i < 0 {
+			return ErrSetlistEntryNotFound
+		}
+
+		entry := entries[i]
+		entries = slices.Delete(entries, i, i+1)
+		entries = slices.Insert(entries, min(position, len(entries)+1)-1, entry)
+		return reorderSetlistEntries(tx, entries)
+	})
+	if err != nil {
+		return models.Setlist{}, err
+	}
+
+	return l.GetSetlist(ctx, setlistID, actor)
+}
+
+// PrintSetlist собирает печатную версию: у песни с ChordPro - аккорды в тональности выступления,
+// у остальных - текст, свой или унаследованный от оригинала
+func (l *Library) PrintSetlist(ctx context.Context, setlistID int, actor string) (setlist.Document, error) {
+	log := logger.Ctx(ctx).WithField("prefix", "PrintSetlist")
+
+	s, err := l.GetSetlist(ctx, setlistID, actor)
+	if err != nil {
+		return setlist.Document{}, err
+	}
+
+	doc := setlist.Document{Name: s.Name, Venue: s.Venue, Date: s.Date, TotalSec: s.TotalDurationSec, Untimed: s.UntimedEntries}
+
+	for _, entry := range s.Entries {
+		sheet, err := l.songSheet(ctx, *entry.Song, entry.Key)
+		if err != nil {
+			return doc, err
+		}
+
+		item := setlist.Item{Position: entry.Position, Title: entry.Song.Title, Key: sheet.Key, Notes: entry.Notes, Sheet: sheet}
+		if entry.DurationSec != nil {
+			item.DurationSec = *entry.DurationSec
+		}
+		item.Sheet.Title = fmt.Sprintf("%d. %s", entry.Position, entry.Song.Title)
+		doc.Items = append(doc.Items, item)
+	}
+
+	log.WithField("count", len(doc.Items)).Debug("Песни для печати") // Debug-лог
+
+	return doc, nil
+}
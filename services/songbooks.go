@@ -0,0 +1,308 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"songLibrary/initializers"
+	"songLibrary/models"
+	"songLibrary/songbook"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrSongbookNotFound = errors.New("песенник не найден")
+	ErrInvalidSongbook  = errors.New("некорректный песенник")
+	ErrSongbookNotReady = errors.New("песенник ещё не собран")
+	ErrSongbookFailed   = errors.New("песенник не удалось собрать")
+)
+
+// Задание в статусе running дольше этого считается брошенным упавшим экземпляром
+const songbookStaleAfter = 15 * time.Minute
+
+// CreateSongbook выбирает песни и ставит задание сборки в очередь
+func (l *Library) CreateSongbook(ctx context.Context, actor string, input models.SongbookInput, maxSongs int) (models.SongbookJob, error) {
+	log := logger.Ctx(ctx).WithField("prefix", "CreateSongbook")
+
+	input.Title = strings.TrimSpace(input.Title)
+	input.Format = strings.ToLower(strings.TrimSpace(input.Format))
+	if input.Format == "" {
+		input.Format = songbook.FormatPDF
+	}
+	if input.Format != songbook.FormatPDF && input.Format != songbook.FormatEPUB {
+		return models.SongbookJob{}, fmt.Errorf("%w: формат должен быть pdf или epub", ErrInvalidSongbook)
+	}
+	if len(input.Title) < 1 || len(input.Title) > 255 {
+		return models.SongbookJob{}, fmt.Errorf("%w: название пустое или слишком длинное", ErrInvalidSongbook)
+	}
+
+	songIDs, err := l.songbookSongs(ctx, actor, input)
+	if err != nil {
+		return models.SongbookJob{}, err
+	}
+	if len(songIDs) == 0 {
+		return models.SongbookJob{}, fmt.Errorf("%w: не найдено ни одной песни", ErrInvalidSongbook)
+	}
+	if len(songIDs) > maxSongs {
+		return models.SongbookJob{}, fmt.Errorf("%w: песен %d, можно не больше %d", ErrInvalidSongbook, len(songIDs), maxSongs)
+	}
+
+	job := models.SongbookJob{Owner: actor, Title: input.Title, Format: input.Format, SongIDs: songIDs, Status: models.SongbookPending}
+
+	log.WithField("format", job.Format).WithField("count", len(songIDs)).Info("Ставим песенник в очередь") // Info-лог
+
+	return job, l.DB(ctx).Create(&job).Error
+}
+
+// songbookSongs - ID песен песенника по порядку: как в плейлисте, по трекам альбома,
+// как в song_ids или, для фильтров, по группе и названию
+func (l *Library) songbookSongs(ctx context.Context, actor string, input models.SongbookInput) (pq.Int64Array, error) {
+	filters := input.Group != "" || input.Genre != "" || input.Tag != ""
+
+	sources := 0
+	for _, set := range []bool{len(input.SongIDs) > 0, input.PlaylistID > 0, input.AlbumID > 0, filters} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return nil, fmt.Errorf("%w: укажите один источник песен: song_ids, playlist_id, album_id или фильтры group, genre, tag", ErrInvalidSongbook)
+	}
+
+	var ids pq.Int64Array
+
+	switch {
+	case input.PlaylistID > 0:
+		p, err := l.GetPlaylist(ctx, input.PlaylistID, actor, input.PlaylistToken)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range p.Entries {
+			ids = append(ids, int64(entry.SongID))
+		}
+
+	case input.AlbumID > 0:
+		album, err := l.GetAlbum(ctx, input.AlbumID)
+		if err != nil {
+			return nil, err
+		}
+		for _, song := range album.Songs {
+			ids = append(ids, int64(song.ID))
+		}
+
+	case len(input.SongIDs) > 0:
+		var found []int
+		if err := l.DB(ctx).Model(&models.Song{}).Where("id IN ?", input.SongIDs).Pluck("id", &found).Error; err != nil {
+			return nil, err
+		}
+		exists := make(map[int]bool, len(found))
+		for _, id := range found {
+			exists[id] = true
+		}
+		for _, id := range input.SongIDs {
+			if !exists[id] {
+				return nil, fmt.Errorf("%w: %d", ErrSongNotFound, id)
+			}
+			ids = append(ids, int64(id))
+		}
+
+	default:
+		query := l.DB(ctx).Model(&models.Song{}).Joins("JOIN groups ON groups.id = songs.group_id")
+		if input.Group != "" {
+			query = query.Where("LOWER(groups.name) = LOWER(?)", strings.TrimSpace(input.Group))
+		}
+		if input.Genre != "" {
+			sets, err := l.GenreSets(ctx, []string{input.Genre})
+			if err != nil {
+				return nil, err
+			}
+			query = query.Where("songs.id IN (?)", l.SongsWithGenres(ctx, sets[0]))
+		}
+		if input.Tag != "" {
+			query = query.Where("songs.id IN (?)", l.SongsWithTags(ctx, []string{input.Tag}))
+		}
+		if err := query.Order("groups.name, songs.title, songs.id").Pluck("songs.id", &ids).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return ids, nil
+}
+
+// GetSongbook возвращает задание актора без файла
+func (l *Library) GetSongbook(ctx context.Context, jobID int, actor string) (models.SongbookJob, error) {
+	var job models.SongbookJob
+	err := l.DB(ctx).Omit("data").Where("owner = ?", actor).First(&job, jobID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return job, ErrSongbookNotFound
+	}
+	return job, err
+}
+
+// SongbookFile возвращает собранный песенник. Пока сборка не закончилась - ErrSongbookNotReady.
+func (l *Library) SongbookFile(ctx context.Context, jobID int, actor string) (models.SongbookJob, error) {
+	job, err := l.GetSongbook(ctx, jobID, actor)
+	if err != nil {
+		return job, err
+	}
+
+	switch job.Status {
+	case models.SongbookDone:
+	case models.SongbookFailed:
+		return job, fmt.Errorf("%w: %s", ErrSongbookFailed, job.Error)
+	default:
+		return job, ErrSongbookNotReady
+	}
+
+	return job, l.DB(ctx).Select("data").First(&job, job.ID).Error
+}
+
+// ProcessSongbooks собирает задания из очереди, пока она не опустеет, и удаляет
+// файлы с истёкшим сроком хранения. Возвращает число обработанных заданий.
+func (l *Library) ProcessSongbooks(ctx context.Context, config initializers.SongbookConfig) (int, error) {
+	log := logger.Ctx(ctx).WithField("prefix", "ProcessSongbooks")
+
+	expired := l.DB(ctx).Unscoped().Where("expires_at < ?", time.Now()).Delete(&models.SongbookJob{})
+	if expired.Error != nil {
+		return 0, expired.Error
+	}
+	if expired.RowsAffected > 0 {
+
+		log.WithField("count", expired.RowsAffected).Info("Удалены устаревшие песенники") // Info-лог
+
+	}
+
+	processed := 0
+	for ctx.Err() == nil {
+		job, ok, err := l.claimSongbook(ctx)
+		if err != nil || !ok {
+			return processed, err
+		}
+		processed++
+
+		log := log.WithField("songbook.id", job.ID)
+
+		log.WithField("format", job.Format).WithField("count", len(job.SongIDs)).Info("Собираем песенник") // Info-лог
+
+		data, buildErr := l.buildSongbook(ctx, job, songbook.Fonts{Regular: config.Font, Bold: config.FontBold})
+
+		now := time.Now()
+		update := map[string]any{"finished_at": now, "expires_at": now.Add(config.TTL)}
+		if buildErr != nil {
+			if ctx.Err() != nil {
+				return processed, ctx.Err()
+			}
+			log.WithError(buildErr).Error("Не удалось собрать песенник")
+			update["status"], update["error"] = models.SongbookFailed, truncate(buildErr.Error(), 500)
+		} else {
+			update["status"], update["data"], update["size"] = models.SongbookDone, data, len(data)
+		}
+
+		if err := l.DB(ctx).Model(&job).Updates(update).Error; err != nil {
+			return processed, err
+		}
+	}
+
+	return processed, ctx.Err()
+}
+
+// claimSongbook берёт из очереди одно задание, пропуская заблокированные другим экземпляром.
+// Брошенные задания в статусе running берутся повторно.
+func (l *Library) claimSongbook(ctx context.Context) (models.SongbookJob, bool, error) {
+	var jobs []models.SongbookJob
+
+	err := l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).Omit("data").
+			Where("status = ? OR (status = ? AND started_at < ?)", models.SongbookPending, models.SongbookRunning, time.Now().Add(-songbookStaleAfter)).
+			Order("id").Limit(1).Find(&jobs).Error
+		if err != nil || len(jobs) == 0 {
+			return err
+		}
+
+		now := time.Now()
+		jobs[0].Status, jobs[0].StartedAt = models.SongbookRunning, &now
+		return tx.Model(&jobs[0]).Updates(map[string]any{"status": models.SongbookRunning, "started_at": now}).Error
+	})
+	if err != nil || len(jobs) == 0 {
+		return models.SongbookJob{}, false, err
+	}
+	return jobs[0], true, nil
+}
+
+// buildSongbook собирает файл из песен задания. Песни, удалённые после постановки
+// в очередь, пропускаются.
+func (l *Library) buildSongbook(ctx context.Context, job models.SongbookJob, fonts songbook.Fonts) ([]byte, error) {
+	var songs []models.Song
+	if err := l.DB(ctx).Where("id IN ?", []int64(job.SongIDs)).Find(&songs).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]models.Song, len(songs))
+	for _, song := range songs {
+		byID[int64(song.ID)] = song
+	}
+
+	book := songbook.Book{ID: fmt.Sprintf("urn:songlibrary:songbook:%d", job.ID), Title: job.Title, Modified: time.Now()}
+	for _, id := range job.SongIDs {
+		song, ok := byID[id]
+		if !ok {
+			continue
+		}
+		sheet, err := l.songSheet(ctx, song, "")
+		if err != nil {
+			return nil, fmt.Errorf("песня %d: %w", id, err)
+		}
+		book.Songs = append(book.Songs, sheet)
+	}
+	if len(book.Songs) == 0 {
+		return nil, errors.New("все песни песенника удалены")
+	}
+
+	return songbook.Render(book, job.Format, fonts)
+}
+
+// SongbookWorker периодически собирает песенники из очереди
+type SongbookWorker struct {
+	library *Library
+	config  initializers.SongbookConfig
+}
+
+func NewSongbookWorker(library *Library, config initializers.SongbookConfig) *SongbookWorker {
+	return &SongbookWorker{library: library, config: config}
+}
+
+func (w *SongbookWorker) Name() string {
+	return "songbook"
+}
+
+func (w *SongbookWorker) Run(ctx context.Context) error {
+	log := logger.Ctx(ctx).WithField("prefix", "SongbookWorker")
+
+	ticker := time.NewTicker(w.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		processed, err := w.library.ProcessSongbooks(ctx, w.config)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return err
+			}
+			log.WithError(err).Error("Проход очереди песенников завершился с ошибкой")
+			continue
+		}
+
+		if processed > 0 {
+			log.WithField("processed", processed).Info("Очередь песенников разобрана") // Info-лог
+		}
+	}
+}
@@ -0,0 +1,493 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"songLibrary/cache"
+	"songLibrary/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrGenreNotFound = errors.New("жанр не найден")
+	ErrGenreExists   = errors.New("жанр с таким названием уже есть")
+	ErrInvalidGenre  = errors.New("некорректный жанр")
+	ErrTagNotFound   = errors.New("тег не найден")
+	ErrInvalidTag    = errors.New("некорректный тег")
+	ErrGroupNotFound = errors.New("группа не найдена")
+)
+
+// Всё, кроме букв и цифр, в slug заменяется дефисом
+var slugSeparatorRegexp = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+// Больше тегов в фасетах не отдаём
+const maxTagFacets = 50
+
+// Пары песня-жанр и песня-тег вместе с жанрами и тегами группы песни
+const (
+	songGenresSQL = `SELECT song_id, genre_id FROM song_genres
+		UNION SELECT songs.id, group_genres.genre_id FROM songs JOIN group_genres ON group_genres.group_id = songs.group_id`
+	songTagsSQL = `SELECT song_id, tag_id FROM song_tags
+		UNION SELECT songs.id, group_tags.tag_id FROM songs JOIN group_tags ON group_tags.group_id = songs.group_id`
+)
+
+func slugify(name string) string {
+	return strings.Trim(slugSeparatorRegexp.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// NormalizeTag приводит тег к нижнему регистру и схлопывает пробелы
+func NormalizeTag(tag string) string {
+	return strings.Join(strings.Fields(strings.ToLower(tag)), " ")
+}
+
+// invalidateTaxonomy сбрасывает все ответы с жанрами и тегами
+func (l *Library) invalidateTaxonomy(ctx context.Context) {
+	l.cache.InvalidateTags(ctx, cache.TagSongsList, cache.TagTaxonomy)
+}
+
+// loadGenres возвращает все жанры списком, иерархия собирается по ParentID
+func loadGenres(db *gorm.DB) ([]models.Genre, error) {
+	var genres []models.Genre
+	err := db.Order("name").Find(&genres).Error
+	return genres, err
+}
+
+// descendants возвращает жанр и все его поджанры
+func descendants(genres []models.Genre, id int) []int {
+	result := []int{id}
+	for i := 0; i < len(result); i++ {
+		for _, genre := range genres {
+			if genre.ParentID != nil && *genre.ParentID == result[i] && !slices.Contains(result, genre.ID) {
+				result = append(result, genre.ID)
+			}
+		}
+	}
+	return result
+}
+
+// ListGenres возвращает дерево жанров, корни и поджанры по алфавиту
+func (l *Library) ListGenres(ctx context.Context) ([]models.Genre, error) {
+	genres, err := loadGenres(l.DB(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	var build func(parentID *int) []models.Genre
+	build = func(parentID *int) []models.Genre {
+		nodes := []models.Genre{}
+		for _, genre := range genres {
+			if (parentID == nil && genre.ParentID == nil) || (parentID != nil && genre.ParentID != nil && *genre.ParentID == *parentID) {
+				id := genre.ID
+				genre.Children = build(&id)
+				nodes = append(nodes, genre)
+			}
+		}
+		return nodes
+	}
+
+	return build(nil), nil
+}
+
+// fillGenre проверяет ввод и переносит его в жанр. Родитель не может быть самим жанром
+// или его поджанром.
+func fillGenre(tx *gorm.DB, genre *models.Genre, input models.GenreInput) error {
+	name := strings.Join(strings.Fields(input.Name), " ")
+	slug := slugify(name)
+	if slug == "" || len(name) > 100 {
+		return fmt.Errorf("%w: название пустое или слишком длинное", ErrInvalidGenre)
+	}
+
+	var count int64
+	err := tx.Model(&models.Genre{}).Where("(LOWER(name) = LOWER(?) OR slug = ?) AND id <> ?", name, slug, genre.ID).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrGenreExists
+	}
+
+	if input.ParentID != nil {
+		genres, err := loadGenres(tx)
+		if err != nil {
+			return err
+		}
+		if !slices.ContainsFunc(genres, func(g models.Genre) bool { return g.ID == *input.ParentID }) {
+			return fmt.Errorf("%w: родительский жанр %d", ErrGenreNotFound, *input.ParentID)
+		}
+		if genre.ID != 0 && slices.Contains(descendants(genres, genre.ID), *input.ParentID) {
+			return fmt.Errorf("%w: жанр не может быть поджанром самого себя", ErrInvalidGenre)
+		}
+	}
+
+	genre.Name = name
+	genre.Slug = slug
+	genre.ParentID = input.ParentID
+	return nil
+}
+
+// CreateGenre добавляет жанр, slug строится из названия
+func (l *Library) CreateGenre(ctx context.Context, input models.GenreInput) (models.Genre, error) {
+	log := logger.Ctx(ctx).WithField("prefix", "CreateGenre")
+
+	var genre models.Genre
+	err := l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := fillGenre(tx, &genre, input); err != nil {
+			return err
+		}
+
+		log.WithField("slug", genre.Slug).Info("Создаём жанр") // Info-лог
+
+		return tx.Create(&genre).Error
+	})
+	return genre, err
+}
+
+// UpdateGenre переименовывает жанр или переносит его под другого родителя
+func (l *Library) UpdateGenre(ctx context.Context, genreID int, input models.GenreInput) (models.Genre, error) {
+	var genre models.Genre
+	err := l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&genre, genreID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrGenreNotFound
+			}
+			return err
+		}
+		if err := fillGenre(tx, &genre, input); err != nil {
+			return err
+		}
+		return tx.Save(&genre).Error
+	})
+	if err != nil {
+		return genre, err
+	}
+
+	l.invalidateTaxonomy(ctx)
+
+	return genre, nil
+}
+
+// DeleteGenre удаляет жанр, его поджанры переходят к его родителю
+func (l *Library) DeleteGenre(ctx context.Context, genreID int) error {
+	log := logger.Ctx(ctx).WithField("prefix", "DeleteGenre")
+
+	err := l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		var genre models.Genre
+		if err := tx.First(&genre, genreID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrGenreNotFound
+			}
+			return err
+		}
+
+		log.WithField("slug", genre.Slug).Info("Удаляем жанр") // Info-лог
+
+		if err := tx.Model(&models.Genre{}).Where("parent_id = ?", genre.ID).Update("parent_id", genre.ParentID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM song_genres WHERE genre_id = ?", genre.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM group_genres WHERE genre_id = ?", genre.ID).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&genre).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	l.invalidateTaxonomy(ctx)
+
+	return nil
+}
+
+// genresBySlug находит жанры по slug, неизвестный slug - ошибка
+func genresBySlug(tx *gorm.DB, slugs []string) ([]models.Genre, error) {
+	genres := []models.Genre{}
+	for _, slug := range slugs {
+		var genre models.Genre
+		if err := tx.Where("slug = ?", slugify(slug)).First(&genre).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("%w: %s", ErrGenreNotFound, slug)
+			}
+			return nil, err
+		}
+		if !slices.ContainsFunc(genres, func(g models.Genre) bool { return g.ID == genre.ID }) {
+			genres = append(genres, genre)
+		}
+	}
+	return genres, nil
+}
+
+// GenreSets возвращает для каждого slug жанр вместе с поджанрами
+func (l *Library) GenreSets(ctx context.Context, slugs []string) ([][]int, error) {
+	selected, err := genresBySlug(l.DB(ctx), slugs)
+	if err != nil {
+		return nil, err
+	}
+	genres, err := loadGenres(l.DB(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	sets := make([][]int, 0, len(selected))
+	for _, genre := range selected {
+		sets = append(sets, descendants(genres, genre.ID))
+	}
+	return sets, nil
+}
+
+// tagsByName находит теги по имени и создаёт недостающие
+func tagsByName(tx *gorm.DB, names []string) ([]models.Tag, error) {
+	tags := []models.Tag{}
+	for _, name := range names {
+		name = NormalizeTag(name)
+		if name == "" || len(name) > 50 {
+			return nil, fmt.Errorf("%w: тег пустой или слишком длинный: %q", ErrInvalidTag, name)
+		}
+
+		var tag models.Tag
+		if err := tx.Where(models.Tag{Name: name}).FirstOrCreate(&tag).Error; err != nil {
+			return nil, err
+		}
+		if !slices.ContainsFunc(tags, func(t models.Tag) bool { return t.ID == tag.ID }) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+// replaceAssociation заменяет жанры или теги песни или группы
+func replaceAssociation(tx *gorm.DB, owner any, name string, values any, empty bool) error {
+	association := tx.Model(owner).Omit(name + ".*").Association(name)
+	if empty {
+		return association.Clear()
+	}
+	return association.Replace(values)
+}
+
+// SetSongGenres заменяет жанры песни
+func (l *Library) SetSongGenres(ctx context.Context, songID int, slugs []string) ([]models.Genre, error) {
+	var song models.Song
+	var genres []models.Genre
+	err := l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&song, songID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrSongNotFound
+			}
+			return err
+		}
+
+		var err error
+		if genres, err = genresBySlug(tx, slugs); err != nil {
+			return err
+		}
+		return replaceAssociation(tx, &song, "Genres", genres, len(genres) == 0)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	l.InvalidateSong(ctx, song.ID, song.GroupID)
+
+	return genres, nil
+}
+
+// SetSongTags заменяет теги песни, новые теги создаются
+func (l *Library) SetSongTags(ctx context.Context, songID int, names []string) ([]models.Tag, error) {
+	var song models.Song
+	var tags []models.Tag
+	err := l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&song, songID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrSongNotFound
+			}
+			return err
+		}
+
+		var err error
+		if tags, err = tagsByName(tx, names); err != nil {
+			return err
+		}
+		return replaceAssociation(tx, &song, "Tags", tags, len(tags) == 0)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	l.InvalidateSong(ctx, song.ID, song.GroupID)
+
+	return tags, nil
+}
+
+// SetGroupGenres заменяет жанры группы, в фильтрах они достаются всем её песням
+func (l *Library) SetGroupGenres(ctx context.Context, groupID int, slugs []string) ([]models.Genre, error) {
+	var genres []models.Genre
+	err := l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		var group models.Group
+		if err := tx.First(&group, groupID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrGroupNotFound
+			}
+			return err
+		}
+
+		var err error
+		if genres, err = genresBySlug(tx, slugs); err != nil {
+			return err
+		}
+		return replaceAssociation(tx, &group, "Genres", genres, len(genres) == 0)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	l.cache.InvalidateTags(ctx, cache.TagSongsList, cache.TagGroup(groupID))
+
+	return genres, nil
+}
+
+// SetGroupTags заменяет теги группы, новые теги создаются
+func (l *Library) SetGroupTags(ctx context.Context, groupID int, names []string) ([]models.Tag, error) {
+	var tags []models.Tag
+	err := l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		var group models.Group
+		if err := tx.First(&group, groupID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrGroupNotFound
+			}
+			return err
+		}
+
+		var err error
+		if tags, err = tagsByName(tx, names); err != nil {
+			return err
+		}
+		return replaceAssociation(tx, &group, "Tags", tags, len(tags) == 0)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	l.cache.InvalidateTags(ctx, cache.TagSongsList, cache.TagGroup(groupID))
+
+	return tags, nil
+}
+
+// ListTags возвращает все теги по алфавиту
+func (l *Library) ListTags(ctx context.Context) ([]models.Tag, error) {
+	tags := []models.Tag{}
+	err := l.DB(ctx).Order("name").Find(&tags).Error
+	return tags, err
+}
+
+// DeleteTag удаляет тег у всех песен и групп
+func (l *Library) DeleteTag(ctx context.Context, tagID int) error {
+	err := l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		var tag models.Tag
+		if err := tx.First(&tag, tagID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTagNotFound
+			}
+			return err
+		}
+
+		if err := tx.Exec("DELETE FROM song_tags WHERE tag_id = ?", tag.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM group_tags WHERE tag_id = ?", tag.ID).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&tag).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	l.invalidateTaxonomy(ctx)
+
+	return nil
+}
+
+// SongsWithGenres - подзапрос ID песен, у которых или у их группы есть один из жанров
+func (l *Library) SongsWithGenres(ctx context.Context, genreIDs []int) *gorm.DB {
+	return l.DB(ctx).Raw("SELECT song_id FROM ("+songGenresSQL+") AS sg WHERE genre_id IN ?", genreIDs)
+}
+
+// SongsWithTags - подзапрос ID песен, у которых или у их группы есть один из тегов
+func (l *Library) SongsWithTags(ctx context.Context, names []string) *gorm.DB {
+	normalized := make([]string, 0, len(names))
+	for _, name := range names {
+		normalized = append(normalized, NormalizeTag(name))
+	}
+	return l.DB(ctx).Raw("SELECT st.song_id FROM ("+songTagsSQL+") AS st JOIN tags ON tags.id = st.tag_id WHERE tags.name IN ?", normalized)
+}
+
+// SongFacets считает песни из подзапроса songIDs по жанрам и тегам. Песня поджанра
+// засчитывается и всем жанрам выше по иерархии, но в каждый жанр - один раз.
+func (l *Library) SongFacets(ctx context.Context, songIDs *gorm.DB) (models.Facets, error) {
+	facets := models.Facets{Genres: []models.FacetCount{}, Tags: []models.FacetCount{}}
+
+	var pairs []struct {
+		SongID  int
+		GenreID int
+	}
+	err := l.DB(ctx).Raw("SELECT DISTINCT song_id, genre_id FROM ("+songGenresSQL+") AS sg WHERE song_id IN (?)", songIDs).Scan(&pairs).Error
+	if err != nil {
+		return facets, err
+	}
+
+	if len(pairs) > 0 {
+		genres, err := loadGenres(l.DB(ctx))
+		if err != nil {
+			return facets, err
+		}
+		byID := make(map[int]models.Genre, len(genres))
+		for _, genre := range genres {
+			byID[genre.ID] = genre
+		}
+
+		songs := map[int]map[int]bool{}
+		for _, pair := range pairs {
+			// Ограничение глубины на случай цикла в иерархии
+			id, depth := pair.GenreID, 0
+			for depth < len(genres) {
+				genre, ok := byID[id]
+				if !ok {
+					break
+				}
+				if songs[id] == nil {
+					songs[id] = map[int]bool{}
+				}
+				songs[id][pair.SongID] = true
+				if genre.ParentID == nil {
+					break
+				}
+				id, depth = *genre.ParentID, depth+1
+			}
+		}
+
+		for id, set := range songs {
+			genre := byID[id]
+			facets.Genres = append(facets.Genres, models.FacetCount{ID: id, Name: genre.Name, Slug: genre.Slug, ParentID: genre.ParentID, Count: int64(len(set))})
+		}
+		slices.SortFunc(facets.Genres, func(a, b models.FacetCount) int {
+			if a.Count != b.Count {
+				return int(b.Count - a.Count)
+			}
+			return strings.Compare(a.Name, b.Name)
+		})
+	}
+
+	err = l.DB(ctx).Raw(`SELECT tags.id, tags.name, COUNT(DISTINCT st.song_id) AS count
+		FROM (`+songTagsSQL+`) AS st JOIN tags ON tags.id = st.tag_id
+		WHERE st.song_id IN (?) AND tags.deleted_at IS NULL
+		GROUP BY tags.id, tags.name ORDER BY count DESC, tags.name LIMIT ?`, songIDs, maxTagFacets).Scan(&facets.Tags).Error
+
+	return facets, err
+}
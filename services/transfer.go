@@ -0,0 +1,284 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"songLibrary/chords"
+	"songLibrary/lyrics"
+	"songLibrary/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// SongRecord - строка формата JSON Lines для импорта и экспорта
+type SongRecord struct {
+	Group       string `json:"group"`
+	Song        string `json:"song"`
+	ReleaseDate string `json:"release_date,omitempty"`
+	Link        string `json:"link,omitempty"`
+	Text        string `json:"text,omitempty"`
+	// Источники полей, при импорте без него поля отмечаются как import
+	Provenance models.Provenance `json:"provenance,omitempty"`
+	// Исходник ChordPro, при импорте заменяет text
+	ChordPro string `json:"chordpro,omitempty"`
+	// Альбом с номером трека, при импорте ищется у группы по названию или создаётся
+	Album *models.AlbumInfo `json:"album,omitempty"`
+	// Участники кроме основного исполнителя из group
+	Credits []models.CreditInput `json:"credits,omitempty"`
+}
+
+type ImportResult struct {
+	Created int      `json:"created"`
+	Skipped int      `json:"skipped"`
+	Failed  int      `json:"failed"`
+	Errors  []string `json:"errors,omitempty"`
+}
+
+// Тестовые данные для команды seed
+var SeedRecords = []SongRecord{
+	{
+		Group:       "Muse",
+		Song:        "Supermassive Black Hole",
+		ReleaseDate: "16.07.2006",
+		Link:        "https://www.youtube.com/watch?v=Xsp3_a-PMTw",
+		Text:        "Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\nYou caught me under false pretenses\nHow long before you let me go?\n\nOoh\nYou set my soul alight\nOoh\nYou set my soul alight",
+	},
+	{
+		Group:       "Fall Out Boy",
+		Song:        "Centuries",
+		ReleaseDate: "09.09.2014",
+		Link:        "https://www.youtube.com/watch?v=LBr7kECsjcQ",
+	},
+}
+
+// Export пишет все песни в формате JSON Lines
+func (l *Library) Export(ctx context.Context, w io.Writer) (int, error) {
+	log := logger.Ctx(ctx).WithField("prefix", "Export")
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+
+	groups := map[int]string{}
+	var exported int
+	var songs []models.Song
+
+	err := l.DB(ctx).Preload("Lyrics", func(db *gorm.DB) *gorm.DB {
+		return db.Order("\"order\"")
+	}).Preload("Album").Preload("Credits", func(db *gorm.DB) *gorm.DB {
+		return db.Order("\"order\", id")
+	}).Preload("Credits.Group").Order("id").FindInBatches(&songs, 100, func(tx *gorm.DB, batch int) error {
+
+		log.WithField("batch", batch).Debug("Экспортируем пачку песен") // Debug-лог
+
+		for _, song := range songs {
+			groupName, ok := groups[song.GroupID]
+			if !ok {
+				var group models.Group
+				if err := l.DB(ctx).Unscoped().First(&group, song.GroupID).Error; err != nil {
+					return err
+				}
+				groupName = group.Name
+				groups[song.GroupID] = groupName
+			}
+
+			record := SongRecord{
+				Group:       groupName,
+				Song:        song.Title,
+				ReleaseDate: song.ReleaseDate,
+				Link:        song.Link,
+				Text:        lyricsText(song.Lyrics),
+				Provenance:  song.Provenance,
+				ChordPro:    song.ChordPro,
+				Album:       songAlbum(song),
+				Credits:     songCredits(song),
+			}
+			if err := encoder.Encode(record); err != nil {
+				return err
+			}
+			exported++
+		}
+		return nil
+	}).Error
+
+	return exported, err
+}
+
+// songAlbum - альбом песни для строки экспорта
+func songAlbum(song models.Song) *models.AlbumInfo {
+	if song.Album == nil {
+		return nil
+	}
+
+	album := &models.AlbumInfo{Title: song.Album.Title, ReleaseDate: song.Album.ReleaseDate, Type: song.Album.Type}
+	if song.TrackNumber != nil {
+		album.TrackNumber = *song.TrackNumber
+	}
+	if song.DiscNumber != nil {
+		album.DiscNumber = *song.DiscNumber
+	}
+	return album
+}
+
+// songCredits - участники песни для строки экспорта, основной исполнитель уже записан в group
+func songCredits(song models.Song) []models.CreditInput {
+	var credits []models.CreditInput
+	for _, credit := range song.Credits {
+		if credit.Group == nil || (credit.GroupID == song.GroupID && credit.Role == models.CreditRoleMain) {
+			continue
+		}
+		credits = append(credits, models.CreditInput{Artist: credit.Group.Name, Role: credit.Role})
+	}
+	return credits
+}
+
+// lyricsText собирает сохранённые части в текст с метками
+func lyricsText(verses []models.Lyrics) string {
+	sections := make([]lyrics.Section, 0, len(verses))
+	for _, verse := range verses {
+		sections = append(sections, lyrics.Section{Label: verse.Label, Text: verse.Verse})
+	}
+	return lyrics.Join(sections)
+}
+
+// ReparseLyrics заново разбирает сохранённые тексты всех песен: типы частей, метки и повторы
+func (l *Library) ReparseLyrics(ctx context.Context) (int, error) {
+	var reparsed int
+	var songs []models.Song
+
+	err := l.DB(ctx).Preload("Lyrics", func(db *gorm.DB) *gorm.DB {
+		return db.Order("\"order\"")
+	}).Order("id").FindInBatches(&songs, 100, func(tx *gorm.DB, batch int) error {
+		for i := range songs {
+			if len(songs[i].Lyrics) == 0 {
+				continue
+			}
+
+			text := lyricsText(songs[i].Lyrics)
+			err := l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+				if err := tx.Where("song_id = ?", songs[i].ID).Delete(&models.Lyrics{}).Error; err != nil {
+					return err
+				}
+
+				// Песни с ChordPro разбираются из исходника, чтобы не потерять аккорды
+				if songs[i].ChordPro != "" {
+					sheet, err := chords.Parse(songs[i].ChordPro)
+					if err != nil {
+						return err
+					}
+					if _, err := applyChordPro(tx, songs[i].ID, sheet); err != nil {
+						return err
+					}
+				} else if _, err := createVerses(tx, songs[i].ID, lyrics.Parse(text)); err != nil {
+					return err
+				}
+
+				return l.FlagExplicit(tx, &songs[i])
+			})
+			if err != nil {
+				return fmt.Errorf("song %d: %w", songs[i].ID, err)
+			}
+
+			l.InvalidateSong(ctx, songs[i].ID)
+			reparsed++
+		}
+		return nil
+	}).Error
+
+	return reparsed, err
+}
+
+// Import читает песни в формате JSON Lines. Строки без даты, ссылки и текста
+// дозапрашиваются у провайдеров, если fetchMissing.
+func (l *Library) Import(ctx context.Context, r io.Reader, fetchMissing bool) (ImportResult, error) {
+	var result ImportResult
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+
+		raw := strings.TrimSpace(scanner.Text())
+		if raw == "" {
+			continue
+		}
+
+		var record SongRecord
+		if err := json.Unmarshal([]byte(raw), &record); err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("line %d: %s", line, err))
+			continue
+		}
+
+		if err := l.ImportRecord(ctx, record, fetchMissing); err != nil {
+			if errors.Is(err, ErrSongExists) {
+				result.Skipped++
+				continue
+			}
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("line %d: %s", line, err))
+			continue
+		}
+		result.Created++
+	}
+
+	return result, scanner.Err()
+}
+
+// ImportRecord добавляет одну песню тем же путём, что и AddSong
+func (l *Library) ImportRecord(ctx context.Context, record SongRecord, fetchMissing bool) error {
+	if err := ValidateSongInput(record.Group, record.Song); err != nil {
+		return err
+	}
+	if record.ChordPro != "" {
+		if _, err := chords.Parse(record.ChordPro); err != nil {
+			return err
+		}
+	}
+
+	var song models.Song
+	var err error
+	if fetchMissing && record.ReleaseDate == "" && record.Link == "" && record.Text == "" && record.Album == nil {
+		song, err = l.AddSong(ctx, record.Group, record.Song)
+	} else {
+		song, err = l.AddSongWithDetail(ctx, record.Group, record.Song, SongDetail{
+			ReleaseDate: record.ReleaseDate,
+			Text:        record.Text,
+			Link:        record.Link,
+			Album:       providerAlbum(record.Album),
+		}, record.Provenance)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Участник мог уже появиться из "feat." в названии
+	for _, credit := range record.Credits {
+		if _, err := l.AddCredit(ctx, song.ID, credit); err != nil && !errors.Is(err, ErrCreditExists) {
+			return err
+		}
+	}
+
+	if record.ChordPro == "" {
+		return nil
+	}
+	_, err = l.SetChordPro(ctx, song.ID, record.ChordPro)
+	return err
+}
+
+// ValidateSongInput - те же ограничения, что и у ручки AddSong
+func ValidateSongInput(groupName, title string) error {
+	if len(groupName) < 1 || len(groupName) > 60 {
+		return fmt.Errorf("значение group пустое или слишком длинное: %s", groupName)
+	}
+	if len(title) < 1 || len(title) > 100 {
+		return fmt.Errorf("значение song пустое или слишком длинное: %s", title)
+	}
+	return nil
+}
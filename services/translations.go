@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"songLibrary/lyrics"
+	"songLibrary/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidLang         = errors.New("некорректный код языка")
+	ErrInvalidTranslation  = errors.New("перевод не совпадает со структурой текста")
+	ErrTranslationExists   = errors.New("перевод на этот язык уже добавлен")
+	ErrTranslationNotFound = errors.New("перевод не найден")
+)
+
+// Код языка ISO 639, при необходимости с регионом: en, ru, pt-br
+var langRegexp = regexp.MustCompile(`^[a-z]{2,3}(-[a-z]{2,4})?$`)
+
+// NormalizeLang приводит код языка к нижнему регистру и проверяет его
+func NormalizeLang(lang string) (string, error) {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if !langRegexp.MatchString(lang) {
+		return lang, fmt.Errorf("%w: %q", ErrInvalidLang, lang)
+	}
+	return lang, nil
+}
+
+// AddTranslation добавляет перевод песни на язык lang
+func (l *Library) AddTranslation(ctx context.Context, songID int, lang, text string) ([]models.LyricsTranslation, error) {
+	return l.saveTranslation(ctx, songID, lang, text, false)
+}
+
+// UpdateTranslation заменяет существующий перевод песни на язык lang
+func (l *Library) UpdateTranslation(ctx context.Context, songID int, lang, text string) ([]models.LyricsTranslation, error) {
+	return l.saveTranslation(ctx, songID, lang, text, true)
+}
+
+// saveTranslation разбирает перевод на части так же, как оригинал, и сохраняет их по одной
+// на каждую часть оригинала. Число частей должно совпадать, а части с меткой в переводе
+// должны быть того же типа, что и в оригинале.
+func (l *Library) saveTranslation(ctx context.Context, songID int, lang, text string, replace bool) ([]models.LyricsTranslation, error) {
+	log := logger.Ctx(ctx).WithField("prefix", "saveTranslation")
+
+	lang, err := NormalizeLang(lang)
+	if err != nil {
+		return nil, err
+	}
+
+	var song models.Song
+	if err := l.DB(ctx).First(&song, songID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSongNotFound
+		}
+		return nil, err
+	}
+
+	var verses []models.Lyrics
+	if err := l.DB(ctx).Where("song_id = ?", songID).Order("\"order\"").Find(&verses).Error; err != nil {
+		return nil, err
+	}
+
+	sections := lyrics.Parse(text)
+	if err := checkStructure(verses, sections); err != nil {
+		return nil, err
+	}
+
+	log.WithField("lang", lang).WithField("count", len(sections)).Info("Сохраняем перевод") // Info-лог
+
+	result := make([]models.LyricsTranslation, 0, len(verses))
+	err = l.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Model(&models.LyricsTranslation{}).Where("song_id = ? AND lang = ?", songID, lang).Count(&existing).Error; err != nil {
+			return err
+		}
+		switch {
+		case replace && existing == 0:
+			return ErrTranslationNotFound
+		case !replace && existing > 0:
+			return ErrTranslationExists
+		}
+
+		if err := tx.Unscoped().Where("song_id = ? AND lang = ?", songID, lang).Delete(&models.LyricsTranslation{}).Error; err != nil {
+			return err
+		}
+
+		for i, verse := range verses {
+			result = append(result, models.LyricsTranslation{SongID: songID, LyricsID: verse.ID, Lang: lang, Verse: sections[i].Text})
+		}
+		return tx.Create(&result).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	l.InvalidateSong(ctx, song.ID, song.GroupID)
+
+	return result, nil
+}
+
+// checkStructure сверяет части перевода с частями оригинала
+func checkStructure(verses []models.Lyrics, sections []lyrics.Section) error {
+	if len(verses) == 0 {
+		return fmt.Errorf("%w: у песни нет текста", ErrInvalidTranslation)
+	}
+	if len(sections) != len(verses) {
+		return fmt.Errorf("%w: в переводе частей %d, в оригинале %d", ErrInvalidTranslation, len(sections), len(verses))
+	}
+
+	for i, section := range sections {
+		if section.Text == "" {
+			return fmt.Errorf("%w: часть %d пустая", ErrInvalidTranslation, i+1)
+		}
+		if section.Label != "" && section.Type != verses[i].Type {
+			return fmt.Errorf("%w: часть %d в оригинале %s, в переводе %s", ErrInvalidTranslation, i+1, verses[i].Type, section.Type)
+		}
+	}
+
+	return nil
+}
+
+// DeleteTranslation удаляет перевод песни на язык lang
+func (l *Library) DeleteTranslation(ctx context.Context, songID int, lang string) error {
+	lang, err := NormalizeLang(lang)
+	if err != nil {
+		return err
+	}
+
+	res := l.DB(ctx).Unscoped().Where("song_id = ? AND lang = ?", songID, lang).Delete(&models.LyricsTranslation{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrTranslationNotFound
+	}
+
+	l.InvalidateSong(ctx, songID)
+
+	return nil
+}
+
+// VerseTranslations возвращает переводы частей на язык lang по ID части.
+// Если перевода на этот язык у песни нет, возвращается ErrTranslationNotFound.
+func (l *Library) VerseTranslations(ctx context.Context, songID int, lang string, verseIDs []int) (map[int]string, error) {
+	lang, err := NormalizeLang(lang)
+	if err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := l.DB(ctx).Model(&models.LyricsTranslation{}).Where("song_id = ? AND lang = ?", songID, lang).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, ErrTranslationNotFound
+	}
+
+	var translations []models.LyricsTranslation
+	if err := l.DB(ctx).Where("lang = ? AND lyrics_id IN ?", lang, verseIDs).Find(&translations).Error; err != nil {
+		return nil, err
+	}
+
+	result := make(map[int]string, len(translations))
+	for _, translation := range translations {
+		result[translation.LyricsID] = translation.Verse
+	}
+	return result, nil
+}
+
+// remapTranslations переносит переводы на пересозданные части текста по порядку.
+// Если число частей изменилось, перевод больше не совпадает со структурой и удаляется.
+func remapTranslations(tx *gorm.DB, songID int, verses []models.Lyrics) error {
+	log := logger.Ctx(tx.Statement.Context).WithField("prefix", "remapTranslations")
+
+	var rows []struct {
+		ID    int
+		Lang  string
+		Order int
+	}
+	err := tx.Table("lyrics_translations AS t").
+		Select("t.id, t.lang, l.\"order\"").
+		Joins("JOIN lyrics l ON l.id = t.lyrics_id").
+		Where("t.song_id = ? AND t.deleted_at IS NULL", songID).
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	byLang := map[string]int{}
+	for _, row := range rows {
+		byLang[row.Lang]++
+	}
+
+	for _, row := range rows {
+		if byLang[row.Lang] != len(verses) || row.Order < 1 || row.Order > len(verses) {
+			continue
+		}
+		if err := tx.Model(&models.LyricsTranslation{}).Where("id = ?", row.ID).Update("lyrics_id", verses[row.Order-1].ID).Error; err != nil {
+			return err
+		}
+	}
+
+	for lang, count := range byLang {
+		if count == len(verses) {
+			continue
+		}
+
+		log.WithField("song.id", songID).WithField("lang", lang).Warn("Структура текста изменилась, перевод удалён") // Warn-лог
+
+		if err := tx.Unscoped().Where("song_id = ? AND lang = ?", songID, lang).Delete(&models.LyricsTranslation{}).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package setlist
+
+import (
+	"fmt"
+	"html"
+	"songLibrary/chords"
+	"strings"
+)
+
+// Форматы печатной версии
+const (
+	FormatHTML = "html"
+	FormatText = "text"
+)
+
+// Item - песня сетлиста для печати
+type Item struct {
+	Position int
+	Title    string
+	Key      string
+	Notes    string
+	// 0, если длительность не указана
+	DurationSec int
+	// Аккорды или текст песни, уже в тональности выступления
+	Sheet chords.Sheet
+}
+
+// Document - сетлист для печати
+type Document struct {
+	Name  string
+	Venue string
+	Date  string
+	// Сумма указанных длительностей и число песен без длительности
+	TotalSec int
+	Untimed  int
+	Items    []Item
+}
+
+// FormatDuration - 3:07 или 1:02:05
+func FormatDuration(sec int) string {
+	if sec >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", sec/3600, sec%3600/60, sec%60)
+	}
+	return fmt.Sprintf("%d:%02d", sec/60, sec%60)
+}
+
+// Render выводит сетлист в одном из форматов
+func (d Document) Render(format string) (string, error) {
+	switch format {
+	case FormatHTML:
+		return d.HTML(), nil
+	case FormatText:
+		return d.Text(), nil
+	default:
+		return "", fmt.Errorf("неизвестный формат: %s", format)
+	}
+}
+
+// subtitle - площадка, дата и общая длительность одной строкой
+func (d Document) subtitle() string {
+	var parts []string
+	for _, part := range []string{d.Venue, d.Date} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	total := FormatDuration(d.TotalSec)
+	if d.Untimed > 0 {
+		total += fmt.Sprintf(" + %d без длительности", d.Untimed)
+	}
+	return strings.Join(append(parts, total), " · ")
+}
+
+func (item Item) duration() string {
+	if item.DurationSec <= 0 {
+		return ""
+	}
+	return FormatDuration(item.DurationSec)
+}
+
+// Text - порядок песен, затем песни с аккордами над строками, для моноширинного шрифта
+func (d Document) Text() string {
+	var b strings.Builder
+
+	b.WriteString(d.Name + "\n" + d.subtitle() + "\n\n")
+	for _, item := range d.Items {
+		fmt.Fprintf(&b, "%2d. %s", item.Position, item.Title)
+		for _, extra := range []string{item.Key, item.duration()} {
+			if extra != "" {
+				b.WriteString("  " + extra)
+			}
+		}
+		b.WriteByte('\n')
+	}
+
+	for _, item := range d.Items {
+		b.WriteString("\n" + strings.Repeat("=", 40) + "\n")
+		if item.Notes != "" {
+			b.WriteString("* " + item.Notes + "\n")
+		}
+		b.WriteString(item.Sheet.Text())
+	}
+
+	return b.String()
+}
+
+// HTML - страница для печати: порядок песен на первом листе, каждая песня с нового листа
+func (d Document) HTML() string {
+	var b strings.Builder
+
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	b.WriteString("<title>" + html.EscapeString(d.Name) + "</title>\n")
+	b.WriteString("<style>\n" + chords.HTMLStyle + htmlStyle + "</style>\n</head>\n<body>\n")
+
+	b.WriteString("<div class=\"song order\">\n<h1 class=\"title\">" + html.EscapeString(d.Name) + "</h1>\n")
+	b.WriteString("<p class=\"meta\">" + html.EscapeString(d.subtitle()) + "</p>\n<table>\n")
+	for _, item := range d.Items {
+		fmt.Fprintf(&b, "<tr><td>%d</td><td>%s</td><td>%s</td><td>%s</td></tr>\n", item.Position,
+			html.EscapeString(item.Title), html.EscapeString(item.Key), item.duration())
+	}
+	b.WriteString("</table>\n</div>\n")
+
+	for _, item := range d.Items {
+		b.WriteString("<div class=\"song\">\n")
+		if item.Notes != "" {
+			b.WriteString("<p class=\"notes\">" + html.EscapeString(item.Notes) + "</p>\n")
+		}
+		b.WriteString(item.Sheet.HTMLFragment())
+		b.WriteString("</div>\n")
+	}
+
+	b.WriteString("</body>\n</html>\n")
+
+	return b.String()
+}
+
+const htmlStyle = `.song { page-break-before: always; }
+.song.order { page-break-before: auto; }
+.order td { padding: 0.2em 1em 0.2em 0; }
+.notes { font-style: italic; border-left: 3px solid #b00; padding-left: 0.5em; }
+`
@@ -0,0 +1,155 @@
+package songbook
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"songLibrary/chords"
+	"strings"
+)
+
+// Дополнение к chords.HTMLStyle для читалок: песня с новой страницы
+const epubStyle = `.song { page-break-before: always; }
+nav ol { list-style: none; padding-left: 0; }
+`
+
+const epubContainer = `<?xml version="1.0" encoding="utf-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+// EPUB собирает песенник EPUB 3: оглавление nav.xhtml (и toc.ncx для старых читалок),
+// каждая песня в отдельном файле
+func EPUB(book Book) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	// mimetype - первым файлом и без сжатия
+	mimetype, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(mimetype, "application/epub+zip"); err != nil {
+		return nil, err
+	}
+
+	title := html.EscapeString(book.Title)
+	files := []struct{ name, content string }{
+		{"META-INF/container.xml", epubContainer},
+		{"OEBPS/style.css", chords.HTMLStyle + epubStyle},
+		{"OEBPS/content.opf", epubPackage(book)},
+		{"OEBPS/nav.xhtml", epubNav(book)},
+		{"OEBPS/toc.ncx", epubNCX(book)},
+	}
+	for i, song := range book.Songs {
+		files = append(files, struct{ name, content string }{"OEBPS/" + songFile(i), xhtmlPage(title, "<div class=\"song\">\n"+song.HTMLFragment()+"</div>\n")})
+	}
+
+	for _, file := range files {
+		w, err := zw.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(w, file.content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func songFile(i int) string {
+	return fmt.Sprintf("song-%03d.xhtml", i+1)
+}
+
+// songLabel - название песни для оглавления
+func songLabel(i int, song chords.Sheet) string {
+	label := fmt.Sprintf("%d. %s", i+1, song.Title)
+	if song.Artist != "" {
+		label += " — " + song.Artist
+	}
+	return html.EscapeString(label)
+}
+
+func xhtmlPage(title, body string) string {
+	return `<?xml version="1.0" encoding="utf-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<head>
+<meta charset="utf-8"/>
+<title>` + title + `</title>
+<link rel="stylesheet" type="text/css" href="style.css"/>
+</head>
+<body>
+` + body + `</body>
+</html>
+`
+}
+
+func epubPackage(book Book) string {
+	var manifest, spine strings.Builder
+	for i := range book.Songs {
+		id := strings.TrimSuffix(songFile(i), ".xhtml")
+		fmt.Fprintf(&manifest, "    <item id=\"%s\" href=\"%s\" media-type=\"application/xhtml+xml\"/>\n", id, songFile(i))
+		fmt.Fprintf(&spine, "    <itemref idref=\"%s\"/>\n", id)
+	}
+
+	return `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="book-id">` + html.EscapeString(book.ID) + `</dc:identifier>
+    <dc:title>` + html.EscapeString(book.Title) + `</dc:title>
+    <dc:language>und</dc:language>
+    <meta property="dcterms:modified">` + book.Modified.UTC().Format("2006-01-02T15:04:05Z") + `</meta>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>
+    <item id="style" href="style.css" media-type="text/css"/>
+` + manifest.String() + `  </manifest>
+  <spine toc="ncx">
+    <itemref idref="nav"/>
+` + spine.String() + `  </spine>
+</package>
+`
+}
+
+func epubNav(book Book) string {
+	title := html.EscapeString(book.Title)
+
+	var b strings.Builder
+	b.WriteString("<h1>" + title + "</h1>\n<nav epub:type=\"toc\" id=\"toc\">\n<ol>\n")
+	for i, song := range book.Songs {
+		fmt.Fprintf(&b, "<li><a href=\"%s\">%s</a></li>\n", songFile(i), songLabel(i, song))
+	}
+	b.WriteString("</ol>\n</nav>\n")
+
+	return xhtmlPage(title, b.String())
+}
+
+func epubNCX(book Book) string {
+	var points strings.Builder
+	for i, song := range book.Songs {
+		fmt.Fprintf(&points, "    <navPoint id=\"p%d\" playOrder=\"%d\"><navLabel><text>%s</text></navLabel><content src=\"%s\"/></navPoint>\n",
+			i+1, i+1, songLabel(i, song), songFile(i))
+	}
+
+	return `<?xml version="1.0" encoding="utf-8"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+  <head>
+    <meta name="dtb:uid" content="` + html.EscapeString(book.ID) + `"/>
+  </head>
+  <docTitle><text>` + html.EscapeString(book.Title) + `</text></docTitle>
+  <navMap>
+` + points.String() + `  </navMap>
+</ncx>
+`
+}
@@ -0,0 +1,198 @@
+package songbook
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"os"
+	"songLibrary/chords"
+	"strconv"
+
+	"github.com/go-pdf/fpdf"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+const (
+	fontFamily = "songbook"
+	// Высота строки текста и строки аккордов, мм
+	lineHeight = 5.5
+	// Отступ припева, мм
+	chorusIndent = 8.0
+	// Колонка номеров страниц в оглавлении, мм
+	tocPageWidth = 15.0
+)
+
+// pdfWriter выводит песни в PDF шрифтом songbook
+type pdfWriter struct {
+	pdf *fpdf.Fpdf
+}
+
+// PDF собирает песенник A4: оглавление со ссылками, затем каждая песня с новой страницы
+func PDF(book Book, fonts Fonts) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(book.Title, true)
+	pdf.SetCreator("songLibrary", true)
+	pdf.SetMargins(20, 20, 20)
+	pdf.SetAutoPageBreak(true, 20)
+	pdf.SetCellMargin(0)
+
+	// Встроенные шрифты PDF знают только cp1252, кириллица в них выводится точками,
+	// поэтому без своего шрифта берутся шрифты Go с латиницей и кириллицей
+	regular, bold := goregular.TTF, gobold.TTF
+	if fonts.Regular != "" {
+		var err error
+		regular, err = os.ReadFile(fonts.Regular)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось прочитать шрифт: %w", err)
+		}
+		bold, err = os.ReadFile(cmp.Or(fonts.Bold, fonts.Regular))
+		if err != nil {
+			return nil, fmt.Errorf("не удалось прочитать шрифт: %w", err)
+		}
+	}
+	pdf.AddUTF8FontFromBytes(fontFamily, "", regular)
+	pdf.AddUTF8FontFromBytes(fontFamily, "B", bold)
+	if err := pdf.Error(); err != nil {
+		return nil, fmt.Errorf("не удалось загрузить шрифт: %w", err)
+	}
+
+	w := pdfWriter{pdf: pdf}
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		w.font("", 9)
+		pdf.CellFormat(0, 10, strconv.Itoa(pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+
+	// Оглавление. Номера страниц ещё неизвестны, вместо них алиасы, которые
+	// подставляются при выводе файла.
+	pdf.AddPage()
+	w.font("B", 20)
+	pdf.MultiCell(0, 10, book.Title, "", "C", false)
+	pdf.Ln(6)
+
+	pageWidth, _ := pdf.GetPageSize()
+	left, _, right, _ := pdf.GetMargins()
+	titleWidth := pageWidth - left - right - tocPageWidth
+
+	links := make([]int, len(book.Songs))
+	w.font("", 11)
+	for i, song := range book.Songs {
+		links[i] = pdf.AddLink()
+		title := fmt.Sprintf("%d. %s", i+1, song.Title)
+		if song.Artist != "" {
+			title += " — " + song.Artist
+		}
+		pdf.CellFormat(titleWidth, 7, w.fit(title, titleWidth), "", 0, "L", false, links[i], "")
+		pdf.CellFormat(tocPageWidth, 7, pageAlias(i), "", 1, "R", false, links[i], "")
+	}
+
+	for i, song := range book.Songs {
+		pdf.AddPage()
+		pdf.SetLink(links[i], 0, -1)
+		pdf.Bookmark(song.Title, 0, -1)
+		pdf.RegisterAlias(pageAlias(i), strconv.Itoa(pdf.PageNo()))
+		w.song(song)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func pageAlias(i int) string {
+	return fmt.Sprintf("{p%d}", i)
+}
+
+func (w pdfWriter) font(style string, size float64) {
+	w.pdf.SetFont(fontFamily, style, size)
+}
+
+// fit обрезает строку с многоточием, чтобы она поместилась в width
+func (w pdfWriter) fit(text string, width float64) string {
+	if w.pdf.GetStringWidth(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && w.pdf.GetStringWidth(string(runes)+"…") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
+
+// song выводит песню: заголовок, тональность, части с аккордами над строками
+func (w pdfWriter) song(sheet chords.Sheet) {
+	pdf := w.pdf
+	_, pageHeight := pdf.GetPageSize()
+	left, _, _, bottom := pdf.GetMargins()
+
+	w.font("B", 16)
+	pdf.MultiCell(0, 8, sheet.Title, "", "L", false)
+	w.font("", 12)
+	pdf.SetTextColor(85, 85, 85)
+	for _, line := range []string{sheet.Artist, sheet.Meta()} {
+		if line != "" {
+			pdf.MultiCell(0, 6, line, "", "L", false)
+		}
+	}
+	pdf.SetTextColor(0, 0, 0)
+	pdf.Ln(4)
+
+	for _, block := range sheet.Blocks {
+		x := left
+		if block.Env == chords.EnvChorus {
+			x += chorusIndent
+		}
+
+		if heading := block.Heading(); heading != "" {
+			w.font("B", 10)
+			pdf.SetX(x)
+			pdf.CellFormat(0, lineHeight, heading, "", 1, "L", false, 0, "")
+		}
+
+		for _, line := range block.Lines {
+			// Аккорды не должны оказаться на одной странице, а строка под ними - на другой
+			if len(line.Chords) > 0 && pdf.GetY()+2*lineHeight > pageHeight-bottom {
+				pdf.AddPage()
+			}
+			if len(line.Chords) > 0 {
+				w.chordLine(x, line)
+			}
+
+			w.font("", 11)
+			pdf.SetX(x)
+			if len(line.Chords) > 0 {
+				pdf.CellFormat(0, lineHeight, line.Text, "", 1, "L", false, 0, "")
+			} else {
+				pdf.MultiCell(0, lineHeight, line.Text, "", "L", false)
+			}
+		}
+		pdf.Ln(3)
+	}
+}
+
+// chordLine ставит аккорды над символами строки. Если аккорды не помещаются,
+// следующий сдвигается вправо, как в текстовом выводе.
+func (w pdfWriter) chordLine(x float64, line chords.Line) {
+	pdf := w.pdf
+	text := []rune(line.Text)
+	y := pdf.GetY()
+
+	pdf.SetTextColor(176, 0, 0)
+	end := x
+	for _, chord := range line.Chords {
+		w.font("", 11)
+		at := max(x+pdf.GetStringWidth(string(text[:min(chord.Pos, len(text))])), end)
+
+		w.font("B", 10)
+		label := chord.Chord
+		pdf.SetXY(at, y)
+		pdf.CellFormat(pdf.GetStringWidth(label), lineHeight, label, "", 0, "L", false, 0, "")
+		end = at + pdf.GetStringWidth(label+" ")
+	}
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetXY(x, y+lineHeight)
+}
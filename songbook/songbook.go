@@ -0,0 +1,49 @@
+package songbook
+
+import (
+	"fmt"
+	"songLibrary/chords"
+	"time"
+)
+
+// Форматы песенника
+const (
+	FormatPDF  = "pdf"
+	FormatEPUB = "epub"
+)
+
+// Book - песенник: песни по порядку, у каждой аккорды или просто текст
+type Book struct {
+	// Уникальный идентификатор для EPUB
+	ID       string
+	Title    string
+	Modified time.Time
+	Songs    []chords.Sheet
+}
+
+// Fonts - TTF-шрифты для PDF. Без Regular используются шрифты Go из golang.org/x/image,
+// в них есть латиница и кириллица.
+type Fonts struct {
+	Regular string
+	Bold    string
+}
+
+// ContentType - Content-Type файла песенника
+func ContentType(format string) string {
+	if format == FormatEPUB {
+		return "application/epub+zip"
+	}
+	return "application/pdf"
+}
+
+// Render собирает песенник в одном из форматов
+func Render(book Book, format string, fonts Fonts) ([]byte, error) {
+	switch format {
+	case FormatPDF:
+		return PDF(book, fonts)
+	case FormatEPUB:
+		return EPUB(book)
+	default:
+		return nil, fmt.Errorf("неизвестный формат: %s", format)
+	}
+}
@@ -1,7 +1,7 @@
 package utils
 
 import (
-	"strings"
+	"songLibrary/lyrics"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -24,6 +24,7 @@ type ProblemDetails struct {
 	Type       string `json:"type" example:"/api/v1/..."`
 	Title      string `json:"title" example:"DB error"`
 	Detail     string `json:"detail" example:"описание ошибки"`
+	RequestID  string `json:"request_id,omitempty" example:"4f9d1c0e8a7b4e2c9d3f6a1b2c3d4e5f"`
 }
 
 func HttpResErrorRFC9457(title string, err error, statusCode int, log *log.Entry, c echo.Context) (int, ProblemDetails) {
@@ -37,10 +38,11 @@ func HttpResErrorRFC9457(title string, err error, statusCode int, log *log.Entry
 		Type:       c.Request().URL.String(),
 		Title:      title,
 		Detail:     err.Error(),
+		RequestID:  c.Response().Header().Get(echo.HeaderXRequestID),
 	}
 }
 
-// Хелпер для разбиения текста песни на куплеты
+// Хелпер для разбиения текста песни на куплеты, подробнее в lyrics.Parse
 func SplitIntoVerses(text string) []string {
-	return strings.Split(text, "\n\n") // Разделяем по двойным переносам строк
+	return lyrics.Verses(text)
 }